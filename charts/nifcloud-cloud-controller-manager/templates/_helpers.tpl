@@ -31,3 +31,17 @@ Selector labels
 app.kubernetes.io/name: {{ include "nifcloud-cloud-controller-manager.name" . }}
 app.kubernetes.io/instance: {{ .Release.Name }}
 {{- end }}
+
+{{/*
+Contents of the cloud config file, which reads the credentials from the mounted secrets if nifcloud.mountCredentials is true
+*/}}
+{{- define "nifcloud-cloud-controller-manager.cloudConfig" -}}
+{{- $cloudConfig := deepCopy .Values.cloudConfig }}
+{{- if .Values.nifcloud.mountCredentials }}
+{{- $global := get $cloudConfig "global" | default (dict) }}
+{{- $_ := set $global "accessKeyIDFile" "/etc/nifcloud-credentials/access_key_id" }}
+{{- $_ := set $global "secretAccessKeyFile" "/etc/nifcloud-credentials/secret_access_key" }}
+{{- $_ := set $cloudConfig "global" $global }}
+{{- end }}
+{{- toYaml $cloudConfig }}
+{{- end }}
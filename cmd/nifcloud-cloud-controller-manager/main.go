@@ -53,6 +53,9 @@ func cloudInitializer(config *cloudcontrollerconfig.CompletedConfig) cloudprovid
 	if cloud == nil {
 		klog.Fatal("cloud provider is nil")
 	}
+	if nifcloudCloud, ok := cloud.(*nifcloud.Cloud); ok {
+		nifcloudCloud.SetClusterName(config.ComponentConfig.KubeCloudShared.ClusterName)
+	}
 
 	return cloud
 }
@@ -1,9 +1,15 @@
 package nifcloud
 
 import (
-	"github.com/aws/aws-sdk-go-v2/aws"
+	"context"
+	"encoding/json"
+
 	"github.com/nifcloud/nifcloud-sdk-go/nifcloud"
-	"github.com/nifcloud/nifcloud-sdk-go/service/computing"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 )
 
 // nifcloud.go
@@ -16,23 +22,62 @@ func (c *Cloud) SetRegion(region string) {
 	c.region = region
 }
 
+func (c *Cloud) SetConfig(config CloudConfig) {
+	c.config = config
+}
+
+func (c *Cloud) SetKubeClient(kubeClient kubernetes.Interface) {
+	c.kubeClient = kubeClient
+}
+
+func (c *Cloud) SetEventRecorder(eventRecorder record.EventRecorder) {
+	c.eventRecorder = eventRecorder
+}
+
+func (c *Cloud) KubeClient() kubernetes.Interface {
+	return c.kubeClient
+}
+
+// nifcloud_config.go
+
+var ExportReadCloudConfig = readCloudConfig
+var ExportCloudConfigCredentials = (*CloudConfig).credentials
+
+// nifcloud_credentials.go
+
+var ExportNewCredentialsProvider = newCredentialsProvider
+var ExportWatchCredentials = (*credentialsProvider).watch
+
 // nifcloud_client.go
 
 type ExportNifcloudAPIClient = nifcloudAPIClient
 
 func NewNIFCLOUDAPIClientWithEndpoint(accessKeyID, secretAccessKey, region, endpoint string) *ExportNifcloudAPIClient {
-	cfg := nifcloud.NewConfig(accessKeyID, secretAccessKey, region)
-	cfg.EndpointResolverWithOptions = aws.EndpointResolverWithOptionsFunc(
-		func(_, region string, _ ...interface{}) (aws.Endpoint, error) {
-			return aws.Endpoint{
-				URL:           endpoint,
-				SigningRegion: region,
-			}, nil
-		},
+	return NewNIFCLOUDAPIClientWithGlobalConfig(accessKeyID, secretAccessKey, GlobalConfig{
+		Region:   region,
+		Endpoint: endpoint,
+	})
+}
+
+func NewNIFCLOUDAPIClientWithGlobalConfig(accessKeyID, secretAccessKey string, globalConfig GlobalConfig) *ExportNifcloudAPIClient {
+	return NewNIFCLOUDAPIClientWithCloudConfig(accessKeyID, secretAccessKey, &CloudConfig{Global: globalConfig})
+}
+
+// NewNIFCLOUDAPIClientWithCloudConfig returns the client which does not retry the failed requests
+// unless the retry config is given, not to make the tests wait for the backoff.
+func NewNIFCLOUDAPIClientWithCloudConfig(accessKeyID, secretAccessKey string, cloudConfig *CloudConfig) *ExportNifcloudAPIClient {
+	config := *cloudConfig
+	if config.Retry.MaxAttempts == 0 {
+		config.Retry.MaxAttempts = 1
+	}
+	client, err := newNIFCLOUDAPIClient(
+		nifcloud.NewStaticCredentialsProvider(accessKeyID, secretAccessKey),
+		&config,
 	)
-	return &ExportNifcloudAPIClient{
-		client: computing.NewFromConfig(cfg),
+	if err != nil {
+		panic(err)
 	}
+	return client
 }
 
 var ExportCreateLoadBalancer = (*ExportNifcloudAPIClient).createLoadBalancer
@@ -40,15 +85,81 @@ var ExportRegisterPortWithLoadBalancer = (*ExportNifcloudAPIClient).registerPort
 var ExportCreateElasticLoadBalancer = (*ExportNifcloudAPIClient).createElasticLoadBalancer
 var ExportRegisterPortWithElasticLoadBalancer = (*ExportNifcloudAPIClient).registerPortWithElasticLoadBalancer
 
+// nifcloud_dry_run.go
+
+var ExportErrDryRunCreated = errDryRunCreated
+
+func NewDryRunClient(client CloudAPIClient, logf func(msg string, keysAndValues ...interface{})) CloudAPIClient {
+	c := newDryRunClient(client)
+	c.logf = logf
+	return c
+}
+
+// nifcloud_instance_cache.go
+
+type ExportInstanceCache = instanceCache
+
+var ExportNewInstanceCache = newInstanceCache
+var ExportInstanceCacheList = (*instanceCache).list
+var ExportInstanceCacheInvalidate = (*instanceCache).invalidate
+
 // nifcloud_instances.go
 
 var ExportGetInstance = (*Cloud).getInstance
 var ExportGetNodeAddress = getNodeAddress
+var ExportInstanceStatePhaseOf = func(state string) string { return string(instanceStatePhaseOf(state)) }
+
+// nifcloud_node_resolver.go
+
+var ExportInstanceByNodeName = (*Cloud).instanceByNodeName
+var ExportInstancesByNodes = (*Cloud).instancesByNodes
+
+// nifcloud_foreign_node.go
+
+var ExportLoadBalancerBackends = (*Cloud).loadBalancerBackends
+
+// nifcloud_node_labels.go
+
+var ExportInstanceLabels = instanceLabels
+var ExportSyncNodeLabels = (*Cloud).syncNodeLabels
 
 // nifcloud_load_balancer.go
 
 var ExportMaxLoadBalancerNameLength = maxLoadBalancerNameLength
+var ExportLoadBalancerName = loadBalancerName
+var ExportLegacyLoadBalancerName = legacyLoadBalancerName
+var ExportMigrateLoadBalancerName = (*Cloud).migrateLoadBalancerName
 var ExportValidateLoadBalancerAnnotations = validateLoadBalancerAnnotations
+var ExportWithLoadBalancerDefaults = (*Cloud).withLoadBalancerDefaults
+
+// nifcloud_load_balancer_owner.go
+
+var ExportLoadBalancerOwner = loadBalancerOwner
+var ExportVerifyLoadBalancerOwner = verifyLoadBalancerOwner
+var ExportCheckLoadBalancerOwner = (*Cloud).checkLoadBalancerOwner
+
+// NewLoadBalancerRecords returns the config map recording the owners keyed by the type and the name of the load balancers
+func NewLoadBalancerRecords(owners map[string]string) *v1.ConfigMap {
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: loadBalancerRecordsConfigMapName, Namespace: loadBalancerRecordsNamespace},
+		Data:       map[string]string{},
+	}
+	for key, owner := range owners {
+		configMap.Data[key] = string(lo.Must(json.Marshal(loadBalancerRecord{Owner: owner})))
+	}
+	return configMap
+}
+
+// LoadBalancerRecordOwners returns the owners recorded in the cluster keyed by the type and the name of the load balancers
+func LoadBalancerRecordOwners(ctx context.Context, c *Cloud) map[string]string {
+	records := lo.Must(c.loadBalancerRecords(ctx))
+	return lo.MapValues(records, func(record loadBalancerRecord, _ string) string { return record.Owner })
+}
+
+// nifcloud_load_balancer_gc.go
+
+var ExportCollectLoadBalancerGarbage = (*Cloud).collectLoadBalancerGarbage
+var ExportRecordSecurityGroupRulesToRevoke = (*Cloud).recordSecurityGroupRulesToRevoke
 
 // nifcloud_l4_load_balancer.go
 
@@ -59,12 +170,16 @@ var ExportUpdateL4LoadBalancer = (*Cloud).updateL4LoadBalancer
 var ExportEnsureL4LoadBalancerDeleted = (*Cloud).ensureL4LoadBalancerDeleted
 var ExportFindL4LoadBalancer = findL4LoadBalancer
 var ExportL4LoadBalancerDifferences = l4LoadBalancerDifferences
+var ExportL4LoadBalancerHealthCheckEquals = l4LoadBalancerHealthCheckEquals
+var ExportL4LoadBalancerSpecUpdate = l4LoadBalancerSpecUpdate
 var ExportL4LoadBalancingTargetsDifferences = l4LoadBalancingTargetsDifferences
 var ExportFilterDifferences = filterDifferences
+var ExportFilterIPAddressOf = filterIPAddressOf
 
 // nifcloud_elastic_load_balancer.go
 
 var ExportGetElasticLoadBalancer = (*Cloud).getElasticLoadBalancer
+var ExportCheckElasticLoadBalancerSourceRanges = (*Cloud).checkElasticLoadBalancerSourceRanges
 var ExportEnsureElasticLoadBalancer = (*Cloud).ensureElasticLoadBalancer
 var ExportUpdateElasticLoadBalancer = (*Cloud).updateElasticLoadBalancer
 var ExportEnsureElasticLoadBalancerDeleted = (*Cloud).ensureElasticLoadBalancerDeleted
@@ -72,14 +187,24 @@ var ExportSecurityGroupRulesOfElasticLoadBalancer = securityGroupRulesOfElasticL
 var ExportSeparateHealthCheckTarget = separateHealthCheckTarget
 var ExportFindElasticLoadBalancer = findElasticLoadBalancer
 var ExportElasticLoadBalancerDifferences = elasticLoadBalancerDifferences
+var ExportElasticLoadBalancerListenerEquals = elasticLoadBalancerListenerEquals
+var ExportElasticLoadBalancerHealthCheckEquals = elasticLoadBalancerHealthCheckEquals
+var ExportElasticLoadBalancerSpecUpdate = elasticLoadBalancerSpecUpdate
+var ExportSecurityGroupRuleDifferences = securityGroupRuleDifferences
 var ExportElasticLoadBalancingTargetsDifferences = elasticLoadBalancingTargetsDifferences
 
+// nifcloud_metrics.go
+
+var ExportNIFCLOUDAPIMetric = nifcloudAPIMetric
+var ExportNIFCLOUDAPIErrorMetric = nifcloudAPIErrorMetric
+var ExportNIFCLOUDAPIRetryMetric = nifcloudAPIRetryMetric
+var ExportNIFCLOUDAPIRateLimiterMetric = nifcloudAPIRateLimiterMetric
+
+// nifcloud_retry.go
+
+var ExportClassifyRetry = func(err error) string { return string(classifyRetry(err)) }
+var ExportNewRetryer = newRetryer
+
 // nifcloud_error_code.go
 
-const (
-	ExportErrorCodeInstanceNotFound             = errorCodeInstanceNotFound
-	ExportErrorCodeLoadBalancerNotFound         = errorCodeLoadBalancerNotFound
-	ExportErrorCodeElasticLoadBalancerNotFound  = errorCodeElasticLoadBalancerNotFound
-	ExportErrorCodeSecurityGroupIngressNotFound = errorCodeSecurityGroupIngressNotFound
-	ExportErrorCodeSecurityGroupDuplicate       = errorCodeSecurityGroupDuplicate
-)
+var ExportErrorCategoryNameOf = errorCategoryNameOf
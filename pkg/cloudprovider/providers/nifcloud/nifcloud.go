@@ -3,9 +3,15 @@ package nifcloud
 import (
 	"fmt"
 	"io"
-	"os"
+	"sync"
 
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
 	cloudprovider "k8s.io/cloud-provider"
+	"k8s.io/klog/v2"
 )
 
 // ProviderName is the name of this cloud provider
@@ -13,34 +19,56 @@ const ProviderName = "nifcloud"
 
 // Cloud is an implementation of Interface, LoadBalancer and Instances for NIFCLOUD
 type Cloud struct {
-	client CloudAPIClient
-	region string
+	client      CloudAPIClient
+	region      string
+	config      CloudConfig
+	credentials *credentialsProvider
+	kubeClient  kubernetes.Interface
+	// eventRecorder records the events of the services
+	eventRecorder record.EventRecorder
+	// clusterName is --cluster-name of the controller manager
+	clusterName string
+
+	// warnedServices is the warnings already recorded as events of the services
+	warnedServices sync.Map
+
+	// routesLock serializes the changes of the route table
+	routesLock sync.Mutex
 }
 
 func init() {
 	registerMetrics()
-	cloudprovider.RegisterCloudProvider(ProviderName, func(_ io.Reader) (cloudprovider.Interface, error) {
-		return newNIFCLOUD()
+	cloudprovider.RegisterCloudProvider(ProviderName, func(config io.Reader) (cloudprovider.Interface, error) {
+		cfg, err := readCloudConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return newNIFCLOUD(cfg)
 	})
 }
 
-func newNIFCLOUD() (cloudprovider.Interface, error) {
-	accessKeyID := os.Getenv("NIFCLOUD_ACCESS_KEY_ID")
-	secretAccessKey := os.Getenv("NIFCLOUD_SECRET_ACCESS_KEY")
-	region := os.Getenv("NIFCLOUD_REGION")
-	if accessKeyID == "" {
-		return nil, fmt.Errorf(`environment variable "NIFCLOUD_ACCESS_KEY_ID" is required`)
+func newNIFCLOUD(cfg *CloudConfig) (cloudprovider.Interface, error) {
+	credentials, err := newCredentialsProvider(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
 	}
-	if secretAccessKey == "" {
-		return nil, fmt.Errorf(`environment variable "NIFCLOUD_SECRET_ACCESS_KEY" is required`)
+
+	apiClient, err := newNIFCLOUDAPIClient(credentials, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create NIFCLOUD API client: %w", err)
 	}
-	if region == "" {
-		return nil, fmt.Errorf(`environment variable "NIFCLOUD_REGION" is required`)
+
+	var client CloudAPIClient = apiClient
+	if cfg.DryRun {
+		klog.Warning("Dry run mode is enabled. No NIFCLOUD resources are created, updated or deleted")
+		client = newDryRunClient(apiClient)
 	}
 
 	return &Cloud{
-		client: newNIFCLOUDAPIClient(accessKeyID, secretAccessKey, region),
-		region: region,
+		client:      client,
+		region:      cfg.Global.Region,
+		config:      *cfg,
+		credentials: credentials,
 	}, nil
 }
 
@@ -48,6 +76,57 @@ func newNIFCLOUD() (cloudprovider.Interface, error) {
 // to perform housekeeping or run custom controllers specific to the cloud provider.
 // Any tasks started here should be cleaned up when the stop channel closes.
 func (c *Cloud) Initialize(clientBuilder cloudprovider.ControllerClientBuilder, stop <-chan struct{}) {
+	c.kubeClient = clientBuilder.ClientOrDie("nifcloud-cloud-provider")
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: c.kubeClient.CoreV1().Events("")})
+	c.eventRecorder = broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "nifcloud-cloud-provider"})
+	go func() {
+		<-stop
+		broadcaster.Shutdown()
+	}()
+
+	if c.credentials != nil {
+		go func() {
+			if err := c.credentials.watch(stop); err != nil {
+				klog.Errorf("Stopped reloading NIFCLOUD credentials: %v", err)
+			}
+		}()
+	}
+
+	if len(c.config.Node.Labels.Attributes) > 0 {
+		go c.runNodeLabelController(stop)
+	}
+
+	if c.config.LoadBalancerGC.Enabled {
+		if c.clusterName == "" {
+			klog.Error("Load balancer garbage collector is not started: the cluster name is unknown")
+		} else {
+			go c.runLoadBalancerGC(stop)
+		}
+	}
+}
+
+// warnService logs the warning and records it as an event of the service
+func (c *Cloud) warnService(service *v1.Service, reason, message string) {
+	klog.Warningf("Service %s/%s: %s", service.Namespace, service.Name, message)
+	if c.eventRecorder != nil {
+		c.eventRecorder.Event(service, v1.EventTypeWarning, reason, message)
+	}
+}
+
+// warnServiceOnce records the warning as an event of the service only once,
+// for the warning which would be repeated on every sync
+func (c *Cloud) warnServiceOnce(service *v1.Service, reason, message string) {
+	if _, warned := c.warnedServices.LoadOrStore(string(service.UID)+"/"+reason+"/"+message, struct{}{}); warned {
+		return
+	}
+	c.warnService(service, reason, message)
+}
+
+// SetClusterName sets --cluster-name of the controller manager, which is not passed to the cloud provider by Initialize
+func (c *Cloud) SetClusterName(clusterName string) {
+	c.clusterName = clusterName
 }
 
 // LoadBalancer returns an implementation of LoadBalancer for NIFCLOUD
@@ -75,8 +154,12 @@ func (c *Cloud) Clusters() (cloudprovider.Clusters, bool) {
 }
 
 // Routes returns a routes interface along with whether the interface is supported.
+// Routes are supported only if the router is configured
 func (c *Cloud) Routes() (cloudprovider.Routes, bool) {
-	return nil, false
+	if c.config.Route.RouterName == "" {
+		return nil, false
+	}
+	return c, true
 }
 
 // ProviderName returns the cloud provider ID.
@@ -3,30 +3,41 @@ package nifcloud
 
 import (
 	"context"
-	"errors"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
 	"sort"
 	"strconv"
 	"time"
 
 	"golang.org/x/exp/slices"
 
-	"github.com/aws/smithy-go"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/nifcloud/nifcloud-sdk-go/nifcloud"
 	"github.com/nifcloud/nifcloud-sdk-go/service/computing"
 	"github.com/nifcloud/nifcloud-sdk-go/service/computing/types"
 	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/wait"
 	cloudprovider "k8s.io/cloud-provider"
 )
 
 const (
-	// only support filter type: 1 (allow CIDRs)
-	loadBalancerFilterType = "1"
+	// filter types of l4 load balancer: 1 allows and 2 denies the IP addresses of the filter
+	loadBalancerFilterTypeAllow = "1"
+	loadBalancerFilterTypeDeny  = "2"
 
 	filterAnyIPAddresses = "*.*.*.*"
 
-	securityGroupAppliedWaiterTimeout       = 3 * time.Minute
-	elasticLoadBalancerAppliedWaiterTimeout = 10 * time.Minute
+	defaultSecurityGroupAppliedWaiterTimeout       = 3 * time.Minute
+	defaultElasticLoadBalancerAppliedWaiterTimeout = 10 * time.Minute
+	defaultRouterAppliedWaiterTimeout              = 5 * time.Minute
+
+	routerAppliedWaiterInterval = 10 * time.Second
+	routerStateAvailable        = "available"
 )
 
 // Instance is instance detail
@@ -38,6 +49,20 @@ type Instance struct {
 	PrivateIPAddress string
 	Zone             string
 	State            string
+	Description      string
+	AccountingType   string
+	ImageID          string
+	Tenancy          string
+	// NetworkInterfaces is empty if the instance is not fetched from the API
+	NetworkInterfaces []InstanceNetworkInterface
+}
+
+// InstanceNetworkInterface is network interface detail of instance
+type InstanceNetworkInterface struct {
+	// NetworkID is net-COMMON_GLOBAL, net-COMMON_PRIVATE or network ID of private LAN
+	NetworkID string
+	IPAddress string
+	DNSName   string
 }
 
 // LoadBalancer is load balancer detail
@@ -54,7 +79,11 @@ type LoadBalancer struct {
 	HealthCheckTarget             string
 	HealthCheckInterval           int32
 	HealthCheckUnhealthyThreshold int32
+	FilterType                    string
 	Filters                       []string
+	// Description is the memo of the load balancer.
+	// It can not be written by API, so only the one set in the control panel is described
+	Description string
 }
 
 // Filter is load balancer filter detail
@@ -79,6 +108,8 @@ type ElasticLoadBalancer struct {
 	HealthCheckInterval           int32
 	HealthCheckUnhealthyThreshold int32
 	NetworkInterfaces             []NetworkInterface
+	// Description is the description of the listener
+	Description string
 }
 
 // NetworkInterface is network interface detail
@@ -106,6 +137,20 @@ type SecurityGroupRule struct {
 	IpRanges   []string
 }
 
+// RouteTable is the route table associated with a router
+type RouteTable struct {
+	RouteTableID string
+	Routes       []Route
+}
+
+// Route is static route detail
+type Route struct {
+	DestinationCIDR string
+	// IPAddress is the next hop
+	IPAddress string
+	NetworkID string
+}
+
 // Equals method checks whether specified instance is the same
 func (i *Instance) Equals(other Instance) bool {
 	if i.InstanceUniqueID != "" && other.InstanceUniqueID != "" {
@@ -135,6 +180,16 @@ func (lb *ElasticLoadBalancer) String() string {
 	return fmt.Sprintf("%s (%d -> %d)", lb.Name, lb.LoadBalancerPort, lb.InstancePort)
 }
 
+// Equals method checks whether specified security group rule is the same
+func (r *SecurityGroupRule) Equals(other SecurityGroupRule) bool {
+	return r.IpProtocol == other.IpProtocol &&
+		r.FromPort == other.FromPort &&
+		r.ToPort == other.ToPort &&
+		r.InOut == other.InOut &&
+		slices.Equal(r.IpRanges, other.IpRanges) &&
+		slices.Equal(r.Groups, other.Groups)
+}
+
 func (r *SecurityGroupRule) String() string {
 	if len(r.Groups) > 0 {
 		return fmt.Sprintf("%s %s [%d-%d] : %s", r.InOut, r.IpProtocol, r.FromPort, r.ToPort, r.Groups)
@@ -145,50 +200,154 @@ func (r *SecurityGroupRule) String() string {
 // CloudAPIClient is interface
 type CloudAPIClient interface {
 	// Instance
+	DescribeInstances(ctx context.Context) ([]Instance, error)
 	DescribeInstancesByInstanceID(ctx context.Context, instanceIDs []string) ([]Instance, error)
 	DescribeInstancesByInstanceUniqueID(ctx context.Context, instanceUniqueIDs []string) ([]Instance, error)
 
 	// LoadBalancer
 	DescribeLoadBalancers(ctx context.Context, name string) ([]LoadBalancer, error)
+	DescribeAllLoadBalancers(ctx context.Context) ([]LoadBalancer, error)
 	CreateLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer) (string, error)
 	RegisterPortWithLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer) error
+	ConfigureHealthCheck(ctx context.Context, loadBalancer *LoadBalancer) error
 	DeleteLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer) error
 	RegisterInstancesWithLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer, instances []Instance) error
 	DeregisterInstancesFromLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer, instances []Instance) error
 	SetFilterForLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer, filters []Filter) error
+	UpdateLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer) error
+	UpdateLoadBalancerName(ctx context.Context, name, newName string) error
 
 	// ElasticLoadBalancer
 	DescribeElasticLoadBalancers(ctx context.Context, name string) ([]ElasticLoadBalancer, error)
+	DescribeAllElasticLoadBalancers(ctx context.Context) ([]ElasticLoadBalancer, error)
 	CreateElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer) (string, error)
 	RegisterPortWithElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer) error
 	ConfigureElasticLoadBalancerHealthCheck(ctx context.Context, elasticLoadBalancer *ElasticLoadBalancer) error
+	WaitElasticLoadBalancerApplied(ctx context.Context, elasticLoadBalancerName string) error
 	DeleteElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer) error
 	RegisterInstancesWithElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer, instances []Instance) error
 	DeregisterInstancesFromElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer, instances []Instance) error
+	UpdateElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer) error
+	UpdateElasticLoadBalancerName(ctx context.Context, name, newName string) error
 
 	// SecurityGroup
 	DescribeSecurityGroupsByInstanceIDs(ctx context.Context, instanceIDs []string) ([]SecurityGroup, error)
 	AuthorizeSecurityGroupIngress(ctx context.Context, securityGroupName string, securityGroupRule *SecurityGroupRule) error
 	RevokeSecurityGroupIngress(ctx context.Context, securityGroupName string, securityGroupRule *SecurityGroupRule) error
 	WaitSecurityGroupApplied(ctx context.Context, securityGroupName string) error
+
+	// Router
+	DescribeRouterRouteTable(ctx context.Context, routerName string) (*RouteTable, error)
+	CreateRoute(ctx context.Context, routeTableID string, route *Route) error
+	DeleteRoute(ctx context.Context, routeTableID string, destinationCIDR string) error
+	WaitRouterApplied(ctx context.Context, routerName string) error
 }
 
 type nifcloudAPIClient struct {
-	client *computing.Client
+	client        *computing.Client
+	instanceCache *instanceCache
+
+	securityGroupAppliedWaiterTimeout       time.Duration
+	elasticLoadBalancerAppliedWaiterTimeout time.Duration
+	routerAppliedWaiterTimeout              time.Duration
 }
 
-func newNIFCLOUDAPIClient(accessKeyID, secretAccessKey, region string) CloudAPIClient {
-	cfg := nifcloud.NewConfig(accessKeyID, secretAccessKey, region)
-	return &nifcloudAPIClient{
-		client: computing.NewFromConfig(cfg),
+func newNIFCLOUDAPIClient(credentials aws.CredentialsProvider, cloudConfig *CloudConfig) (*nifcloudAPIClient, error) {
+	cfg := nifcloud.NewConfig("", "", cloudConfig.Global.Region)
+	// the provider is called on signing every request, so the credentials can be swapped without recreating the client
+	cfg.Credentials = credentials
+
+	httpClient, err := newHTTPClient(cloudConfig.Global)
+	if err != nil {
+		return nil, err
+	}
+	cfg.HTTPClient = httpClient
+	cfg.Retryer = func() aws.Retryer {
+		return newRetryer(cloudConfig.Retry)
+	}
+	cfg.APIOptions = append(cfg.APIOptions, addMetricsMiddleware, addErrorCategoryMiddleware, newAPIRateLimiter(cloudConfig.RateLimit).addMiddleware)
+
+	if endpoint := cloudConfig.Global.Endpoint; endpoint != "" {
+		cfg.EndpointResolverWithOptions = aws.EndpointResolverWithOptionsFunc(
+			func(_, region string, _ ...interface{}) (aws.Endpoint, error) {
+				return aws.Endpoint{
+					URL:           endpoint,
+					SigningRegion: region,
+				}, nil
+			},
+		)
+	}
+
+	c := &nifcloudAPIClient{
+		client:                                  computing.NewFromConfig(cfg),
+		securityGroupAppliedWaiterTimeout:       defaultSecurityGroupAppliedWaiterTimeout,
+		elasticLoadBalancerAppliedWaiterTimeout: defaultElasticLoadBalancerAppliedWaiterTimeout,
+		routerAppliedWaiterTimeout:              defaultRouterAppliedWaiterTimeout,
+	}
+	if timeout := cloudConfig.Waiter.SecurityGroupAppliedTimeout.Duration; timeout > 0 {
+		c.securityGroupAppliedWaiterTimeout = timeout
+	}
+	if timeout := cloudConfig.Waiter.ElasticLoadBalancerAppliedTimeout.Duration; timeout > 0 {
+		c.elasticLoadBalancerAppliedWaiterTimeout = timeout
 	}
+	if timeout := cloudConfig.Waiter.RouterAppliedTimeout.Duration; timeout > 0 {
+		c.routerAppliedWaiterTimeout = timeout
+	}
+	c.instanceCache = newInstanceCache(cloudConfig.Cache.InstanceTTL.Duration, c.describeAllInstances)
+
+	return c, nil
+}
+
+func newHTTPClient(globalConfig GlobalConfig) (*awshttp.BuildableClient, error) {
+	httpClient := awshttp.NewBuildableClient()
+
+	if globalConfig.ProxyURL != "" {
+		proxyURL, err := url.Parse(globalConfig.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy url %q: %w", globalConfig.ProxyURL, err)
+		}
+		httpClient = httpClient.WithTransportOptions(func(tr *http.Transport) {
+			tr.Proxy = http.ProxyURL(proxyURL)
+		})
+	}
+
+	if globalConfig.CABundleFile != "" {
+		caBundle, err := os.ReadFile(globalConfig.CABundleFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca bundle %s: %w", globalConfig.CABundleFile, err)
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("no valid certificate is found in ca bundle %s", globalConfig.CABundleFile)
+		}
+		httpClient = httpClient.WithTransportOptions(func(tr *http.Transport) {
+			if tr.TLSClientConfig == nil {
+				tr.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			}
+			tr.TLSClientConfig.RootCAs = rootCAs
+		})
+	}
+
+	if timeout := globalConfig.RequestTimeout.Duration; timeout > 0 {
+		httpClient = httpClient.WithTimeout(timeout)
+	}
+
+	return httpClient, nil
+}
+
+// DescribeInstances returns all instances in the region from the instance cache
+func (c *nifcloudAPIClient) DescribeInstances(ctx context.Context) ([]Instance, error) {
+	instances, _, err := c.instanceCache.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return instances, nil
 }
 
 func (c *nifcloudAPIClient) DescribeInstancesByInstanceID(ctx context.Context, instanceIDs []string) ([]Instance, error) {
 	res, err := c.client.DescribeInstances(ctx, &computing.DescribeInstancesInput{InstanceId: instanceIDs})
 	if err != nil {
-		var awsErr smithy.APIError
-		if errors.As(err, &awsErr) && awsErr.ErrorCode() == "Client.InvalidParameterNotFound.Instance" {
+		if IsNotFound(err) {
 			return nil, cloudprovider.InstanceNotFound
 		}
 		return nil, fmt.Errorf("failed to call DescribeInstances with instance ids %v: %w", instanceIDs, err)
@@ -199,16 +358,7 @@ func (c *nifcloudAPIClient) DescribeInstancesByInstanceID(ctx context.Context, i
 		if len(rs.InstancesSet) == 0 {
 			return nil, fmt.Errorf("instances set is empty")
 		}
-		instance := rs.InstancesSet[0]
-		instances = append(instances, Instance{
-			InstanceID:       nifcloud.ToString(instance.InstanceId),
-			InstanceUniqueID: nifcloud.ToString(instance.InstanceUniqueId),
-			InstanceType:     nifcloud.ToString(instance.InstanceType),
-			PublicIPAddress:  nifcloud.ToString(instance.IpAddress),
-			PrivateIPAddress: nifcloud.ToString(instance.PrivateIpAddress),
-			Zone:             nifcloud.ToString(instance.Placement.AvailabilityZone),
-			State:            nifcloud.ToString(instance.InstanceState.Name),
-		})
+		instances = append(instances, newInstance(rs.InstancesSet[0]))
 	}
 
 	if len(instances) == 0 {
@@ -218,10 +368,36 @@ func (c *nifcloudAPIClient) DescribeInstancesByInstanceID(ctx context.Context, i
 	return instances, nil
 }
 
+// DescribeInstancesByInstanceUniqueID returns the instances from the instance cache.
+// If any of them is not found, the cache is refreshed once since the instance may be created after the last fetch.
 func (c *nifcloudAPIClient) DescribeInstancesByInstanceUniqueID(ctx context.Context, instanceUniqueIDs []string) ([]Instance, error) {
+	all, fetched, err := c.instanceCache.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := filterInstancesByInstanceUniqueID(all, instanceUniqueIDs)
+	if len(instances) < len(instanceUniqueIDs) && !fetched {
+		c.instanceCache.invalidate()
+		all, _, err = c.instanceCache.list(ctx)
+		if err != nil {
+			return nil, err
+		}
+		instances = filterInstancesByInstanceUniqueID(all, instanceUniqueIDs)
+	}
+
+	if len(instances) == 0 {
+		return nil, cloudprovider.InstanceNotFound
+	}
+
+	return instances, nil
+}
+
+// describeAllInstances returns all instances in the region
+func (c *nifcloudAPIClient) describeAllInstances(ctx context.Context) ([]Instance, error) {
 	res, err := c.client.DescribeInstances(ctx, nil)
 	if err != nil {
-		if IsAPIError(err, errorCodeInstanceNotFound) {
+		if IsNotFound(err) {
 			return nil, cloudprovider.InstanceNotFound
 		}
 		return nil, fmt.Errorf("failed to call DescribeInstances API: %w", err)
@@ -232,26 +408,51 @@ func (c *nifcloudAPIClient) DescribeInstancesByInstanceUniqueID(ctx context.Cont
 		if len(rs.InstancesSet) == 0 {
 			return nil, fmt.Errorf("instances set is empty")
 		}
-		instance := rs.InstancesSet[0]
-		if !lo.Contains(instanceUniqueIDs, nifcloud.ToString(instance.InstanceUniqueId)) {
-			continue
-		}
-		instances = append(instances, Instance{
-			InstanceID:       nifcloud.ToString(instance.InstanceId),
-			InstanceUniqueID: nifcloud.ToString(instance.InstanceUniqueId),
-			InstanceType:     nifcloud.ToString(instance.InstanceType),
-			PublicIPAddress:  nifcloud.ToString(instance.IpAddress),
-			PrivateIPAddress: nifcloud.ToString(instance.PrivateIpAddress),
-			Zone:             nifcloud.ToString(instance.Placement.AvailabilityZone),
-			State:            nifcloud.ToString(instance.InstanceState.Name),
-		})
+		instances = append(instances, newInstance(rs.InstancesSet[0]))
 	}
 
-	if len(instances) == 0 {
-		return nil, cloudprovider.InstanceNotFound
+	return instances, nil
+}
+
+func filterInstancesByInstanceUniqueID(instances []Instance, instanceUniqueIDs []string) []Instance {
+	return lo.Filter(instances, func(instance Instance, _ int) bool {
+		return lo.Contains(instanceUniqueIDs, instance.InstanceUniqueID)
+	})
+}
+
+func newInstance(instance types.InstancesSet) Instance {
+	return Instance{
+		InstanceID:        nifcloud.ToString(instance.InstanceId),
+		InstanceUniqueID:  nifcloud.ToString(instance.InstanceUniqueId),
+		InstanceType:      nifcloud.ToString(instance.InstanceType),
+		PublicIPAddress:   nifcloud.ToString(instance.IpAddress),
+		PrivateIPAddress:  nifcloud.ToString(instance.PrivateIpAddress),
+		Zone:              nifcloud.ToString(instance.Placement.AvailabilityZone),
+		State:             nifcloud.ToString(instance.InstanceState.Name),
+		Description:       nifcloud.ToString(instance.Description),
+		AccountingType:    nifcloud.ToString(instance.AccountingType),
+		ImageID:           nifcloud.ToString(instance.ImageId),
+		Tenancy:           nifcloud.ToString(instance.Tenancy),
+		NetworkInterfaces: newInstanceNetworkInterfaces(instance.NetworkInterfaceSet),
 	}
+}
 
-	return instances, nil
+func newInstanceNetworkInterfaces(networkInterfaceSet []types.NetworkInterfaceSetOfDescribeInstances) []InstanceNetworkInterface {
+	networkInterfaces := []InstanceNetworkInterface{}
+	for _, nic := range networkInterfaceSet {
+		networkInterface := InstanceNetworkInterface{
+			NetworkID: nifcloud.ToString(nic.NiftyNetworkId),
+			IPAddress: nifcloud.ToString(nic.PrivateIpAddress),
+			DNSName:   nifcloud.ToString(nic.PrivateDnsName),
+		}
+		// the address of the global network is given as the association
+		if networkInterface.NetworkID == commonGlobalNetworkID && nic.Association != nil {
+			networkInterface.IPAddress = nifcloud.ToString(nic.Association.PublicIp)
+			networkInterface.DNSName = nifcloud.ToString(nic.Association.PublicDnsName)
+		}
+		networkInterfaces = append(networkInterfaces, networkInterface)
+	}
+	return networkInterfaces
 }
 
 func (c *nifcloudAPIClient) DescribeLoadBalancers(ctx context.Context, name string) ([]LoadBalancer, error) {
@@ -269,8 +470,21 @@ func (c *nifcloudAPIClient) DescribeLoadBalancers(ctx context.Context, name stri
 		return nil, fmt.Errorf("could not fetch load balancers info for %q: %w", name, err)
 	}
 
+	return toLoadBalancers(res.DescribeLoadBalancersResult.LoadBalancerDescriptions), nil
+}
+
+func (c *nifcloudAPIClient) DescribeAllLoadBalancers(ctx context.Context) ([]LoadBalancer, error) {
+	res, err := c.client.DescribeLoadBalancers(ctx, &computing.DescribeLoadBalancersInput{})
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch load balancers info: %w", err)
+	}
+
+	return toLoadBalancers(res.DescribeLoadBalancersResult.LoadBalancerDescriptions), nil
+}
+
+func toLoadBalancers(lbDescs []types.LoadBalancerDescriptions) []LoadBalancer {
 	result := []LoadBalancer{}
-	for _, lbDesc := range res.DescribeLoadBalancersResult.LoadBalancerDescriptions {
+	for _, lbDesc := range lbDescs {
 		lb := LoadBalancer{
 			Name:                          nifcloud.ToString(lbDesc.LoadBalancerName),
 			VIP:                           nifcloud.ToString(lbDesc.DNSName),
@@ -283,6 +497,7 @@ func (c *nifcloudAPIClient) DescribeLoadBalancers(ctx context.Context, name stri
 			HealthCheckTarget:             nifcloud.ToString(lbDesc.HealthCheck.Target),
 			HealthCheckInterval:           nifcloud.ToInt32(lbDesc.HealthCheck.Interval),
 			HealthCheckUnhealthyThreshold: nifcloud.ToInt32(lbDesc.HealthCheck.UnhealthyThreshold),
+			Description:                   nifcloud.ToString(lbDesc.Description),
 		}
 
 		balancingTargets := []Instance{}
@@ -296,6 +511,11 @@ func (c *nifcloudAPIClient) DescribeLoadBalancers(ctx context.Context, name stri
 		}
 		lb.BalancingTargets = balancingTargets
 
+		lb.FilterType = nifcloud.ToString(lbDesc.Filter.FilterType)
+		if lb.FilterType == "" {
+			lb.FilterType = loadBalancerFilterTypeAllow
+		}
+
 		filters := []string{}
 		for _, filter := range lbDesc.Filter.IPAddresses {
 			if nifcloud.ToString(filter.IPAddress) == filterAnyIPAddresses {
@@ -308,7 +528,7 @@ func (c *nifcloudAPIClient) DescribeLoadBalancers(ctx context.Context, name stri
 		result = append(result, lb)
 	}
 
-	return result, nil
+	return result
 }
 
 func (c *nifcloudAPIClient) CreateLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer) (string, error) {
@@ -463,11 +683,16 @@ func (c *nifcloudAPIClient) SetFilterForLoadBalancer(ctx context.Context, loadBa
 		})
 	}
 
+	filterType := loadBalancer.FilterType
+	if filterType == "" {
+		filterType = loadBalancerFilterTypeAllow
+	}
+
 	input := &computing.SetFilterForLoadBalancerInput{
 		LoadBalancerName: nifcloud.String(loadBalancer.Name),
 		LoadBalancerPort: nifcloud.Int32(loadBalancer.LoadBalancerPort),
 		InstancePort:     nifcloud.Int32(loadBalancer.InstancePort),
-		FilterType:       types.FilterTypeOfSetFilterForLoadBalancerRequest(loadBalancerFilterType),
+		FilterType:       types.FilterTypeOfSetFilterForLoadBalancerRequest(filterType),
 		IPAddresses: &types.ListOfRequestIPAddresses{
 			Member: ipAddresses,
 		},
@@ -556,6 +781,45 @@ func (c *nifcloudAPIClient) DeleteLoadBalancer(ctx context.Context, loadBalancer
 	return nil
 }
 
+// UpdateLoadBalancer updates the network volume and the accounting type of the load balancer.
+// The zero values are not updated
+func (c *nifcloudAPIClient) UpdateLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer) error {
+	if loadBalancer == nil {
+		return fmt.Errorf("loadBalancer is nil")
+	}
+
+	input := &computing.UpdateLoadBalancerInput{
+		LoadBalancerName: nifcloud.String(loadBalancer.Name),
+	}
+	if loadBalancer.NetworkVolume != 0 {
+		input.NetworkVolumeUpdate = nifcloud.Int32(loadBalancer.NetworkVolume)
+	}
+	if loadBalancer.AccountingType != "" {
+		accountingType, err := strconv.Atoi(loadBalancer.AccountingType)
+		if err != nil {
+			return fmt.Errorf("invalid accounting type %q: %w", loadBalancer.AccountingType, err)
+		}
+		input.AccountingTypeUpdate = nifcloud.Int32(int32(accountingType))
+	}
+	if _, err := c.client.UpdateLoadBalancer(ctx, input); err != nil {
+		return fmt.Errorf("failed to update load balancer %q: %w", loadBalancer.Name, err)
+	}
+
+	return nil
+}
+
+func (c *nifcloudAPIClient) UpdateLoadBalancerName(ctx context.Context, name, newName string) error {
+	input := &computing.UpdateLoadBalancerInput{
+		LoadBalancerName:       nifcloud.String(name),
+		LoadBalancerNameUpdate: nifcloud.String(newName),
+	}
+	if _, err := c.client.UpdateLoadBalancer(ctx, input); err != nil {
+		return fmt.Errorf("failed to rename load balancer %q to %q: %w", name, newName, err)
+	}
+
+	return nil
+}
+
 func (c *nifcloudAPIClient) DescribeElasticLoadBalancers(ctx context.Context, name string) ([]ElasticLoadBalancer, error) {
 	input := &computing.NiftyDescribeElasticLoadBalancersInput{
 		ElasticLoadBalancers: &types.RequestElasticLoadBalancers{
@@ -567,8 +831,21 @@ func (c *nifcloudAPIClient) DescribeElasticLoadBalancers(ctx context.Context, na
 		return nil, fmt.Errorf("could not fetch load balancers info for %q: %w", name, err)
 	}
 
+	return toElasticLoadBalancers(res.NiftyDescribeElasticLoadBalancersResult.ElasticLoadBalancerDescriptions)
+}
+
+func (c *nifcloudAPIClient) DescribeAllElasticLoadBalancers(ctx context.Context) ([]ElasticLoadBalancer, error) {
+	res, err := c.client.NiftyDescribeElasticLoadBalancers(ctx, &computing.NiftyDescribeElasticLoadBalancersInput{})
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch load balancers info: %w", err)
+	}
+
+	return toElasticLoadBalancers(res.NiftyDescribeElasticLoadBalancersResult.ElasticLoadBalancerDescriptions)
+}
+
+func toElasticLoadBalancers(elbDescs []types.ElasticLoadBalancerDescriptions) ([]ElasticLoadBalancer, error) {
 	result := []ElasticLoadBalancer{}
-	for _, elbDesc := range res.NiftyDescribeElasticLoadBalancersResult.ElasticLoadBalancerDescriptions {
+	for _, elbDesc := range elbDescs {
 		for _, listener := range elbDesc.ElasticLoadBalancerListenerDescriptions {
 			elb := ElasticLoadBalancer{
 				Name:                          nifcloud.ToString(elbDesc.ElasticLoadBalancerName),
@@ -582,6 +859,7 @@ func (c *nifcloudAPIClient) DescribeElasticLoadBalancers(ctx context.Context, na
 				HealthCheckTarget:             nifcloud.ToString(listener.Listener.HealthCheck.Target),
 				HealthCheckInterval:           nifcloud.ToInt32(listener.Listener.HealthCheck.Interval),
 				HealthCheckUnhealthyThreshold: nifcloud.ToInt32(listener.Listener.HealthCheck.UnhealthyThreshold),
+				Description:                   nifcloud.ToString(listener.Listener.Description),
 			}
 
 			networkVolume, err := strconv.Atoi(*elbDesc.NetworkVolume)
@@ -672,6 +950,9 @@ func (c *nifcloudAPIClient) createElasticLoadBalancer(ctx context.Context, elast
 	if elasticLoadBalancer.BalancingType != 0 {
 		input.Listeners.Member[0].BalancingType = nifcloud.Int32(elasticLoadBalancer.BalancingType)
 	}
+	if elasticLoadBalancer.Description != "" {
+		input.Listeners.Member[0].Description = nifcloud.String(elasticLoadBalancer.Description)
+	}
 	if elasticLoadBalancer.AccountingType != "" {
 		input.AccountingType = types.AccountingTypeOfNiftyCreateElasticLoadBalancerRequest(elasticLoadBalancer.AccountingType)
 	}
@@ -748,6 +1029,9 @@ func (c *nifcloudAPIClient) registerPortWithElasticLoadBalancer(ctx context.Cont
 	if elasticLoadBalancer.BalancingType != 0 {
 		input.Listeners.Member[0].BalancingType = nifcloud.Int32(elasticLoadBalancer.BalancingType)
 	}
+	if elasticLoadBalancer.Description != "" {
+		input.Listeners.Member[0].Description = nifcloud.String(elasticLoadBalancer.Description)
+	}
 
 	if _, err := c.client.NiftyRegisterPortWithElasticLoadBalancer(ctx, input); err != nil {
 		return fmt.Errorf("could not register port with load balancer %s: %w", elasticLoadBalancer.String(), err)
@@ -859,14 +1143,57 @@ func (c *nifcloudAPIClient) DeregisterInstancesFromElasticLoadBalancer(ctx conte
 	return nil
 }
 
-func (c *nifcloudAPIClient) WaitElasticLoadBalancerApplied(ctx context.Context, elasticLoadBalancerName string) error {
+// UpdateElasticLoadBalancer updates the network volume and the accounting type of the elastic load balancer.
+// The zero values are not updated
+func (c *nifcloudAPIClient) UpdateElasticLoadBalancer(ctx context.Context, elasticLoadBalancer *ElasticLoadBalancer) error {
+	if elasticLoadBalancer == nil {
+		return fmt.Errorf("loadBalancer is nil")
+	}
+
+	input := &computing.NiftyUpdateElasticLoadBalancerInput{
+		ElasticLoadBalancerName: nifcloud.String(elasticLoadBalancer.Name),
+	}
+	if elasticLoadBalancer.NetworkVolume != 0 {
+		input.NetworkVolumeUpdate = nifcloud.Int32(elasticLoadBalancer.NetworkVolume)
+	}
+	if elasticLoadBalancer.AccountingType != "" {
+		accountingType, err := strconv.Atoi(elasticLoadBalancer.AccountingType)
+		if err != nil {
+			return fmt.Errorf("invalid accounting type %q: %w", elasticLoadBalancer.AccountingType, err)
+		}
+		input.AccountingTypeUpdate = nifcloud.Int32(int32(accountingType))
+	}
+	if _, err := c.client.NiftyUpdateElasticLoadBalancer(ctx, input); err != nil {
+		return fmt.Errorf("failed to update elastic load balancer %q: %w", elasticLoadBalancer.Name, err)
+	}
+
+	return nil
+}
+
+func (c *nifcloudAPIClient) UpdateElasticLoadBalancerName(ctx context.Context, name, newName string) error {
+	input := &computing.NiftyUpdateElasticLoadBalancerInput{
+		ElasticLoadBalancerName:       nifcloud.String(name),
+		ElasticLoadBalancerNameUpdate: nifcloud.String(newName),
+	}
+	if _, err := c.client.NiftyUpdateElasticLoadBalancer(ctx, input); err != nil {
+		return fmt.Errorf("failed to rename elastic load balancer %q to %q: %w", name, newName, err)
+	}
+
+	return nil
+}
+
+func (c *nifcloudAPIClient) WaitElasticLoadBalancerApplied(ctx context.Context, elasticLoadBalancerName string) (err error) {
+	defer func(start time.Time) {
+		recordNIFCLOUDMetric("WaitElasticLoadBalancerApplied", time.Since(start).Seconds(), err)
+	}(time.Now())
+
 	waiter := computing.NewElasticLoadBalancerAvailableWaiter(c.client)
 	params := &computing.NiftyDescribeElasticLoadBalancersInput{
 		ElasticLoadBalancers: &types.RequestElasticLoadBalancers{
 			ListOfRequestElasticLoadBalancerName: []string{elasticLoadBalancerName},
 		},
 	}
-	if err := waiter.Wait(ctx, params, elasticLoadBalancerAppliedWaiterTimeout); err != nil {
+	if err := waiter.Wait(ctx, params, c.elasticLoadBalancerAppliedWaiterTimeout); err != nil {
 		return fmt.Errorf("failed waiting elastic load balancer: %w", err)
 	}
 	return nil
@@ -959,7 +1286,7 @@ func (c *nifcloudAPIClient) AuthorizeSecurityGroupIngress(ctx context.Context, s
 	ipRanges := []types.RequestIpRanges{}
 	for _, ipRange := range securityGroupRule.IpRanges {
 		ipRanges = append(ipRanges, types.RequestIpRanges{
-			CidrIp: &ipRange,
+			CidrIp: nifcloud.String(ipRange),
 		})
 	}
 
@@ -997,7 +1324,7 @@ func (c *nifcloudAPIClient) RevokeSecurityGroupIngress(ctx context.Context, secu
 	ipRanges := []types.RequestIpRanges{}
 	for _, ipRange := range securityGroupRule.IpRanges {
 		ipRanges = append(ipRanges, types.RequestIpRanges{
-			CidrIp: &ipRange,
+			CidrIp: nifcloud.String(ipRange),
 		})
 	}
 	ipPermissions := []types.RequestIpPermissionsOfRevokeSecurityGroupIngress{
@@ -1029,11 +1356,115 @@ func (c *nifcloudAPIClient) RevokeSecurityGroupIngress(ctx context.Context, secu
 	return nil
 }
 
-func (c *nifcloudAPIClient) WaitSecurityGroupApplied(ctx context.Context, securityGroupName string) error {
+func (c *nifcloudAPIClient) WaitSecurityGroupApplied(ctx context.Context, securityGroupName string) (err error) {
+	defer func(start time.Time) {
+		recordNIFCLOUDMetric("WaitSecurityGroupApplied", time.Since(start).Seconds(), err)
+	}(time.Now())
+
 	waiter := computing.NewSecurityGroupAppliedWaiter(c.client)
 	params := &computing.DescribeSecurityGroupsInput{GroupName: []string{securityGroupName}}
-	if err := waiter.Wait(ctx, params, securityGroupAppliedWaiterTimeout); err != nil {
+	if err := waiter.Wait(ctx, params, c.securityGroupAppliedWaiterTimeout); err != nil {
 		return fmt.Errorf("failed waiting security group: %w", err)
 	}
 	return nil
 }
+
+func (c *nifcloudAPIClient) describeRouter(ctx context.Context, routerName string) (*types.RouterSetOfNiftyDescribeRouters, error) {
+	res, err := c.client.NiftyDescribeRouters(ctx, &computing.NiftyDescribeRoutersInput{RouterName: []string{routerName}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to request NiftyDescribeRouters: %w", err)
+	}
+	if len(res.RouterSet) == 0 {
+		return nil, fmt.Errorf("router %q is not found", routerName)
+	}
+	return &res.RouterSet[0], nil
+}
+
+func (c *nifcloudAPIClient) DescribeRouterRouteTable(ctx context.Context, routerName string) (*RouteTable, error) {
+	router, err := c.describeRouter(ctx, routerName)
+	if err != nil {
+		return nil, err
+	}
+	routeTableID := nifcloud.ToString(router.RouteTableId)
+	if routeTableID == "" {
+		return nil, fmt.Errorf("router %q has no route table", routerName)
+	}
+
+	res, err := c.client.DescribeRouteTables(ctx, &computing.DescribeRouteTablesInput{RouteTableId: []string{routeTableID}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to request DescribeRouteTables: %w", err)
+	}
+	if len(res.RouteTableSet) == 0 {
+		return nil, fmt.Errorf("route table %q of router %q is not found", routeTableID, routerName)
+	}
+
+	routeTable := &RouteTable{RouteTableID: routeTableID}
+	for _, route := range res.RouteTableSet[0].RouteSet {
+		routeTable.Routes = append(routeTable.Routes, Route{
+			DestinationCIDR: nifcloud.ToString(route.DestinationCidrBlock),
+			IPAddress:       nifcloud.ToString(route.IpAddress),
+			NetworkID:       nifcloud.ToString(route.NetworkId),
+		})
+	}
+
+	return routeTable, nil
+}
+
+func (c *nifcloudAPIClient) CreateRoute(ctx context.Context, routeTableID string, route *Route) error {
+	if route == nil {
+		return fmt.Errorf("route is nil")
+	}
+
+	input := &computing.CreateRouteInput{
+		RouteTableId:         nifcloud.String(routeTableID),
+		DestinationCidrBlock: nifcloud.String(route.DestinationCIDR),
+		IpAddress:            nifcloud.String(route.IPAddress),
+	}
+	res, err := c.client.CreateRoute(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to create route %s via %s in %s: %w", route.DestinationCIDR, route.IPAddress, routeTableID, err)
+	}
+
+	if !nifcloud.ToBool(res.Return) {
+		return fmt.Errorf("failed to create route %s via %s in %s", route.DestinationCIDR, route.IPAddress, routeTableID)
+	}
+
+	return nil
+}
+
+func (c *nifcloudAPIClient) DeleteRoute(ctx context.Context, routeTableID string, destinationCIDR string) error {
+	input := &computing.DeleteRouteInput{
+		RouteTableId:         nifcloud.String(routeTableID),
+		DestinationCidrBlock: nifcloud.String(destinationCIDR),
+	}
+	res, err := c.client.DeleteRoute(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to delete route %s in %s: %w", destinationCIDR, routeTableID, err)
+	}
+
+	if !nifcloud.ToBool(res.Return) {
+		return fmt.Errorf("failed to delete route %s in %s", destinationCIDR, routeTableID)
+	}
+
+	return nil
+}
+
+// WaitRouterApplied waits until the router becomes available after its route table is changed.
+// The SDK has no waiter for routers, so the state is polled
+func (c *nifcloudAPIClient) WaitRouterApplied(ctx context.Context, routerName string) (err error) {
+	defer func(start time.Time) {
+		recordNIFCLOUDMetric("WaitRouterApplied", time.Since(start).Seconds(), err)
+	}(time.Now())
+
+	err = wait.PollUntilContextTimeout(ctx, routerAppliedWaiterInterval, c.routerAppliedWaiterTimeout, true, func(ctx context.Context) (bool, error) {
+		router, err := c.describeRouter(ctx, routerName)
+		if err != nil {
+			return false, err
+		}
+		return nifcloud.ToString(router.State) == routerStateAvailable, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed waiting router: %w", err)
+	}
+	return nil
+}
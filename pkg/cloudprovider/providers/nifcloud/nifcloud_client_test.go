@@ -2,9 +2,12 @@ package nifcloud_test
 
 import (
 	"context"
+	"encoding/pem"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
 	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
@@ -12,6 +15,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
 	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	cloudprovider "k8s.io/cloud-provider"
 )
 
@@ -57,7 +61,7 @@ var _ = Describe("nifcloudAPIClient", func() {
 
 			It("return the instance", func() {
 				ctx := context.Background()
-				expectedInstances := []nifcloud.Instance{*helper.NewTestInstance()}
+				expectedInstances := []nifcloud.Instance{*helper.NewTestInstanceWithNetworkInterfaces()}
 				gotInstances, gotErr := testNifcloudAPIClient.DescribeInstancesByInstanceID(ctx, testInstanceIDs)
 				Expect(gotErr).ShouldNot(HaveOccurred())
 				Expect(gotInstances).Should(Equal(expectedInstances))
@@ -97,7 +101,7 @@ var _ = Describe("nifcloudAPIClient", func() {
 
 			It("return the instance", func() {
 				ctx := context.Background()
-				expectedInstances := []nifcloud.Instance{*helper.NewTestInstance()}
+				expectedInstances := []nifcloud.Instance{*helper.NewTestInstanceWithNetworkInterfaces()}
 				gotInstances, gotErr := testNifcloudAPIClient.DescribeInstancesByInstanceUniqueID(ctx, testInstanceUniqueIDs)
 				Expect(gotErr).ShouldNot(HaveOccurred())
 				Expect(gotInstances).Should(Equal(expectedInstances))
@@ -123,6 +127,40 @@ var _ = Describe("nifcloudAPIClient", func() {
 		})
 	})
 
+	var _ = Describe("DescribeAllLoadBalancers", func() {
+		BeforeEach(func() {
+			handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				lo.Must0(r.ParseForm())
+				Expect(r.Form.Has("LoadBalancerNames.member.1")).Should(BeFalse())
+				_, _ = w.Write(lo.Must(os.ReadFile("./testdata/describe_load_balancers_two_ports_and_filters.xml")))
+			})
+		})
+
+		It("return all l4 load balancers", func() {
+			ctx := context.Background()
+			gotL4LoadBalancers, gotErr := testNifcloudAPIClient.DescribeAllLoadBalancers(ctx)
+			Expect(gotErr).ShouldNot(HaveOccurred())
+			Expect(gotL4LoadBalancers).Should(HaveLen(2))
+		})
+	})
+
+	var _ = Describe("DescribeAllElasticLoadBalancers", func() {
+		BeforeEach(func() {
+			handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				lo.Must0(r.ParseForm())
+				Expect(r.Form.Has("ElasticLoadBalancers.ElasticLoadBalancerName.1")).Should(BeFalse())
+				_, _ = w.Write(lo.Must(os.ReadFile("./testdata/describe_elastic_load_balancers_two_port_and_network_interfaces.xml")))
+			})
+		})
+
+		It("return all elastic load balancers", func() {
+			ctx := context.Background()
+			gotElasticLoadBalancers, gotErr := testNifcloudAPIClient.DescribeAllElasticLoadBalancers(ctx)
+			Expect(gotErr).ShouldNot(HaveOccurred())
+			Expect(gotElasticLoadBalancers).Should(HaveLen(2))
+		})
+	})
+
 	var _ = Describe("DescribeLoadBalancers", func() {
 		Describe("given l4 load balancer is existed", func() {
 			testLoadBalancerName := "testl4lb"
@@ -195,7 +233,7 @@ var _ = Describe("nifcloudAPIClient", func() {
 				ctx := context.Background()
 				gotL4LoadBalancer, gotErr := testNifcloudAPIClient.DescribeLoadBalancers(ctx, testLoadBalancerName)
 				Expect(gotErr).Should(HaveOccurred())
-				Expect(nifcloud.IsAPIError(gotErr, nifcloud.ExportErrorCodeLoadBalancerNotFound)).Should(BeTrue())
+				Expect(nifcloud.IsAPIError(gotErr, helper.ErrorCodeLoadBalancerNotFound)).Should(BeTrue())
 				Expect(gotL4LoadBalancer).Should(BeNil())
 			})
 		})
@@ -823,6 +861,7 @@ var _ = Describe("nifcloudAPIClient", func() {
 					expectedElasticLoadBalancers[i].BalancingTargets[0].Zone = ""
 					expectedElasticLoadBalancers[i].BalancingTargets[0].State = ""
 					expectedElasticLoadBalancers[i].NetworkInterfaces = networkInterfaces
+					expectedElasticLoadBalancers[i].Description = "k8s:testcluster/default/testlbsvc/testuid"
 				}
 				gotElasticLoadBalancer, gotErr := testNifcloudAPIClient.DescribeElasticLoadBalancers(ctx, testLoadBalancerName)
 				Expect(gotErr).ShouldNot(HaveOccurred())
@@ -846,7 +885,7 @@ var _ = Describe("nifcloudAPIClient", func() {
 				ctx := context.Background()
 				gotElasticLoadBalancer, gotErr := testNifcloudAPIClient.DescribeElasticLoadBalancers(ctx, testLoadBalancerName)
 				Expect(gotErr).Should(HaveOccurred())
-				Expect(nifcloud.IsAPIError(gotErr, nifcloud.ExportErrorCodeElasticLoadBalancerNotFound)).Should(BeTrue())
+				Expect(nifcloud.IsAPIError(gotErr, helper.ErrorCodeElasticLoadBalancerNotFound)).Should(BeTrue())
 				Expect(gotElasticLoadBalancer).Should(BeNil())
 			})
 		})
@@ -867,6 +906,7 @@ var _ = Describe("nifcloudAPIClient", func() {
 					Expect(r.Form.Get("Listeners.member.1.InstancePort")).Should(Equal("30000"))
 					Expect(r.Form.Get("Listeners.member.1.Protocol")).Should(Equal("TCP"))
 					Expect(r.Form.Get("Listeners.member.1.BalancingType")).Should(Equal("1"))
+					Expect(r.Form.Get("Listeners.member.1.Description")).Should(Equal("k8s:testcluster/default/testlbsvc/testuid"))
 					Expect(r.Form.Get("NetworkInterface.1.NetworkId")).Should(Equal("net-COMMON_GLOBAL"))
 					Expect(r.Form.Get("NetworkInterface.1.IsVipNetwork")).Should(Equal("true"))
 					_, _ = w.Write(lo.Must(os.ReadFile("./testdata/create_elastic_load_balancer.xml")))
@@ -876,6 +916,7 @@ var _ = Describe("nifcloudAPIClient", func() {
 			It("return nil", func() {
 				ctx := context.Background()
 				testElasticLoadBalancers := helper.NewTestElasticLoadBalancer(testLoadBalancerName)
+				testElasticLoadBalancers[0].Description = "k8s:testcluster/default/testlbsvc/testuid"
 				gotDNSName, gotErr := nifcloud.ExportCreateElasticLoadBalancer(testNifcloudAPIClient, ctx, &testElasticLoadBalancers[0])
 				Expect(gotErr).ShouldNot(HaveOccurred())
 				Expect(gotDNSName).Should(BeEmpty())
@@ -924,6 +965,7 @@ var _ = Describe("nifcloudAPIClient", func() {
 					Expect(r.Form.Get("Listeners.member.1.ElasticLoadBalancerPort")).Should(Equal("443"))
 					Expect(r.Form.Get("Listeners.member.1.InstancePort")).Should(Equal("30001"))
 					Expect(r.Form.Get("Listeners.member.1.Protocol")).Should(Equal("TCP"))
+					Expect(r.Form.Get("Listeners.member.1.Description")).Should(Equal("k8s:testcluster/default/testlbsvc/testuid"))
 					_, _ = w.Write(lo.Must(os.ReadFile("./testdata/register_port_with_elastic_load_balancer.xml")))
 				})
 			})
@@ -931,6 +973,7 @@ var _ = Describe("nifcloudAPIClient", func() {
 			It("return nil", func() {
 				ctx := context.Background()
 				testElasticLoadBalancers := helper.NewTestElasticLoadBalancerWithTwoPort(testLoadBalancerName)
+				testElasticLoadBalancers[1].Description = "k8s:testcluster/default/testlbsvc/testuid"
 				gotErr := nifcloud.ExportRegisterPortWithElasticLoadBalancer(testNifcloudAPIClient, ctx, &testElasticLoadBalancers[1])
 				Expect(gotErr).ShouldNot(HaveOccurred())
 			})
@@ -1592,4 +1635,186 @@ var _ = Describe("nifcloudAPIClient", func() {
 			})
 		})
 	})
+
+	var _ = Describe("DescribeRouterRouteTable", func() {
+		routerName := "router01"
+
+		Describe("the router has a route table", func() {
+			BeforeEach(func() {
+				handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					lo.Must0(r.ParseForm())
+					switch r.Form.Get("Action") {
+					case "NiftyDescribeRouters":
+						Expect(r.Form.Get("RouterName.1")).Should(Equal(routerName))
+						_, _ = w.Write(lo.Must(os.ReadFile("./testdata/nifty_describe_routers.xml")))
+					case "DescribeRouteTables":
+						Expect(r.Form.Get("RouteTableId.1")).Should(Equal("rtb-abcd1234"))
+						_, _ = w.Write(lo.Must(os.ReadFile("./testdata/describe_route_tables.xml")))
+					default:
+						GinkgoT().Fatalf("unexpected action %s", r.Form.Get("Action"))
+					}
+				})
+			})
+
+			It("return the route table", func() {
+				ctx := context.Background()
+				gotRouteTable, gotErr := testNifcloudAPIClient.DescribeRouterRouteTable(ctx, routerName)
+				Expect(gotErr).ShouldNot(HaveOccurred())
+				Expect(gotRouteTable).Should(Equal(&nifcloud.RouteTable{
+					RouteTableID: "rtb-abcd1234",
+					Routes: []nifcloud.Route{
+						{DestinationCIDR: "10.244.1.0/24", IPAddress: "192.168.0.100", NetworkID: "net-COMMON_PRIVATE"},
+						{DestinationCIDR: "10.244.2.0/24", IPAddress: "192.168.0.101", NetworkID: "net-COMMON_PRIVATE"},
+					},
+				}))
+			})
+		})
+	})
+
+	var _ = Describe("CreateRoute", func() {
+		Describe("creating route is success", func() {
+			BeforeEach(func() {
+				handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					lo.Must0(r.ParseForm())
+					Expect(r.Form.Get("RouteTableId")).Should(Equal("rtb-abcd1234"))
+					Expect(r.Form.Get("DestinationCidrBlock")).Should(Equal("10.244.1.0/24"))
+					Expect(r.Form.Get("IpAddress")).Should(Equal("192.168.0.100"))
+					_, _ = w.Write(lo.Must(os.ReadFile("./testdata/create_route.xml")))
+				})
+			})
+
+			It("return nil", func() {
+				ctx := context.Background()
+				gotErr := testNifcloudAPIClient.CreateRoute(ctx, "rtb-abcd1234", &nifcloud.Route{
+					DestinationCIDR: "10.244.1.0/24",
+					IPAddress:       "192.168.0.100",
+				})
+				Expect(gotErr).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+
+	var _ = Describe("DeleteRoute", func() {
+		Describe("deleting route is success", func() {
+			BeforeEach(func() {
+				handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					lo.Must0(r.ParseForm())
+					Expect(r.Form.Get("RouteTableId")).Should(Equal("rtb-abcd1234"))
+					Expect(r.Form.Get("DestinationCidrBlock")).Should(Equal("10.244.1.0/24"))
+					_, _ = w.Write(lo.Must(os.ReadFile("./testdata/delete_route.xml")))
+				})
+			})
+
+			It("return nil", func() {
+				ctx := context.Background()
+				gotErr := testNifcloudAPIClient.DeleteRoute(ctx, "rtb-abcd1234", "10.244.1.0/24")
+				Expect(gotErr).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+})
+
+var _ = Describe("nifcloudAPIClient with http options", func() {
+	var (
+		region          string
+		testInstanceIDs []string
+		handler         http.HandlerFunc
+	)
+
+	BeforeEach(func() {
+		region = "jp-east-1"
+		testInstanceIDs = []string{"testinstance"}
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lo.Must0(r.ParseForm())
+			Expect(r.Form.Get("InstanceId.1")).Should(Equal(testInstanceIDs[0]))
+			_, _ = w.Write(lo.Must(os.ReadFile("./testdata/describe_instances_instance_id.xml")))
+		})
+	})
+
+	Describe("CA bundle is specified", func() {
+		var ts *httptest.Server
+
+		BeforeEach(func() {
+			ts = httptest.NewTLSServer(handler)
+		})
+
+		AfterEach(func() {
+			ts.Close()
+		})
+
+		It("verify the endpoint with the CA bundle", func() {
+			caBundleFile := filepath.Join(GinkgoT().TempDir(), "ca.crt")
+			caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+			Expect(os.WriteFile(caBundleFile, caBundle, 0600)).NotTo(HaveOccurred())
+
+			client := nifcloud.NewNIFCLOUDAPIClientWithGlobalConfig("testkey", "testsecretkey", nifcloud.GlobalConfig{
+				Region:       region,
+				Endpoint:     ts.URL,
+				CABundleFile: caBundleFile,
+			})
+			gotInstances, gotErr := client.DescribeInstancesByInstanceID(context.Background(), testInstanceIDs)
+			Expect(gotErr).ShouldNot(HaveOccurred())
+			Expect(gotInstances).Should(Equal([]nifcloud.Instance{*helper.NewTestInstanceWithNetworkInterfaces()}))
+		})
+
+		It("return error without the CA bundle", func() {
+			client := nifcloud.NewNIFCLOUDAPIClientWithGlobalConfig("testkey", "testsecretkey", nifcloud.GlobalConfig{
+				Region:   region,
+				Endpoint: ts.URL,
+			})
+			_, gotErr := client.DescribeInstancesByInstanceID(context.Background(), testInstanceIDs)
+			Expect(gotErr).Should(HaveOccurred())
+		})
+	})
+
+	Describe("proxy is specified", func() {
+		var proxy *httptest.Server
+
+		BeforeEach(func() {
+			proxy = helper.NewTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Host).Should(Equal("computing.api.example.test"))
+				handler.ServeHTTP(w, r)
+			}))
+		})
+
+		AfterEach(func() {
+			proxy.Close()
+		})
+
+		It("send requests via the proxy", func() {
+			client := nifcloud.NewNIFCLOUDAPIClientWithGlobalConfig("testkey", "testsecretkey", nifcloud.GlobalConfig{
+				Region:   region,
+				Endpoint: "http://computing.api.example.test/api/",
+				ProxyURL: proxy.URL,
+			})
+			gotInstances, gotErr := client.DescribeInstancesByInstanceID(context.Background(), testInstanceIDs)
+			Expect(gotErr).ShouldNot(HaveOccurred())
+			Expect(gotInstances).Should(Equal([]nifcloud.Instance{*helper.NewTestInstanceWithNetworkInterfaces()}))
+		})
+	})
+
+	Describe("request timeout is specified", func() {
+		var ts *httptest.Server
+
+		BeforeEach(func() {
+			ts = helper.NewTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(500 * time.Millisecond)
+				handler.ServeHTTP(w, r)
+			}))
+		})
+
+		AfterEach(func() {
+			ts.Close()
+		})
+
+		It("return error when the request exceeds the timeout", func() {
+			client := nifcloud.NewNIFCLOUDAPIClientWithGlobalConfig("testkey", "testsecretkey", nifcloud.GlobalConfig{
+				Region:         region,
+				Endpoint:       ts.URL,
+				RequestTimeout: metav1.Duration{Duration: 100 * time.Millisecond},
+			})
+			_, gotErr := client.DescribeInstancesByInstanceID(context.Background(), testInstanceIDs)
+			Expect(gotErr).Should(HaveOccurred())
+		})
+	})
 })
@@ -0,0 +1,343 @@
+package nifcloud
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// CloudConfigAPIVersion is the supported version of the cloud config file
+	CloudConfigAPIVersion = "config.nifcloud.com/v1alpha1"
+	// CloudConfigKind is the kind of the cloud config file
+	CloudConfigKind = "CloudConfig"
+)
+
+// CloudConfig is the configuration of this cloud provider passed by --cloud-config.
+// The environment variables NIFCLOUD_ACCESS_KEY_ID, NIFCLOUD_SECRET_ACCESS_KEY and NIFCLOUD_REGION
+// take precedence over the values in the file.
+type CloudConfig struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+
+	// DryRun disables all mutating API calls. The planned mutations are logged instead
+	DryRun bool `json:"dryRun,omitempty"`
+
+	Global       GlobalConfig       `json:"global"`
+	Retry        RetryConfig        `json:"retry"`
+	RateLimit    RateLimitConfig    `json:"rateLimit"`
+	Cache        CacheConfig        `json:"cache"`
+	Waiter       WaiterConfig       `json:"waiter"`
+	LoadBalancer LoadBalancerConfig `json:"loadBalancer"`
+	Node         NodeConfig         `json:"node"`
+	Route        RouteConfig        `json:"route"`
+
+	LoadBalancerGC LoadBalancerGCConfig `json:"loadBalancerGC"`
+}
+
+// GlobalConfig is the configuration for the NIFCLOUD account and API
+type GlobalConfig struct {
+	// Region is the NIFCLOUD region (e.g. jp-east-1)
+	Region string `json:"region,omitempty"`
+	// Endpoint is the URL of the computing API. The regional endpoint is used if empty
+	Endpoint string `json:"endpoint,omitempty"`
+	// AccessKeyIDFile is the path to the file that contains the access key id
+	AccessKeyIDFile string `json:"accessKeyIDFile,omitempty"`
+	// SecretAccessKeyFile is the path to the file that contains the secret access key
+	SecretAccessKeyFile string `json:"secretAccessKeyFile,omitempty"`
+	// ProxyURL is the URL of the HTTP proxy for the API requests.
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used if empty
+	ProxyURL string `json:"proxyURL,omitempty"`
+	// CABundleFile is the path to the PEM encoded CA certificates to verify the API endpoint.
+	// The system root CAs are used if empty
+	CABundleFile string `json:"caBundleFile,omitempty"`
+	// RequestTimeout is the timeout of each API request. No timeout if zero
+	RequestTimeout metav1.Duration `json:"requestTimeout,omitempty"`
+}
+
+// RetryConfig is the configuration for retrying failed API requests
+type RetryConfig struct {
+	// MaxAttempts is the maximum number of attempts of each API request including the first one. Defaults to 5
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// MaxBackoff is the maximum delay between attempts. Defaults to 20s
+	MaxBackoff metav1.Duration `json:"maxBackoff,omitempty"`
+}
+
+// RateLimitConfig is the configuration for the client-side rate limiting of API requests.
+// Read (Describe*) requests and mutating requests are limited separately
+type RateLimitConfig struct {
+	// Read defaults to 10 QPS with 20 burst
+	Read TokenBucketConfig `json:"read"`
+	// Mutating defaults to 2 QPS with 5 burst
+	Mutating TokenBucketConfig `json:"mutating"`
+}
+
+// TokenBucketConfig is the configuration of a token bucket
+type TokenBucketConfig struct {
+	QPS   float64 `json:"qps,omitempty"`
+	Burst int     `json:"burst,omitempty"`
+}
+
+// CacheConfig is the configuration for caching NIFCLOUD resources
+type CacheConfig struct {
+	// InstanceTTL is how long the inventory of instances is cached. Defaults to 30s
+	InstanceTTL metav1.Duration `json:"instanceTTL,omitempty"`
+}
+
+// WaiterConfig is the configuration for waiting NIFCLOUD resources to be applied
+type WaiterConfig struct {
+	SecurityGroupAppliedTimeout       metav1.Duration `json:"securityGroupAppliedTimeout,omitempty"`
+	ElasticLoadBalancerAppliedTimeout metav1.Duration `json:"elasticLoadBalancerAppliedTimeout,omitempty"`
+	RouterAppliedTimeout              metav1.Duration `json:"routerAppliedTimeout,omitempty"`
+}
+
+// LoadBalancerConfig is the cluster-wide default values of load balancers.
+// Each value is used when the corresponding service annotation is not specified.
+type LoadBalancerConfig struct {
+	Type                          string `json:"type,omitempty"`
+	NetworkVolume                 string `json:"networkVolume,omitempty"`
+	AccountingType                string `json:"accountingType,omitempty"`
+	PolicyType                    string `json:"policyType,omitempty"`
+	BalancingType                 string `json:"balancingType,omitempty"`
+	HealthCheckProtocol           string `json:"healthCheckProtocol,omitempty"`
+	HealthCheckInterval           string `json:"healthCheckInterval,omitempty"`
+	HealthCheckUnhealthyThreshold string `json:"healthCheckUnhealthyThreshold,omitempty"`
+}
+
+// NodeConfig is the configuration for nodes and their NIFCLOUD instances
+type NodeConfig struct {
+	// InstanceResolver is how the instance of a node is found.
+	// One of instanceID, hostname, privateIP, label and annotation. Defaults to instanceID
+	InstanceResolver string `json:"instanceResolver,omitempty"`
+	// InstanceIDKey is the label or annotation key whose value is the instance id.
+	// Required when the instance resolver is label or annotation
+	InstanceIDKey string `json:"instanceIDKey,omitempty"`
+	// Addresses is which network is reported as each type of the node addresses
+	Addresses NodeAddressesConfig `json:"addresses"`
+	// Labels is the instance attributes added to nodes as labels
+	Labels NodeLabelsConfig `json:"labels"`
+	// Foreign is how the nodes not running on NIFCLOUD are treated in hybrid clusters
+	Foreign ForeignNodesConfig `json:"foreign"`
+}
+
+// NodeAddressesConfig is the network reported as each type of the node addresses.
+// Each value is net-COMMON_GLOBAL, net-COMMON_PRIVATE or network ID of private LAN.
+// The addresses of the other networks are reported as ExternalIP for global and InternalIP for the others
+type NodeAddressesConfig struct {
+	// InternalIP defaults to net-COMMON_PRIVATE
+	InternalIP string `json:"internalIP,omitempty"`
+	// ExternalIP defaults to net-COMMON_GLOBAL
+	ExternalIP string `json:"externalIP,omitempty"`
+	// Hostname is not reported if empty
+	Hostname string `json:"hostname,omitempty"`
+	// InternalDNS is not reported if empty
+	InternalDNS string `json:"internalDNS,omitempty"`
+}
+
+// NodeLabelsConfig is the configuration for labeling nodes with the attributes of their instances
+type NodeLabelsConfig struct {
+	// Attributes is the instance attributes added as node labels. No label is added if empty.
+	// Any of instanceFamily, instanceSize, accountingType, imageID, dedicated and description
+	Attributes []string `json:"attributes,omitempty"`
+	// SyncPeriod is how often the labels are updated. Defaults to 5m
+	SyncPeriod metav1.Duration `json:"syncPeriod,omitempty"`
+}
+
+// ForeignNodesConfig is the configuration for the nodes not managed by this cloud provider.
+// The nodes whose provider id has another scheme than nifcloud are always foreign
+type ForeignNodesConfig struct {
+	// Label is the node label key. The nodes with the label "true" are foreign
+	Label string `json:"label,omitempty"`
+	// LoadBalancerBackends is exclude or include. Defaults to exclude.
+	// With include, foreign nodes are registered to load balancers if their instances are found by the instance resolver
+	LoadBalancerBackends string `json:"loadBalancerBackends,omitempty"`
+}
+
+// RouteConfig is the configuration for the routes to the pod CIDRs of the nodes.
+// NIFCLOUD routes have no tags, so the routes whose destination is in ClusterCIDRs are owned by this cluster
+type RouteConfig struct {
+	// RouterName is the router whose route table has the routes. Routes are not supported if empty
+	RouterName string `json:"routerName,omitempty"`
+	// ClusterCIDRs is the pod CIDRs of this cluster. Required with RouterName.
+	// They must not overlap with those of the other clusters sharing the router
+	ClusterCIDRs []string `json:"clusterCIDRs,omitempty"`
+	// NetworkID is the network of the next hop addresses. Defaults to net-COMMON_PRIVATE
+	NetworkID string `json:"networkID,omitempty"`
+}
+
+// LoadBalancerGCConfig is the configuration for deleting the load balancers whose services no longer exist
+type LoadBalancerGCConfig struct {
+	// Enabled enables the garbage collector.
+	// It only deletes the load balancers recorded as owned by the services of --cluster-name
+	Enabled bool `json:"enabled,omitempty"`
+	// Interval is how often orphaned load balancers are looked for. Defaults to 10m
+	Interval metav1.Duration `json:"interval,omitempty"`
+	// GracePeriod is how long an orphaned load balancer is kept before deleted. Defaults to 1h
+	GracePeriod metav1.Duration `json:"gracePeriod,omitempty"`
+	// DryRun only logs the orphaned load balancers without deleting them
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// readCloudConfig reads the cloud config file and the environment variables.
+// config may be nil when --cloud-config is not specified.
+func readCloudConfig(config io.Reader) (*CloudConfig, error) {
+	cfg := &CloudConfig{}
+	if config != nil {
+		data, err := io.ReadAll(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cloud config: %w", err)
+		}
+		if len(strings.TrimSpace(string(data))) > 0 {
+			if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+				return nil, fmt.Errorf("failed to parse cloud config: %w", err)
+			}
+			if cfg.APIVersion != CloudConfigAPIVersion {
+				return nil, fmt.Errorf("cloud config apiVersion %q is not supported, must be %q", cfg.APIVersion, CloudConfigAPIVersion)
+			}
+			if cfg.Kind != CloudConfigKind {
+				return nil, fmt.Errorf("cloud config kind %q is not supported, must be %q", cfg.Kind, CloudConfigKind)
+			}
+		}
+	}
+
+	if region := os.Getenv("NIFCLOUD_REGION"); region != "" {
+		cfg.Global.Region = region
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (cfg *CloudConfig) validate() error {
+	if cfg.Global.Region == "" {
+		return fmt.Errorf(`region is required. set "global.region" in cloud config or environment variable "NIFCLOUD_REGION"`)
+	}
+	if cfg.Global.Endpoint != "" {
+		if err := validateURL(cfg.Global.Endpoint); err != nil {
+			return fmt.Errorf("global.endpoint is invalid: %w", err)
+		}
+	}
+	if cfg.Global.ProxyURL != "" {
+		if err := validateURL(cfg.Global.ProxyURL); err != nil {
+			return fmt.Errorf("global.proxyURL is invalid: %w", err)
+		}
+	}
+	if cfg.Global.RequestTimeout.Duration < 0 {
+		return fmt.Errorf("global.requestTimeout must not be negative")
+	}
+	if cfg.Retry.MaxAttempts < 0 {
+		return fmt.Errorf("retry.maxAttempts must not be negative")
+	}
+	if cfg.Retry.MaxBackoff.Duration < 0 {
+		return fmt.Errorf("retry.maxBackoff must not be negative")
+	}
+	for name, bucket := range map[string]TokenBucketConfig{
+		"rateLimit.read":     cfg.RateLimit.Read,
+		"rateLimit.mutating": cfg.RateLimit.Mutating,
+	} {
+		if bucket.QPS < 0 || bucket.Burst < 0 {
+			return fmt.Errorf("%s.qps and %s.burst must not be negative", name, name)
+		}
+	}
+	if cfg.Cache.InstanceTTL.Duration < 0 {
+		return fmt.Errorf("cache.instanceTTL must not be negative")
+	}
+	if cfg.Waiter.SecurityGroupAppliedTimeout.Duration < 0 {
+		return fmt.Errorf("waiter.securityGroupAppliedTimeout must not be negative")
+	}
+	if cfg.Waiter.ElasticLoadBalancerAppliedTimeout.Duration < 0 {
+		return fmt.Errorf("waiter.elasticLoadBalancerAppliedTimeout must not be negative")
+	}
+	if cfg.Waiter.RouterAppliedTimeout.Duration < 0 {
+		return fmt.Errorf("waiter.routerAppliedTimeout must not be negative")
+	}
+	if err := validateLoadBalancerAnnotations(cfg.LoadBalancer.annotations()); err != nil {
+		return fmt.Errorf("loadBalancer defaults are invalid: %w", err)
+	}
+	if err := cfg.Node.validate(); err != nil {
+		return fmt.Errorf("node is invalid: %w", err)
+	}
+	if err := cfg.Route.validate(); err != nil {
+		return fmt.Errorf("route is invalid: %w", err)
+	}
+	if err := cfg.LoadBalancerGC.validate(); err != nil {
+		return fmt.Errorf("loadBalancerGC is invalid: %w", err)
+	}
+
+	return nil
+}
+
+// credentials returns the access key id and secret access key.
+// The environment variables take precedence over the files in the cloud config.
+func (cfg *CloudConfig) credentials() (string, string, error) {
+	accessKeyID, err := readValueFromEnvOrFile("NIFCLOUD_ACCESS_KEY_ID", cfg.Global.AccessKeyIDFile)
+	if err != nil {
+		return "", "", err
+	}
+	if accessKeyID == "" {
+		return "", "", fmt.Errorf(`access key id is required. set "global.accessKeyIDFile" in cloud config or environment variable "NIFCLOUD_ACCESS_KEY_ID"`)
+	}
+
+	secretAccessKey, err := readValueFromEnvOrFile("NIFCLOUD_SECRET_ACCESS_KEY", cfg.Global.SecretAccessKeyFile)
+	if err != nil {
+		return "", "", err
+	}
+	if secretAccessKey == "" {
+		return "", "", fmt.Errorf(`secret access key is required. set "global.secretAccessKeyFile" in cloud config or environment variable "NIFCLOUD_SECRET_ACCESS_KEY"`)
+	}
+
+	return accessKeyID, secretAccessKey, nil
+}
+
+func validateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%q must be an absolute URL", rawURL)
+	}
+	return nil
+}
+
+func readValueFromEnvOrFile(env, path string) (string, error) {
+	if v := os.Getenv(env); v != "" {
+		return v, nil
+	}
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// annotations converts the default values to service annotations
+func (lbc LoadBalancerConfig) annotations() map[string]string {
+	annotations := map[string]string{}
+	set := func(key, value string) {
+		if value != "" {
+			annotations[key] = value
+		}
+	}
+	set(ServiceAnnotationLoadBalancerType, lbc.Type)
+	set(ServiceAnnotationLoadBalancerNetworkVolume, lbc.NetworkVolume)
+	set(ServiceAnnotationLoadBalancerAccountingType, lbc.AccountingType)
+	set(ServiceAnnotationLoadBalancerPolicyType, lbc.PolicyType)
+	set(ServiceAnnotationLoadBalancerBalancingType, lbc.BalancingType)
+	set(ServiceAnnotationLoadBalancerHCProtocol, lbc.HealthCheckProtocol)
+	set(ServiceAnnotationLoadBalancerHCInterval, lbc.HealthCheckInterval)
+	set(ServiceAnnotationLoadBalancerHCUnhealthyThreshold, lbc.HealthCheckUnhealthyThreshold)
+
+	return annotations
+}
@@ -0,0 +1,442 @@
+package nifcloud_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("readCloudConfig", func() {
+	BeforeEach(func() {
+		for _, env := range []string{"NIFCLOUD_ACCESS_KEY_ID", "NIFCLOUD_SECRET_ACCESS_KEY", "NIFCLOUD_REGION"} {
+			Expect(os.Unsetenv(env)).NotTo(HaveOccurred())
+		}
+	})
+
+	Context("cloud config is not specified", func() {
+		Context("environment variables are set", func() {
+			BeforeEach(func() {
+				Expect(os.Setenv("NIFCLOUD_REGION", "jp-east-1")).NotTo(HaveOccurred())
+				Expect(os.Setenv("NIFCLOUD_ACCESS_KEY_ID", "testkey")).NotTo(HaveOccurred())
+				Expect(os.Setenv("NIFCLOUD_SECRET_ACCESS_KEY", "testsecretkey")).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				for _, env := range []string{"NIFCLOUD_ACCESS_KEY_ID", "NIFCLOUD_SECRET_ACCESS_KEY", "NIFCLOUD_REGION"} {
+					Expect(os.Unsetenv(env)).NotTo(HaveOccurred())
+				}
+			})
+
+			It("return the config from environment variables", func() {
+				cfg, err := nifcloud.ExportReadCloudConfig(nil)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(cfg.Global.Region).Should(Equal("jp-east-1"))
+
+				accessKeyID, secretAccessKey, err := nifcloud.ExportCloudConfigCredentials(cfg)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(accessKeyID).Should(Equal("testkey"))
+				Expect(secretAccessKey).Should(Equal("testsecretkey"))
+			})
+		})
+
+		Context("region is not set", func() {
+			It("return error", func() {
+				_, err := nifcloud.ExportReadCloudConfig(nil)
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Context("cloud config is specified", func() {
+		var dir string
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, "access_key_id"), []byte("filekey\n"), 0600)).NotTo(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(dir, "secret_access_key"), []byte("filesecretkey\n"), 0600)).NotTo(HaveOccurred())
+		})
+
+		Context("the config is valid", func() {
+			It("return the config", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1alpha1
+kind: CloudConfig
+dryRun: true
+global:
+  region: jp-west-1
+  endpoint: https://computing.example.com
+  accessKeyIDFile: ` + filepath.Join(dir, "access_key_id") + `
+  secretAccessKeyFile: ` + filepath.Join(dir, "secret_access_key") + `
+retry:
+  maxAttempts: 10
+  maxBackoff: 30s
+waiter:
+  securityGroupAppliedTimeout: 5m
+  elasticLoadBalancerAppliedTimeout: 15m
+loadBalancer:
+  type: elb
+  networkVolume: "100"
+node:
+  instanceResolver: annotation
+  instanceIDKey: nifcloud.com/instance-id
+  addresses:
+    internalIP: net-abcd1234
+route:
+  routerName: router01
+  clusterCIDRs:
+    - 10.244.0.0/16
+`
+				cfg, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(cfg.DryRun).Should(BeTrue())
+				Expect(cfg.Global.Region).Should(Equal("jp-west-1"))
+				Expect(cfg.Global.Endpoint).Should(Equal("https://computing.example.com"))
+				Expect(cfg.Retry.MaxAttempts).Should(Equal(10))
+				Expect(cfg.Retry.MaxBackoff.Duration).Should(Equal(30 * time.Second))
+				Expect(cfg.Waiter.SecurityGroupAppliedTimeout.Duration).Should(Equal(5 * time.Minute))
+				Expect(cfg.Waiter.ElasticLoadBalancerAppliedTimeout.Duration).Should(Equal(15 * time.Minute))
+				Expect(cfg.LoadBalancer.Type).Should(Equal("elb"))
+				Expect(cfg.LoadBalancer.NetworkVolume).Should(Equal("100"))
+				Expect(cfg.Node.InstanceResolver).Should(Equal("annotation"))
+				Expect(cfg.Node.InstanceIDKey).Should(Equal("nifcloud.com/instance-id"))
+				Expect(cfg.Node.Addresses.InternalIP).Should(Equal("net-abcd1234"))
+				Expect(cfg.Route.RouterName).Should(Equal("router01"))
+				Expect(cfg.Route.ClusterCIDRs).Should(Equal([]string{"10.244.0.0/16"}))
+
+				accessKeyID, secretAccessKey, err := nifcloud.ExportCloudConfigCredentials(cfg)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(accessKeyID).Should(Equal("filekey"))
+				Expect(secretAccessKey).Should(Equal("filesecretkey"))
+			})
+		})
+
+		Context("environment variables are set", func() {
+			BeforeEach(func() {
+				Expect(os.Setenv("NIFCLOUD_REGION", "jp-east-1")).NotTo(HaveOccurred())
+				Expect(os.Setenv("NIFCLOUD_ACCESS_KEY_ID", "envkey")).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				Expect(os.Unsetenv("NIFCLOUD_REGION")).NotTo(HaveOccurred())
+				Expect(os.Unsetenv("NIFCLOUD_ACCESS_KEY_ID")).NotTo(HaveOccurred())
+			})
+
+			It("override the config with environment variables", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1alpha1
+kind: CloudConfig
+global:
+  region: jp-west-1
+  accessKeyIDFile: ` + filepath.Join(dir, "access_key_id") + `
+  secretAccessKeyFile: ` + filepath.Join(dir, "secret_access_key") + `
+`
+				cfg, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(cfg.Global.Region).Should(Equal("jp-east-1"))
+
+				accessKeyID, secretAccessKey, err := nifcloud.ExportCloudConfigCredentials(cfg)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(accessKeyID).Should(Equal("envkey"))
+				Expect(secretAccessKey).Should(Equal("filesecretkey"))
+			})
+		})
+
+		Context("apiVersion is invalid", func() {
+			It("return error", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1
+kind: CloudConfig
+global:
+  region: jp-west-1
+`
+				_, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("unknown field is set", func() {
+			It("return error", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1alpha1
+kind: CloudConfig
+global:
+  region: jp-west-1
+  zone: west-11
+`
+				_, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("proxy url is not absolute", func() {
+			It("return error", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1alpha1
+kind: CloudConfig
+global:
+  region: jp-west-1
+  proxyURL: proxy.example.com:3128
+`
+				_, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("retry max attempts is negative", func() {
+			It("return error", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1alpha1
+kind: CloudConfig
+global:
+  region: jp-west-1
+retry:
+  maxAttempts: -1
+`
+				_, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("rate limit qps is negative", func() {
+			It("return error", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1alpha1
+kind: CloudConfig
+global:
+  region: jp-west-1
+rateLimit:
+  mutating:
+    qps: -1
+`
+				_, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("waiter timeout is negative", func() {
+			It("return error", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1alpha1
+kind: CloudConfig
+global:
+  region: jp-west-1
+waiter:
+  securityGroupAppliedTimeout: -1m
+`
+				_, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("load balancer defaults are invalid", func() {
+			It("return error", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1alpha1
+kind: CloudConfig
+global:
+  region: jp-west-1
+loadBalancer:
+  type: elb
+  policyType: standard
+`
+				_, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("node instance resolver is unknown", func() {
+			It("return error", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1alpha1
+kind: CloudConfig
+global:
+  region: jp-west-1
+node:
+  instanceResolver: macAddress
+`
+				_, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("node instance id key is not set for the label resolver", func() {
+			It("return error", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1alpha1
+kind: CloudConfig
+global:
+  region: jp-west-1
+node:
+  instanceResolver: label
+`
+				_, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("node address network is not a network id", func() {
+			It("return error", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1alpha1
+kind: CloudConfig
+global:
+  region: jp-west-1
+node:
+  addresses:
+    internalIP: private
+`
+				_, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("node label attribute is unknown", func() {
+			It("return error", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1alpha1
+kind: CloudConfig
+global:
+  region: jp-west-1
+node:
+  labels:
+    attributes:
+    - instanceFamily
+    - hostname
+`
+				_, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("foreign node load balancer backends is unknown", func() {
+			It("return error", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1alpha1
+kind: CloudConfig
+global:
+  region: jp-west-1
+node:
+  foreign:
+    loadBalancerBackends: always
+`
+				_, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("route router name is specified without cluster CIDRs", func() {
+			It("return error", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1alpha1
+kind: CloudConfig
+global:
+  region: jp-west-1
+route:
+  routerName: router01
+`
+				_, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("load balancer gc grace period is negative", func() {
+			It("return error", func() {
+				config := `
+apiVersion: config.nifcloud.com/v1alpha1
+kind: CloudConfig
+global:
+  region: jp-west-1
+loadBalancerGC:
+  enabled: true
+  gracePeriod: -1h
+`
+				_, err := nifcloud.ExportReadCloudConfig(strings.NewReader(config))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+
+		Context("credential file is not existed", func() {
+			It("return error", func() {
+				cfg := &nifcloud.CloudConfig{
+					Global: nifcloud.GlobalConfig{
+						Region:          "jp-west-1",
+						AccessKeyIDFile: filepath.Join(dir, "not_found"),
+					},
+				}
+				_, _, err := nifcloud.ExportCloudConfigCredentials(cfg)
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+	})
+})
+
+var _ = Describe("withLoadBalancerDefaults", func() {
+	var cloud *nifcloud.Cloud
+
+	BeforeEach(func() {
+		cloud = &nifcloud.Cloud{}
+		cloud.SetConfig(nifcloud.CloudConfig{
+			LoadBalancer: nifcloud.LoadBalancerConfig{
+				NetworkVolume:       "100",
+				PolicyType:          "ats",
+				HealthCheckInterval: "30",
+			},
+		})
+	})
+
+	Context("service has no annotations", func() {
+		It("fill all defaults", func() {
+			service := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+			got := nifcloud.ExportWithLoadBalancerDefaults(cloud, service)
+			Expect(got.Annotations).Should(Equal(map[string]string{
+				nifcloud.ServiceAnnotationLoadBalancerNetworkVolume: "100",
+				nifcloud.ServiceAnnotationLoadBalancerPolicyType:    "ats",
+				nifcloud.ServiceAnnotationLoadBalancerHCInterval:    "30",
+			}))
+			Expect(service.Annotations).Should(BeNil())
+		})
+	})
+
+	Context("service has annotations", func() {
+		It("keep the service annotations", func() {
+			service := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						nifcloud.ServiceAnnotationLoadBalancerHCInterval: "10",
+					},
+				},
+			}
+			got := nifcloud.ExportWithLoadBalancerDefaults(cloud, service)
+			Expect(got.Annotations[nifcloud.ServiceAnnotationLoadBalancerHCInterval]).Should(Equal("10"))
+			Expect(got.Annotations[nifcloud.ServiceAnnotationLoadBalancerNetworkVolume]).Should(Equal("100"))
+		})
+	})
+
+	Context("service uses the other load balancer type", func() {
+		It("does not fill the type specific defaults", func() {
+			service := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						nifcloud.ServiceAnnotationLoadBalancerType: "elb",
+					},
+				},
+			}
+			got := nifcloud.ExportWithLoadBalancerDefaults(cloud, service)
+			Expect(got.Annotations).Should(Equal(map[string]string{
+				nifcloud.ServiceAnnotationLoadBalancerType:       "elb",
+				nifcloud.ServiceAnnotationLoadBalancerHCInterval: "30",
+			}))
+		})
+	})
+})
@@ -0,0 +1,118 @@
+package nifcloud
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/fsnotify/fsnotify"
+	"golang.org/x/exp/slices"
+	"k8s.io/klog/v2"
+)
+
+// credentialsProvider provides the NIFCLOUD credentials to the API client.
+// The credentials are read from the environment variables or the files in the cloud config,
+// and are swapped atomically when the files are changed (e.g. a mounted Secret is updated).
+// The requests already signed are not affected by the swap.
+type credentialsProvider struct {
+	config *CloudConfig
+	value  atomic.Pointer[aws.Credentials]
+}
+
+func newCredentialsProvider(config *CloudConfig) (*credentialsProvider, error) {
+	p := &credentialsProvider{config: config}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Retrieve returns the current credentials
+func (p *credentialsProvider) Retrieve(_ context.Context) (aws.Credentials, error) {
+	return *p.value.Load(), nil
+}
+
+func (p *credentialsProvider) reload() error {
+	accessKeyID, secretAccessKey, err := p.config.credentials()
+	if err != nil {
+		return err
+	}
+
+	if current := p.value.Load(); current != nil &&
+		current.AccessKeyID == accessKeyID && current.SecretAccessKey == secretAccessKey {
+		return nil
+	}
+
+	p.value.Store(&aws.Credentials{
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+	})
+	klog.Infof("Loaded NIFCLOUD credentials (access key id: %s)", accessKeyID)
+
+	return nil
+}
+
+// watchedDirs returns the directories of credential files which are not overridden by environment variables.
+// Directories are watched instead of files because a mounted Secret is updated by swapping symlinks.
+func (p *credentialsProvider) watchedDirs() []string {
+	dirs := []string{}
+	for env, path := range map[string]string{
+		"NIFCLOUD_ACCESS_KEY_ID":     p.config.Global.AccessKeyIDFile,
+		"NIFCLOUD_SECRET_ACCESS_KEY": p.config.Global.SecretAccessKeyFile,
+	} {
+		if path == "" || os.Getenv(env) != "" {
+			continue
+		}
+		if dir := filepath.Dir(path); !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// watch reloads the credentials whenever the credential files are changed until stop is closed.
+// If the files are invalid, the previous credentials are kept.
+func (p *credentialsProvider) watch(stop <-chan struct{}) error {
+	dirs := p.watchedDirs()
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher for credential files: %w", err)
+	}
+	defer watcher.Close()
+
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+	klog.Infof("Watching NIFCLOUD credential files in %v", dirs)
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if err := p.reload(); err != nil {
+				klog.Errorf("Failed to reload NIFCLOUD credentials, keep using the previous ones: %v", err)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			klog.Errorf("Error watching NIFCLOUD credential files: %v", err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package nifcloud_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("credentialsProvider", func() {
+	var (
+		dir                 string
+		accessKeyIDFile     string
+		secretAccessKeyFile string
+		config              *nifcloud.CloudConfig
+	)
+
+	BeforeEach(func() {
+		Expect(os.Unsetenv("NIFCLOUD_ACCESS_KEY_ID")).NotTo(HaveOccurred())
+		Expect(os.Unsetenv("NIFCLOUD_SECRET_ACCESS_KEY")).NotTo(HaveOccurred())
+
+		dir = GinkgoT().TempDir()
+		accessKeyIDFile = filepath.Join(dir, "access_key_id")
+		secretAccessKeyFile = filepath.Join(dir, "secret_access_key")
+		Expect(os.WriteFile(accessKeyIDFile, []byte("key1"), 0600)).NotTo(HaveOccurred())
+		Expect(os.WriteFile(secretAccessKeyFile, []byte("secret1"), 0600)).NotTo(HaveOccurred())
+
+		config = &nifcloud.CloudConfig{
+			Global: nifcloud.GlobalConfig{
+				Region:              "jp-east-1",
+				AccessKeyIDFile:     accessKeyIDFile,
+				SecretAccessKeyFile: secretAccessKeyFile,
+			},
+		}
+	})
+
+	Context("credential files are existed", func() {
+		It("return the credentials", func() {
+			p, err := nifcloud.ExportNewCredentialsProvider(config)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			creds, err := p.Retrieve(context.Background())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(creds.AccessKeyID).Should(Equal("key1"))
+			Expect(creds.SecretAccessKey).Should(Equal("secret1"))
+		})
+	})
+
+	Context("credential files are not existed", func() {
+		It("return error", func() {
+			config.Global.AccessKeyIDFile = filepath.Join(dir, "not_found")
+			_, err := nifcloud.ExportNewCredentialsProvider(config)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("credential files are updated", func() {
+		It("reload the credentials", func() {
+			p, err := nifcloud.ExportNewCredentialsProvider(config)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			stop := make(chan struct{})
+			defer close(stop)
+			go func() {
+				defer GinkgoRecover()
+				Expect(nifcloud.ExportWatchCredentials(p, stop)).ShouldNot(HaveOccurred())
+			}()
+
+			// rename new files over the old ones like a Secret volume update
+			Eventually(func() string {
+				Expect(os.WriteFile(filepath.Join(dir, ".access_key_id.tmp"), []byte("key2"), 0600)).NotTo(HaveOccurred())
+				Expect(os.WriteFile(filepath.Join(dir, ".secret_access_key.tmp"), []byte("secret2"), 0600)).NotTo(HaveOccurred())
+				Expect(os.Rename(filepath.Join(dir, ".access_key_id.tmp"), accessKeyIDFile)).NotTo(HaveOccurred())
+				Expect(os.Rename(filepath.Join(dir, ".secret_access_key.tmp"), secretAccessKeyFile)).NotTo(HaveOccurred())
+
+				creds, err := p.Retrieve(context.Background())
+				Expect(err).ShouldNot(HaveOccurred())
+				return creds.AccessKeyID + ":" + creds.SecretAccessKey
+			}).Should(Equal("key2:secret2"))
+		})
+	})
+
+	Context("credential files are broken", func() {
+		It("keep the previous credentials", func() {
+			p, err := nifcloud.ExportNewCredentialsProvider(config)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			stop := make(chan struct{})
+			defer close(stop)
+			go func() {
+				defer GinkgoRecover()
+				Expect(nifcloud.ExportWatchCredentials(p, stop)).ShouldNot(HaveOccurred())
+			}()
+
+			Expect(os.WriteFile(accessKeyIDFile, []byte(""), 0600)).NotTo(HaveOccurred())
+			Consistently(func() string {
+				creds, err := p.Retrieve(context.Background())
+				Expect(err).ShouldNot(HaveOccurred())
+				return creds.AccessKeyID
+			}, "200ms").Should(Equal("key1"))
+		})
+	})
+})
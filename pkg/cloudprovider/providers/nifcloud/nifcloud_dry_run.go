@@ -0,0 +1,217 @@
+package nifcloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// dryRunClient is the CloudAPIClient which does not mutate any NIFCLOUD resources.
+// Describe* and waiter calls are passed through to the underlying client,
+// and mutating calls are only logged as "would do" entries and succeed.
+// The creations return errDryRunCreated instead, because the caller can not continue without the created load balancer.
+// Every method is implemented explicitly not to pass through a mutating call added to CloudAPIClient.
+type dryRunClient struct {
+	client CloudAPIClient
+
+	// logf records a planned mutation. It is klog.InfoS except in tests
+	logf func(msg string, keysAndValues ...interface{})
+}
+
+var _ CloudAPIClient = &dryRunClient{}
+
+// errDryRunCreated is returned by the simulated creations, because the load balancer which is not created has no VIP
+var errDryRunCreated = errors.New("dry run: load balancer is not created")
+
+// dryRunLoadBalancerStatus returns the published status of the service instead of the VIP of the simulated load balancer,
+// so that an empty ingress never overwrites the published one
+func dryRunLoadBalancerStatus(service *v1.Service, loadBalancerName string) (*v1.LoadBalancerStatus, error) {
+	if len(service.Status.LoadBalancer.Ingress) == 0 {
+		return nil, fmt.Errorf("dry run: load balancer %q would be created, but it has no VIP to publish", loadBalancerName)
+	}
+	return service.Status.LoadBalancer.DeepCopy(), nil
+}
+
+func newDryRunClient(client CloudAPIClient) *dryRunClient {
+	return &dryRunClient{
+		client: client,
+		logf:   klog.InfoS,
+	}
+}
+
+func (c *dryRunClient) wouldDo(action string, keysAndValues ...interface{}) {
+	c.logf("Dry run: would call NIFCLOUD API", append([]interface{}{"action", action}, keysAndValues...)...)
+}
+
+func instanceIDsOf(instances []Instance) []string {
+	return lo.Map(instances, func(instance Instance, _ int) string { return instance.InstanceID })
+}
+
+func (c *dryRunClient) DescribeInstances(ctx context.Context) ([]Instance, error) {
+	return c.client.DescribeInstances(ctx)
+}
+
+func (c *dryRunClient) DescribeInstancesByInstanceID(ctx context.Context, instanceIDs []string) ([]Instance, error) {
+	return c.client.DescribeInstancesByInstanceID(ctx, instanceIDs)
+}
+
+func (c *dryRunClient) DescribeInstancesByInstanceUniqueID(ctx context.Context, instanceUniqueIDs []string) ([]Instance, error) {
+	return c.client.DescribeInstancesByInstanceUniqueID(ctx, instanceUniqueIDs)
+}
+
+func (c *dryRunClient) DescribeLoadBalancers(ctx context.Context, name string) ([]LoadBalancer, error) {
+	return c.client.DescribeLoadBalancers(ctx, name)
+}
+
+func (c *dryRunClient) DescribeAllLoadBalancers(ctx context.Context) ([]LoadBalancer, error) {
+	return c.client.DescribeAllLoadBalancers(ctx)
+}
+
+func (c *dryRunClient) DescribeElasticLoadBalancers(ctx context.Context, name string) ([]ElasticLoadBalancer, error) {
+	return c.client.DescribeElasticLoadBalancers(ctx, name)
+}
+
+func (c *dryRunClient) DescribeAllElasticLoadBalancers(ctx context.Context) ([]ElasticLoadBalancer, error) {
+	return c.client.DescribeAllElasticLoadBalancers(ctx)
+}
+
+func (c *dryRunClient) DescribeSecurityGroupsByInstanceIDs(ctx context.Context, instanceIDs []string) ([]SecurityGroup, error) {
+	return c.client.DescribeSecurityGroupsByInstanceIDs(ctx, instanceIDs)
+}
+
+func (c *dryRunClient) WaitSecurityGroupApplied(ctx context.Context, securityGroupName string) error {
+	return c.client.WaitSecurityGroupApplied(ctx, securityGroupName)
+}
+
+func (c *dryRunClient) WaitElasticLoadBalancerApplied(ctx context.Context, elasticLoadBalancerName string) error {
+	return c.client.WaitElasticLoadBalancerApplied(ctx, elasticLoadBalancerName)
+}
+
+func (c *dryRunClient) DescribeRouterRouteTable(ctx context.Context, routerName string) (*RouteTable, error) {
+	return c.client.DescribeRouterRouteTable(ctx, routerName)
+}
+
+func (c *dryRunClient) WaitRouterApplied(ctx context.Context, routerName string) error {
+	return c.client.WaitRouterApplied(ctx, routerName)
+}
+
+func (c *dryRunClient) CreateLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer) (string, error) {
+	c.wouldDo("CreateLoadBalancer", "loadBalancer", loadBalancer.String(),
+		"networkVolume", loadBalancer.NetworkVolume, "accountingType", loadBalancer.AccountingType,
+		"policyType", loadBalancer.PolicyType, "balancingType", loadBalancer.BalancingType,
+		"healthCheckTarget", loadBalancer.HealthCheckTarget, "filterType", loadBalancer.FilterType, "filters", loadBalancer.Filters)
+	return "", errDryRunCreated
+}
+
+func (c *dryRunClient) RegisterPortWithLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer) error {
+	c.wouldDo("RegisterPortWithLoadBalancer", "loadBalancer", loadBalancer.String())
+	return nil
+}
+
+func (c *dryRunClient) ConfigureHealthCheck(ctx context.Context, loadBalancer *LoadBalancer) error {
+	c.wouldDo("ConfigureHealthCheck", "loadBalancer", loadBalancer.String(),
+		"healthCheckTarget", loadBalancer.HealthCheckTarget, "healthCheckInterval", loadBalancer.HealthCheckInterval,
+		"healthCheckUnhealthyThreshold", loadBalancer.HealthCheckUnhealthyThreshold)
+	return nil
+}
+
+func (c *dryRunClient) DeleteLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer) error {
+	c.wouldDo("DeleteLoadBalancer", "loadBalancer", loadBalancer.String())
+	return nil
+}
+
+func (c *dryRunClient) RegisterInstancesWithLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer, instances []Instance) error {
+	c.wouldDo("RegisterInstancesWithLoadBalancer", "loadBalancer", loadBalancer.String(), "instances", instanceIDsOf(instances))
+	return nil
+}
+
+func (c *dryRunClient) DeregisterInstancesFromLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer, instances []Instance) error {
+	c.wouldDo("DeregisterInstancesFromLoadBalancer", "loadBalancer", loadBalancer.String(), "instances", instanceIDsOf(instances))
+	return nil
+}
+
+func (c *dryRunClient) SetFilterForLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer, filters []Filter) error {
+	c.wouldDo("SetFilterForLoadBalancer", "loadBalancer", loadBalancer.String(), "filterType", loadBalancer.FilterType, "filters", filters)
+	return nil
+}
+
+func (c *dryRunClient) UpdateLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer) error {
+	c.wouldDo("UpdateLoadBalancer", "loadBalancer", loadBalancer.Name,
+		"networkVolume", loadBalancer.NetworkVolume, "accountingType", loadBalancer.AccountingType)
+	return nil
+}
+
+func (c *dryRunClient) UpdateLoadBalancerName(ctx context.Context, name, newName string) error {
+	c.wouldDo("UpdateLoadBalancerName", "loadBalancer", name, "newName", newName)
+	return nil
+}
+
+func (c *dryRunClient) CreateElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer) (string, error) {
+	c.wouldDo("CreateElasticLoadBalancer", "elasticLoadBalancer", loadBalancer.String(),
+		"protocol", loadBalancer.Protocol, "networkVolume", loadBalancer.NetworkVolume,
+		"accountingType", loadBalancer.AccountingType, "balancingType", loadBalancer.BalancingType,
+		"healthCheckTarget", loadBalancer.HealthCheckTarget, "availabilityZone", loadBalancer.AvailabilityZone)
+	return "", errDryRunCreated
+}
+
+func (c *dryRunClient) RegisterPortWithElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer) error {
+	c.wouldDo("RegisterPortWithElasticLoadBalancer", "elasticLoadBalancer", loadBalancer.String(), "protocol", loadBalancer.Protocol)
+	return nil
+}
+
+func (c *dryRunClient) ConfigureElasticLoadBalancerHealthCheck(ctx context.Context, loadBalancer *ElasticLoadBalancer) error {
+	c.wouldDo("ConfigureElasticLoadBalancerHealthCheck", "elasticLoadBalancer", loadBalancer.String(),
+		"healthCheckTarget", loadBalancer.HealthCheckTarget, "healthCheckInterval", loadBalancer.HealthCheckInterval,
+		"healthCheckUnhealthyThreshold", loadBalancer.HealthCheckUnhealthyThreshold)
+	return nil
+}
+
+func (c *dryRunClient) DeleteElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer) error {
+	c.wouldDo("DeleteElasticLoadBalancer", "elasticLoadBalancer", loadBalancer.String(), "protocol", loadBalancer.Protocol)
+	return nil
+}
+
+func (c *dryRunClient) RegisterInstancesWithElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer, instances []Instance) error {
+	c.wouldDo("RegisterInstancesWithElasticLoadBalancer", "elasticLoadBalancer", loadBalancer.String(), "instances", instanceIDsOf(instances))
+	return nil
+}
+
+func (c *dryRunClient) DeregisterInstancesFromElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer, instances []Instance) error {
+	c.wouldDo("DeregisterInstancesFromElasticLoadBalancer", "elasticLoadBalancer", loadBalancer.String(), "instances", instanceIDsOf(instances))
+	return nil
+}
+
+func (c *dryRunClient) UpdateElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer) error {
+	c.wouldDo("UpdateElasticLoadBalancer", "elasticLoadBalancer", loadBalancer.Name,
+		"networkVolume", loadBalancer.NetworkVolume, "accountingType", loadBalancer.AccountingType)
+	return nil
+}
+
+func (c *dryRunClient) UpdateElasticLoadBalancerName(ctx context.Context, name, newName string) error {
+	c.wouldDo("UpdateElasticLoadBalancerName", "elasticLoadBalancer", name, "newName", newName)
+	return nil
+}
+
+func (c *dryRunClient) AuthorizeSecurityGroupIngress(ctx context.Context, securityGroupName string, securityGroupRule *SecurityGroupRule) error {
+	c.wouldDo("AuthorizeSecurityGroupIngress", "securityGroup", securityGroupName, "rule", securityGroupRule.String())
+	return nil
+}
+
+func (c *dryRunClient) RevokeSecurityGroupIngress(ctx context.Context, securityGroupName string, securityGroupRule *SecurityGroupRule) error {
+	c.wouldDo("RevokeSecurityGroupIngress", "securityGroup", securityGroupName, "rule", securityGroupRule.String())
+	return nil
+}
+
+func (c *dryRunClient) CreateRoute(ctx context.Context, routeTableID string, route *Route) error {
+	c.wouldDo("CreateRoute", "routeTable", routeTableID, "destinationCIDR", route.DestinationCIDR, "ipAddress", route.IPAddress)
+	return nil
+}
+
+func (c *dryRunClient) DeleteRoute(ctx context.Context, routeTableID string, destinationCIDR string) error {
+	c.wouldDo("DeleteRoute", "routeTable", routeTableID, "destinationCIDR", destinationCIDR)
+	return nil
+}
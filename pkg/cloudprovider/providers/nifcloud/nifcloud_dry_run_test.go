@@ -0,0 +1,153 @@
+package nifcloud_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("dryRunClient", func() {
+	var (
+		ctrl    *gomock.Controller
+		mock    *nifcloud.MockCloudAPIClient
+		entries []map[string]interface{}
+		client  nifcloud.CloudAPIClient
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		mock = nifcloud.NewMockCloudAPIClient(ctrl)
+		entries = nil
+		client = nifcloud.NewDryRunClient(mock, func(msg string, keysAndValues ...interface{}) {
+			entry := map[string]interface{}{"msg": msg}
+			for i := 0; i+1 < len(keysAndValues); i += 2 {
+				entry[fmt.Sprint(keysAndValues[i])] = keysAndValues[i+1]
+			}
+			entries = append(entries, entry)
+		})
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("describe is called", func() {
+		It("pass through to the client", func() {
+			ctx := context.Background()
+			testLoadBalancers := helper.NewTestL4LoadBalancer("testlb")
+			mock.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), "testlb").
+				Return(testLoadBalancers, nil).
+				Times(1)
+
+			got, err := client.DescribeLoadBalancers(ctx, "testlb")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(got).Should(Equal(testLoadBalancers))
+			Expect(entries).Should(BeEmpty())
+		})
+	})
+
+	Context("mutating API is called", func() {
+		It("does not call the client and record the entry", func() {
+			ctx := context.Background()
+			testLoadBalancer := helper.NewTestL4LoadBalancer("testlb")[0]
+			testElasticLoadBalancer := &nifcloud.ElasticLoadBalancer{Name: "testelb", Protocol: "TCP", LoadBalancerPort: 80, InstancePort: 30000}
+			testRule := &nifcloud.SecurityGroupRule{IpProtocol: "TCP", FromPort: 30000, ToPort: 30000, InOut: "IN", IpRanges: []string{"203.0.113.1"}}
+
+			vip, err := client.CreateLoadBalancer(ctx, &testLoadBalancer)
+			Expect(err).Should(MatchError(nifcloud.ExportErrDryRunCreated))
+			Expect(vip).Should(BeEmpty())
+			Expect(client.DeleteElasticLoadBalancer(ctx, testElasticLoadBalancer)).ShouldNot(HaveOccurred())
+			Expect(client.RevokeSecurityGroupIngress(ctx, "testsg", testRule)).ShouldNot(HaveOccurred())
+			Expect(client.DeleteRoute(ctx, "rtb-abcd1234", "10.244.1.0/24")).ShouldNot(HaveOccurred())
+
+			Expect(entries).Should(HaveLen(4))
+			Expect(entries[0]).Should(HaveKeyWithValue("action", "CreateLoadBalancer"))
+			Expect(entries[0]).Should(HaveKeyWithValue("loadBalancer", testLoadBalancer.String()))
+			Expect(entries[1]).Should(HaveKeyWithValue("action", "DeleteElasticLoadBalancer"))
+			Expect(entries[1]).Should(HaveKeyWithValue("elasticLoadBalancer", testElasticLoadBalancer.String()))
+			Expect(entries[2]).Should(HaveKeyWithValue("action", "RevokeSecurityGroupIngress"))
+			Expect(entries[2]).Should(HaveKeyWithValue("securityGroup", "testsg"))
+			Expect(entries[3]).Should(HaveKeyWithValue("action", "DeleteRoute"))
+			Expect(entries[3]).Should(HaveKeyWithValue("destinationCIDR", "10.244.1.0/24"))
+		})
+	})
+
+	Context("load balancer is created", func() {
+		var cloud *nifcloud.Cloud
+		var testService *corev1.Service
+
+		BeforeEach(func() {
+			cloud = &nifcloud.Cloud{}
+			cloud.SetClient(client)
+			cloud.SetRegion("east1")
+			cloud.SetConfig(nifcloud.CloudConfig{DryRun: true})
+			cloud.SetKubeClient(fake.NewSimpleClientset())
+			testService = &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "testservice", Namespace: "default"},
+			}
+		})
+
+		It("returns the published ingress of the service instead of the vip of the l4 load balancer", func() {
+			ctx := context.Background()
+			testService.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.1"}}
+			mock.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), "testlb").
+				Return(nil, helper.NewMockAPIError(helper.ErrorCodeLoadBalancerNotFound)).
+				Times(1)
+
+			status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, testService, "testlb", helper.NewTestL4LoadBalancerWithTwoPort("testlb"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(*status).Should(Equal(testService.Status.LoadBalancer))
+			Expect(entries).Should(ContainElement(HaveKeyWithValue("action", "CreateLoadBalancer")))
+			Expect(entries).ShouldNot(ContainElement(HaveKeyWithValue("action", "RegisterPortWithLoadBalancer")))
+		})
+
+		It("returns the error if the service has no ingress of the l4 load balancer", func() {
+			ctx := context.Background()
+			mock.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), "testlb").
+				Return(nil, helper.NewMockAPIError(helper.ErrorCodeLoadBalancerNotFound)).
+				Times(1)
+
+			status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, testService, "testlb", helper.NewTestL4LoadBalancer("testlb"))
+			Expect(err).Should(MatchError(ContainSubstring("dry run")))
+			Expect(status).Should(BeNil())
+		})
+
+		It("returns the published ingress of the service instead of the vip of the elastic load balancer", func() {
+			ctx := context.Background()
+			testService.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.1"}}
+			mock.EXPECT().
+				DescribeElasticLoadBalancers(gomock.Any(), "testelb").
+				Return(nil, helper.NewMockAPIError(helper.ErrorCodeElasticLoadBalancerNotFound)).
+				Times(1)
+
+			status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, testService, "testelb", helper.NewTestElasticLoadBalancerWithTwoPort("testelb"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(*status).Should(Equal(testService.Status.LoadBalancer))
+			Expect(entries).Should(ContainElement(HaveKeyWithValue("action", "CreateElasticLoadBalancer")))
+			Expect(entries).ShouldNot(ContainElement(HaveKeyWithValue("action", "RegisterPortWithElasticLoadBalancer")))
+		})
+
+		It("returns the error if the service has no ingress of the elastic load balancer", func() {
+			ctx := context.Background()
+			mock.EXPECT().
+				DescribeElasticLoadBalancers(gomock.Any(), "testelb").
+				Return(nil, helper.NewMockAPIError(helper.ErrorCodeElasticLoadBalancerNotFound)).
+				Times(1)
+
+			status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, testService, "testelb", helper.NewTestElasticLoadBalancer("testelb"))
+			Expect(err).Should(MatchError(ContainSubstring("dry run")))
+			Expect(status).Should(BeNil())
+		})
+	})
+})
@@ -2,12 +2,15 @@ package nifcloud
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	servicehelpers "k8s.io/cloud-provider/service/helpers"
 	"k8s.io/klog/v2"
 )
 
@@ -24,15 +27,32 @@ func isPrivateLanNetworkID(networkID string) bool {
 	return networkID != commonGlobalNetworkID && networkID != commonPrivateNetworkID
 }
 
-func (c *Cloud) getElasticLoadBalancer(ctx context.Context, clusterName string, service *v1.Service) (status *v1.LoadBalancerStatus, exists bool, err error) {
-	// get load balancer name
+// describeElasticLoadBalancersOfService returns the elastic load balancers of the service and their name.
+// The load balancers under the legacy name are returned if not found under the name
+func (c *Cloud) describeElasticLoadBalancersOfService(ctx context.Context, clusterName string, service *v1.Service) (string, []ElasticLoadBalancer, error) {
 	loadBalancerName := c.GetLoadBalancerName(ctx, clusterName, service)
+	loadBalancers, err := c.client.DescribeElasticLoadBalancers(ctx, loadBalancerName)
+	legacyName := legacyLoadBalancerName(service)
+	if err == nil || !IsNotFound(err) || legacyName == "" {
+		return loadBalancerName, loadBalancers, err
+	}
 
+	legacyLoadBalancers, legacyErr := c.client.DescribeElasticLoadBalancers(ctx, legacyName)
+	if legacyErr != nil {
+		if IsNotFound(legacyErr) {
+			return loadBalancerName, nil, err
+		}
+		return legacyName, nil, legacyErr
+	}
+	return legacyName, legacyLoadBalancers, nil
+}
+
+func (c *Cloud) getElasticLoadBalancer(ctx context.Context, clusterName string, service *v1.Service) (status *v1.LoadBalancerStatus, exists bool, err error) {
 	// describe load balancer
-	loadBalancers, err := c.client.DescribeElasticLoadBalancers(ctx, loadBalancerName)
+	loadBalancerName, loadBalancers, err := c.describeElasticLoadBalancersOfService(ctx, clusterName, service)
 	if err != nil {
 		switch {
-		case IsAPIError(err, errorCodeElasticLoadBalancerNotFound):
+		case IsNotFound(err):
 			return nil, false, nil
 		}
 		return nil, false, err
@@ -41,12 +61,17 @@ func (c *Cloud) getElasticLoadBalancer(ctx context.Context, clusterName string,
 	if len(loadBalancers) == 0 {
 		return nil, false, nil
 	}
+	if err := c.verifyElasticLoadBalancerOwner(ctx, clusterName, service, loadBalancerName, loadBalancers); err != nil {
+		return nil, false, err
+	}
 
 	// return load balancer status
 	return toLoadBalancerStatus(loadBalancers[0].VIP), true, nil
 }
 
-func (c *Cloud) ensureElasticLoadBalancer(ctx context.Context, loadBalancerName string, desire []ElasticLoadBalancer) (*v1.LoadBalancerStatus, error) {
+// ensureElasticLoadBalancer creates or updates the elastic load balancers to the desire.
+// The description of the desire is the ownership marker which the existing load balancers must have unless the service adopts them
+func (c *Cloud) ensureElasticLoadBalancer(ctx context.Context, service *v1.Service, loadBalancerName string, desire []ElasticLoadBalancer) (*v1.LoadBalancerStatus, error) {
 	// correct state differences
 	if len(desire) == 0 {
 		return nil, fmt.Errorf("desire ElasticLoadBalancer length must be larger than 1")
@@ -57,7 +82,12 @@ func (c *Cloud) ensureElasticLoadBalancer(ctx context.Context, loadBalancerName
 
 	// if not exist, create load balancer
 	if err != nil {
-		if IsAPIError(err, errorCodeElasticLoadBalancerNotFound) {
+		if IsNotFound(err) {
+			// record the owner before creating, so that the created load balancers are never left unrecorded
+			if err := c.recordLoadBalancerOwner(ctx, "elb", loadBalancerName, desire[0].Description); err != nil {
+				return nil, err
+			}
+
 			// create all load balancers
 			var vip string
 			var networkInterfaces []NetworkInterface
@@ -65,6 +95,9 @@ func (c *Cloud) ensureElasticLoadBalancer(ctx context.Context, loadBalancerName
 				klog.Infof("Creating ElasticLoadBalancer %q (%d -> %d)", lb.Name, lb.LoadBalancerPort, lb.InstancePort)
 				if i == 0 {
 					_, err = c.client.CreateElasticLoadBalancer(ctx, &lb)
+					if errors.Is(err, errDryRunCreated) {
+						return dryRunLoadBalancerStatus(service, loadBalancerName)
+					}
 					if err != nil {
 						return nil, fmt.Errorf("failed to create elastic load balancer: %w", err)
 					}
@@ -91,6 +124,10 @@ func (c *Cloud) ensureElasticLoadBalancer(ctx context.Context, loadBalancerName
 	}
 
 	// if exist, configure load balancers
+	descriptions := lo.Map(current, func(lb ElasticLoadBalancer, _ int) string { return lb.Description })
+	if err := verifyLoadBalancerOwner(loadBalancerName, desire[0].Description, descriptions, isLoadBalancerAdopted(service)); err != nil {
+		return nil, err
+	}
 
 	for i := range desire {
 		desire[i].VIP = current[0].VIP
@@ -132,12 +169,53 @@ func (c *Cloud) ensureElasticLoadBalancer(ctx context.Context, loadBalancerName
 		}
 	}
 
+	// reconcile the network volume and the accounting type shared by all ports
+	if toUpdate, ok := elasticLoadBalancerSpecUpdate(desire[0], current[0]); ok {
+		klog.Infof(
+			"Updating elastic load balancer %q: network volume %d -> %d, accounting type %q -> %q",
+			loadBalancerName, current[0].NetworkVolume, desire[0].NetworkVolume, current[0].AccountingType, desire[0].AccountingType,
+		)
+		if err := c.client.UpdateElasticLoadBalancer(ctx, &toUpdate); err != nil {
+			return nil, fmt.Errorf("failed to update elastic load balancer: %w", err)
+		}
+		if err := c.client.WaitElasticLoadBalancerApplied(ctx, loadBalancerName); err != nil {
+			return nil, fmt.Errorf("failed to wait elastic load balancer applied: %w", err)
+		}
+	}
+
 	for _, currentLB := range current {
 		desireLB, err := findElasticLoadBalancer(desire, currentLB)
 		if err != nil {
 			return nil, err
 		}
 
+		// reconcile listener settings
+		if !elasticLoadBalancerListenerEquals(*desireLB, currentLB) {
+			// deleting the only port deletes the elastic load balancer and its VIP
+			if len(current) > 1 {
+				if err := c.replaceElasticLoadBalancerListener(ctx, &currentLB, desireLB, desire); err != nil {
+					return nil, err
+				}
+				// the registered listener has the desired health check and balancing targets
+				continue
+			}
+			field, from, to := elasticLoadBalancerListenerDifference(*desireLB, currentLB)
+			c.warnServiceOnce(service, "ListenerNotUpdated", fmt.Sprintf(
+				"cannot change the %s of elastic load balancer %q (%d -> %d) from %s to %s because it has only one port: recreate the service to apply it",
+				field, currentLB.Name, currentLB.LoadBalancerPort, currentLB.InstancePort, from, to,
+			))
+		}
+
+		// reconcile health check
+		if !elasticLoadBalancerHealthCheckEquals(*desireLB, currentLB) {
+			if err := c.configureElasticLoadBalancerHealthCheck(ctx, &currentLB, desireLB, desire); err != nil {
+				return nil, err
+			}
+			currentLB.HealthCheckTarget = desireLB.HealthCheckTarget
+			currentLB.HealthCheckInterval = desireLB.HealthCheckInterval
+			currentLB.HealthCheckUnhealthyThreshold = desireLB.HealthCheckUnhealthyThreshold
+		}
+
 		// reconcile balancing targets
 		toRegister := elasticLoadBalancingTargetsDifferences(desireLB.BalancingTargets, currentLB.BalancingTargets)
 		if len(toRegister) > 0 {
@@ -171,6 +249,109 @@ func (c *Cloud) ensureElasticLoadBalancer(ctx context.Context, loadBalancerName
 	return toLoadBalancerStatus(current[0].VIP), nil
 }
 
+// replaceElasticLoadBalancerListener deletes the listener and registers the desired one,
+// because the balancing type of a listener can not be updated in place.
+// The security group rules of the deleted listener are denied unless another listener of all desire needs them
+func (c *Cloud) replaceElasticLoadBalancerListener(ctx context.Context, current, desire *ElasticLoadBalancer, all []ElasticLoadBalancer) error {
+	klog.Infof(
+		"Replacing ElasticLoadBalancer port %q (%d -> %d) to change balancing type from %d to %d",
+		current.Name, current.LoadBalancerPort, current.InstancePort, current.BalancingType, desire.BalancingType,
+	)
+	currentRules, err := securityGroupRulesOfElasticLoadBalancer(ctx, current)
+	if err != nil {
+		return err
+	}
+	desireRules, err := securityGroupRulesOfElasticLoadBalancers(ctx, all)
+	if err != nil {
+		return err
+	}
+
+	if err := c.client.DeleteElasticLoadBalancer(ctx, current); err != nil {
+		return fmt.Errorf("failed to delete elastic load balancer: %w", err)
+	}
+	if err := c.revokeSecurityGroupRules(ctx, current.BalancingTargets, securityGroupRuleDifferences(currentRules, desireRules)); err != nil {
+		return fmt.Errorf("failed to deny security group rules from elastic load balancer: %w", err)
+	}
+	if err := c.client.RegisterPortWithElasticLoadBalancer(ctx, desire); err != nil {
+		return fmt.Errorf("failed to add port to elastic load balancer: %w", err)
+	}
+	if err := c.allowSecurityGroupRulesFromElasticLoadBalancer(ctx, desire, desire.BalancingTargets); err != nil {
+		return fmt.Errorf("failed to allow security group rules from elastic load balancer: %w", err)
+	}
+
+	return nil
+}
+
+// configureElasticLoadBalancerHealthCheck updates the health check of the listener in place.
+// The security group rules for the new health check are allowed before it is applied,
+// and the rules for the old one are denied after it is applied unless another listener of all desire needs them
+func (c *Cloud) configureElasticLoadBalancerHealthCheck(ctx context.Context, current, desire *ElasticLoadBalancer, all []ElasticLoadBalancer) error {
+	klog.Infof(
+		"Configuring health check of elastic load balancer %q (%d -> %d): %s (interval: %d, unhealthy threshold: %d)",
+		current.Name, current.LoadBalancerPort, current.InstancePort,
+		desire.HealthCheckTarget, desire.HealthCheckInterval, desire.HealthCheckUnhealthyThreshold,
+	)
+
+	currentRules, err := securityGroupRulesOfElasticLoadBalancer(ctx, current)
+	if err != nil {
+		return err
+	}
+	desireRules, err := securityGroupRulesOfElasticLoadBalancers(ctx, all)
+	if err != nil {
+		return err
+	}
+	listenerRules, err := securityGroupRulesOfElasticLoadBalancer(ctx, desire)
+	if err != nil {
+		return err
+	}
+
+	if err := c.authorizeSecurityGroupRules(ctx, current.BalancingTargets, securityGroupRuleDifferences(listenerRules, currentRules)); err != nil {
+		return fmt.Errorf("failed to allow security group rules from elastic load balancer: %w", err)
+	}
+	if err := c.client.ConfigureElasticLoadBalancerHealthCheck(ctx, desire); err != nil {
+		return fmt.Errorf("failed to configure health check: %w", err)
+	}
+	if err := c.client.WaitElasticLoadBalancerApplied(ctx, current.Name); err != nil {
+		return err
+	}
+	if err := c.revokeSecurityGroupRules(ctx, current.BalancingTargets, securityGroupRuleDifferences(currentRules, desireRules)); err != nil {
+		return fmt.Errorf("failed to deny security group rules from elastic load balancer: %w", err)
+	}
+
+	return nil
+}
+
+// checkElasticLoadBalancerSourceRanges refuses to create the elastic load balancer restricting the source ranges.
+// Elastic load balancers have no filter in the API, and the security groups of the nodes can not restrict the source
+// because the traffic comes from the VIP, so the ranges are refused rather than exposing the service to all.
+// The existing elastic load balancers of such services (e.g. created by older versions) are still reconciled with a warning event,
+// so that their services can be updated and deleted until they migrate to l4 load balancers
+func (c *Cloud) checkElasticLoadBalancerSourceRanges(ctx context.Context, loadBalancerName string, service *v1.Service) error {
+	sourceRanges, err := servicehelpers.GetLoadBalancerSourceRanges(service)
+	if err != nil {
+		return err
+	}
+	if servicehelpers.IsAllowAll(sourceRanges) {
+		return nil
+	}
+
+	if _, err := c.client.DescribeElasticLoadBalancers(ctx, loadBalancerName); err != nil {
+		if IsNotFound(err) {
+			return fmt.Errorf(
+				"source ranges %v of service %q are not supported by elastic load balancer: use %s=lb to restrict the source",
+				sourceRanges.StringSlice(), service.GetName(), ServiceAnnotationLoadBalancerType,
+			)
+		}
+		return fmt.Errorf("failed to describe elastic load balancer %q: %w", loadBalancerName, err)
+	}
+
+	c.warnService(service, "SourceRangesNotEnforced", fmt.Sprintf(
+		"source ranges %v are not enforced by elastic load balancer %q: use %s=lb to restrict the source",
+		sourceRanges.StringSlice(), loadBalancerName, ServiceAnnotationLoadBalancerType,
+	))
+	return nil
+}
+
 func NewElasticLoadBalancerFromService(loadBalancerName string, instances []Instance, service *v1.Service) ([]ElasticLoadBalancer, error) {
 	portCount := len(service.Spec.Ports)
 
@@ -389,6 +570,20 @@ func NewElasticLoadBalancerFromService(loadBalancerName string, instances []Inst
 }
 
 func (c *Cloud) allowSecurityGroupRulesFromElasticLoadBalancer(ctx context.Context, elasticLoadBalancer *ElasticLoadBalancer, instances []Instance) error {
+	securityGroupRules, err := securityGroupRulesOfElasticLoadBalancer(ctx, elasticLoadBalancer)
+	if err != nil {
+		return err
+	}
+
+	return c.authorizeSecurityGroupRules(ctx, instances, securityGroupRules)
+}
+
+// authorizeSecurityGroupRules authorizes the rules in the security groups of the instances
+func (c *Cloud) authorizeSecurityGroupRules(ctx context.Context, instances []Instance, securityGroupRules []SecurityGroupRule) error {
+	if len(securityGroupRules) == 0 {
+		return nil
+	}
+
 	instanceIDs := []string{}
 	for _, instance := range instances {
 		instanceIDs = append(instanceIDs, instance.InstanceID)
@@ -399,16 +594,11 @@ func (c *Cloud) allowSecurityGroupRulesFromElasticLoadBalancer(ctx context.Conte
 		return err
 	}
 
-	securityGroupRules, err := securityGroupRulesOfElasticLoadBalancer(ctx, elasticLoadBalancer)
-	if err != nil {
-		return err
-	}
-
 	for _, securityGroup := range securityGroups {
 		for _, securityGroupRule := range securityGroupRules {
 			err = c.client.AuthorizeSecurityGroupIngress(ctx, securityGroup.GroupName, &securityGroupRule)
 			if err != nil {
-				if IsAPIError(err, errorCodeSecurityGroupDuplicate) {
+				if IsDuplicate(err) {
 					// ignore error
 				} else {
 					return err
@@ -425,6 +615,20 @@ func (c *Cloud) allowSecurityGroupRulesFromElasticLoadBalancer(ctx context.Conte
 }
 
 func (c *Cloud) denySecurityGroupRulesFromElasticLoadBalancer(ctx context.Context, elasticLoadBalancer *ElasticLoadBalancer, instances []Instance) error {
+	securityGroupRules, err := securityGroupRulesOfElasticLoadBalancer(ctx, elasticLoadBalancer)
+	if err != nil {
+		return err
+	}
+
+	return c.revokeSecurityGroupRules(ctx, instances, securityGroupRules)
+}
+
+// revokeSecurityGroupRules revokes the rules from the security groups of the instances
+func (c *Cloud) revokeSecurityGroupRules(ctx context.Context, instances []Instance, securityGroupRules []SecurityGroupRule) error {
+	if len(securityGroupRules) == 0 {
+		return nil
+	}
+
 	instanceIDs := []string{}
 	for _, instance := range instances {
 		instanceIDs = append(instanceIDs, instance.InstanceID)
@@ -435,16 +639,11 @@ func (c *Cloud) denySecurityGroupRulesFromElasticLoadBalancer(ctx context.Contex
 		return err
 	}
 
-	securityGroupRules, err := securityGroupRulesOfElasticLoadBalancer(ctx, elasticLoadBalancer)
-	if err != nil {
-		return err
-	}
-
 	for _, securityGroup := range securityGroups {
 		for _, securityGroupRule := range securityGroupRules {
 			err = c.client.RevokeSecurityGroupIngress(ctx, securityGroup.GroupName, &securityGroupRule)
 			if err != nil {
-				if IsAPIError(err, errorCodeSecurityGroupIngressNotFound) {
+				if IsNotFound(err) {
 					// ignore error
 				} else {
 					return err
@@ -460,6 +659,19 @@ func (c *Cloud) denySecurityGroupRulesFromElasticLoadBalancer(ctx context.Contex
 	return nil
 }
 
+// securityGroupRulesOfElasticLoadBalancers returns the security group rules needed by any of the listeners
+func securityGroupRulesOfElasticLoadBalancers(ctx context.Context, elasticLoadBalancers []ElasticLoadBalancer) ([]SecurityGroupRule, error) {
+	securityGroupRules := []SecurityGroupRule{}
+	for i := range elasticLoadBalancers {
+		rules, err := securityGroupRulesOfElasticLoadBalancer(ctx, &elasticLoadBalancers[i])
+		if err != nil {
+			return nil, err
+		}
+		securityGroupRules = append(securityGroupRules, rules...)
+	}
+	return securityGroupRules, nil
+}
+
 func securityGroupRulesOfElasticLoadBalancer(ctx context.Context, elasticLoadBalancer *ElasticLoadBalancer) ([]SecurityGroupRule, error) {
 	securityGroupRules := []SecurityGroupRule{}
 	VIPRanges := []string{elasticLoadBalancer.VIP}
@@ -585,11 +797,8 @@ func separateHealthCheckTarget(healthCheckTarget string) (string, string) {
 }
 
 func (c *Cloud) updateElasticLoadBalancer(ctx context.Context, clusterName string, service *v1.Service) error {
-	// get elastic load balancer name
-	loadBalancerName := c.GetLoadBalancerName(ctx, clusterName, service)
-
 	// describe load balancer
-	loadBalancers, err := c.client.DescribeElasticLoadBalancers(ctx, loadBalancerName)
+	loadBalancerName, loadBalancers, err := c.describeElasticLoadBalancersOfService(ctx, clusterName, service)
 	if err != nil {
 		return err
 	}
@@ -597,29 +806,55 @@ func (c *Cloud) updateElasticLoadBalancer(ctx context.Context, clusterName strin
 		return fmt.Errorf("load balancer %q not found", loadBalancerName)
 	}
 
-	return nil
+	return c.verifyElasticLoadBalancerOwner(ctx, clusterName, service, loadBalancerName, loadBalancers)
 }
 
 func (c *Cloud) ensureElasticLoadBalancerDeleted(ctx context.Context, clusterName string, service *v1.Service) error {
-	// get elastic load balancer name
-	loadBalancerName := c.GetLoadBalancerName(ctx, clusterName, service)
-
 	// describe load balancer
-	loadBalancers, err := c.client.DescribeElasticLoadBalancers(ctx, loadBalancerName)
+	loadBalancerName, loadBalancers, err := c.describeElasticLoadBalancersOfService(ctx, clusterName, service)
 	if err != nil {
 		switch {
-		case IsAPIError(err, errorCodeElasticLoadBalancerNotFound):
+		case IsNotFound(err):
 			klog.Infof("Load balancer %q is not found", loadBalancerName)
-			return nil
+			return c.forgetLoadBalancer(ctx, "elb", loadBalancerName)
 		}
 		return err
 	}
 	if len(loadBalancers) == 0 {
 		klog.Infof("Load balancer %q already deleted", loadBalancerName)
+		return c.forgetLoadBalancer(ctx, "elb", loadBalancerName)
+	}
+	if err := c.verifyElasticLoadBalancerOwner(ctx, clusterName, service, loadBalancerName, loadBalancers); err != nil {
+		return fmt.Errorf("refusing to delete load balancer: %w", err)
+	}
+
+	if err := c.deleteElasticLoadBalancers(ctx, loadBalancers); err != nil {
+		return err
+	}
+	return c.forgetLoadBalancer(ctx, "elb", loadBalancerName)
+}
+
+// deleteElasticLoadBalancers deletes all ports of the elastic load balancer and
+// the security group rules allowing the traffic from them.
+// The rules are recorded before the deletion, so that they are revoked by the garbage collector if left behind
+func (c *Cloud) deleteElasticLoadBalancers(ctx context.Context, loadBalancers []ElasticLoadBalancer) error {
+	if len(loadBalancers) == 0 {
 		return nil
 	}
+	instanceIDs := []string{}
+	securityGroupRules := []SecurityGroupRule{}
+	for _, lb := range loadBalancers {
+		rules, err := securityGroupRulesOfElasticLoadBalancer(ctx, &lb)
+		if err != nil {
+			return err
+		}
+		securityGroupRules = append(securityGroupRules, rules...)
+		instanceIDs = append(instanceIDs, lo.Map(lb.BalancingTargets, func(instance Instance, _ int) string { return instance.InstanceID })...)
+	}
+	if err := c.recordSecurityGroupRulesToRevoke(ctx, loadBalancers[0].Name, lo.Uniq(instanceIDs), securityGroupRules); err != nil {
+		return err
+	}
 
-	// delete load balancer
 	for _, lb := range loadBalancers {
 		klog.Infof("Deleting LoadBalancer %q (%d -> %d)", lb.Name, lb.LoadBalancerPort, lb.InstancePort)
 		if err := c.client.DeleteElasticLoadBalancer(ctx, &lb); err != nil {
@@ -646,6 +881,60 @@ func findElasticLoadBalancer(from []ElasticLoadBalancer, target ElasticLoadBalan
 	)
 }
 
+// elasticLoadBalancerListenerEquals returns true if the listener settings which can not be updated in place are the same.
+// The balancing type of the target is not compared if it is not specified
+func elasticLoadBalancerListenerEquals(target, other ElasticLoadBalancer) bool {
+	return target.Protocol == other.Protocol &&
+		(target.BalancingType == 0 || target.BalancingType == other.BalancingType)
+}
+
+// elasticLoadBalancerListenerDifference returns the listener setting which differs, and its values of other and target
+func elasticLoadBalancerListenerDifference(target, other ElasticLoadBalancer) (string, string, string) {
+	if target.Protocol != other.Protocol {
+		return "protocol", other.Protocol, target.Protocol
+	}
+	return "balancing type", strconv.Itoa(int(other.BalancingType)), strconv.Itoa(int(target.BalancingType))
+}
+
+// elasticLoadBalancerHealthCheckEquals returns true if the health check settings of the listeners are the same
+func elasticLoadBalancerHealthCheckEquals(target, other ElasticLoadBalancer) bool {
+	return target.HealthCheckTarget == other.HealthCheckTarget &&
+		target.HealthCheckInterval == other.HealthCheckInterval &&
+		target.HealthCheckUnhealthyThreshold == other.HealthCheckUnhealthyThreshold
+}
+
+func securityGroupRuleDifferences(target, other []SecurityGroupRule) []SecurityGroupRule {
+	diff := []SecurityGroupRule{}
+	for _, x := range target {
+		found := false
+		for _, y := range other {
+			if x.Equals(y) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			diff = append(diff, x)
+		}
+	}
+
+	return diff
+}
+
+// elasticLoadBalancerSpecUpdate returns the network volume and the accounting type of desire which differ from current.
+// The values not specified in desire are left as is. It returns false if there is nothing to update
+func elasticLoadBalancerSpecUpdate(desire, current ElasticLoadBalancer) (ElasticLoadBalancer, bool) {
+	toUpdate := ElasticLoadBalancer{Name: current.Name}
+	if desire.NetworkVolume != 0 && desire.NetworkVolume != current.NetworkVolume {
+		toUpdate.NetworkVolume = desire.NetworkVolume
+	}
+	if desire.AccountingType != "" && desire.AccountingType != current.AccountingType {
+		toUpdate.AccountingType = desire.AccountingType
+	}
+	return toUpdate, toUpdate.NetworkVolume != 0 || toUpdate.AccountingType != ""
+}
+
 func elasticLoadBalancerDifferences(target, other []ElasticLoadBalancer) []ElasticLoadBalancer {
 	diff := []ElasticLoadBalancer{}
 	for _, x := range target {
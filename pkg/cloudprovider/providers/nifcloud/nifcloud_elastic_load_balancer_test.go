@@ -2,7 +2,6 @@ package nifcloud_test
 
 import (
 	"context"
-	"strings"
 
 	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
 	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
@@ -16,6 +15,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 )
 
 var _ = Describe("getElasticLoadBalancer", func() {
@@ -27,7 +28,7 @@ var _ = Describe("getElasticLoadBalancer", func() {
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		loadBalancerUID = types.UID(uuid.NewString())
-		loadBalancerName = strings.Replace(string(loadBalancerUID), "-", "", -1)[:nifcloud.ExportMaxLoadBalancerNameLength]
+		loadBalancerName = nifcloud.ExportLoadBalancerName("testCluster", loadBalancerUID)
 	})
 
 	AfterEach(func() {
@@ -38,12 +39,6 @@ var _ = Describe("getElasticLoadBalancer", func() {
 		It("return the status", func() {
 			ctx := context.Background()
 			clusterName := "testCluster"
-			service := &corev1.Service{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: clusterName,
-					UID:  loadBalancerUID,
-				},
-			}
 			testIPAddress := "203.0.113.1"
 
 			expectedStatus := &corev1.LoadBalancerStatus{
@@ -53,14 +48,21 @@ var _ = Describe("getElasticLoadBalancer", func() {
 					},
 				},
 			}
+			service := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: clusterName,
+					UID:  loadBalancerUID,
+				},
+			}
 
 			c := nifcloud.NewMockCloudAPIClient(ctrl)
 			c.EXPECT().
 				DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
 				Return([]nifcloud.ElasticLoadBalancer{
 					{
-						Name: loadBalancerName,
-						VIP:  testIPAddress,
+						Name:        loadBalancerName,
+						VIP:         testIPAddress,
+						Description: nifcloud.ExportLoadBalancerOwner(clusterName, service),
 					},
 				}, nil).
 				Times(1)
@@ -68,6 +70,7 @@ var _ = Describe("getElasticLoadBalancer", func() {
 			cloud := &nifcloud.Cloud{}
 			cloud.SetClient(c)
 			cloud.SetRegion(region)
+			cloud.SetKubeClient(fake.NewSimpleClientset())
 
 			status, exists, err := nifcloud.ExportGetElasticLoadBalancer(cloud, ctx, clusterName, service)
 			Expect(err).ShouldNot(HaveOccurred())
@@ -87,13 +90,17 @@ var _ = Describe("getElasticLoadBalancer", func() {
 				},
 			}
 
-			apiErr := helper.NewMockAPIError(nifcloud.ExportErrorCodeElasticLoadBalancerNotFound)
+			apiErr := helper.NewMockAPIError(helper.ErrorCodeElasticLoadBalancerNotFound)
 
 			c := nifcloud.NewMockCloudAPIClient(ctrl)
 			c.EXPECT().
 				DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
 				Return([]nifcloud.ElasticLoadBalancer{}, apiErr).
 				Times(1)
+			c.EXPECT().
+				DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(nifcloud.ExportLegacyLoadBalancerName(service))).
+				Return([]nifcloud.ElasticLoadBalancer{}, apiErr).
+				Times(1)
 
 			cloud := &nifcloud.Cloud{}
 			cloud.SetClient(c)
@@ -148,7 +155,7 @@ var _ = Describe("ensureElasticLoadBalancer", func() {
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		loadBalancerUID = types.UID(uuid.NewString())
-		loadBalancerName = strings.Replace(string(loadBalancerUID), "-", "", -1)[:nifcloud.ExportMaxLoadBalancerNameLength]
+		loadBalancerName = nifcloud.ExportLoadBalancerName("testCluster", loadBalancerUID)
 	})
 
 	AfterEach(func() {
@@ -161,6 +168,7 @@ var _ = Describe("ensureElasticLoadBalancer", func() {
 				ctx := context.Background()
 				testIPAddress := "203.0.113.1"
 				testDesire := helper.NewTestElasticLoadBalancer(loadBalancerName)
+				testDesire[0].Description = "k8s:testcluster/default/testsvc/testuid"
 				createdELB := helper.NewTestElasticLoadBalancer(loadBalancerName)
 				createdELB[0].VIP = testIPAddress
 				createdELB[0].NetworkInterfaces[0].SystemIpAddresses = []string{"203.0.113.10", "203.0.113.11"}
@@ -176,7 +184,7 @@ var _ = Describe("ensureElasticLoadBalancer", func() {
 				}
 
 				c := nifcloud.NewMockCloudAPIClient(ctrl)
-				notFoundErr := helper.NewMockAPIError(nifcloud.ExportErrorCodeElasticLoadBalancerNotFound)
+				notFoundErr := helper.NewMockAPIError(helper.ErrorCodeElasticLoadBalancerNotFound)
 				gomock.InOrder(
 					c.EXPECT().
 						DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
@@ -238,10 +246,12 @@ var _ = Describe("ensureElasticLoadBalancer", func() {
 				cloud := &nifcloud.Cloud{}
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
+				cloud.SetKubeClient(fake.NewSimpleClientset())
 
-				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
+				Expect(nifcloud.LoadBalancerRecordOwners(ctx, cloud)).Should(Equal(map[string]string{"elb." + loadBalancerName: testDesire[0].Description}))
 			})
 		})
 
@@ -267,7 +277,7 @@ var _ = Describe("ensureElasticLoadBalancer", func() {
 				}
 
 				c := nifcloud.NewMockCloudAPIClient(ctrl)
-				notFoundErr := helper.NewMockAPIError(nifcloud.ExportErrorCodeElasticLoadBalancerNotFound)
+				notFoundErr := helper.NewMockAPIError(helper.ErrorCodeElasticLoadBalancerNotFound)
 				gomock.InOrder(
 					c.EXPECT().
 						DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
@@ -354,8 +364,9 @@ var _ = Describe("ensureElasticLoadBalancer", func() {
 				cloud := &nifcloud.Cloud{}
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
+				cloud.SetKubeClient(fake.NewSimpleClientset())
 
-				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
 			})
@@ -449,7 +460,7 @@ var _ = Describe("ensureElasticLoadBalancer", func() {
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
 
-				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
 			})
@@ -543,7 +554,7 @@ var _ = Describe("ensureElasticLoadBalancer", func() {
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
 
-				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
 			})
@@ -673,7 +684,7 @@ var _ = Describe("ensureElasticLoadBalancer", func() {
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
 
-				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
 			})
@@ -764,7 +775,7 @@ var _ = Describe("ensureElasticLoadBalancer", func() {
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
 
-				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
 			})
@@ -854,11 +865,310 @@ var _ = Describe("ensureElasticLoadBalancer", func() {
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
 
-				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(*status).Should(Equal(*expectedStatus))
+			})
+		})
+
+		Context("change the health check protocol of the elastic load balancer", func() {
+			It("configure the health check and update the security group rules", func() {
+				ctx := context.Background()
+				testIPAddress := "192.168.0.1"
+				systemIPAddresses := []string{"192.168.0.10", "192.168.0.11"}
+				existedELB := helper.NewTestElasticLoadBalancer(loadBalancerName)
+				existedELB[0].VIP = testIPAddress
+				existedELB[0].NetworkInterfaces[0].SystemIpAddresses = systemIPAddresses
+				testDesire := helper.NewTestElasticLoadBalancer(loadBalancerName)
+				testDesire[0].HealthCheckTarget = "ICMP"
+				testDesire[0].HealthCheckInterval = 30
+				testSecurityGroups := helper.NewTestEmptySecurityGroups()
+
+				expectedStatus := &corev1.LoadBalancerStatus{
+					Ingress: []corev1.LoadBalancerIngress{
+						{
+							IP: testIPAddress,
+						},
+					},
+				}
+				authorizedSecurityGroupRules := []nifcloud.SecurityGroupRule{
+					{IpProtocol: "ICMP", InOut: "IN", IpRanges: []string{testIPAddress}},
+					{IpProtocol: "ICMP", InOut: "IN", IpRanges: []string{systemIPAddresses[0]}},
+					{IpProtocol: "ICMP", InOut: "IN", IpRanges: []string{systemIPAddresses[1]}},
+				}
+				revokedSecurityGroupRules := []nifcloud.SecurityGroupRule{
+					{IpProtocol: "TCP", FromPort: 30000, ToPort: 30000, InOut: "IN", IpRanges: []string{systemIPAddresses[0]}},
+					{IpProtocol: "TCP", FromPort: 30000, ToPort: 30000, InOut: "IN", IpRanges: []string{systemIPAddresses[1]}},
+				}
+
+				c := nifcloud.NewMockCloudAPIClient(ctrl)
+				c.EXPECT().
+					DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+					Return(existedELB, nil).
+					Times(1)
+				c.EXPECT().
+					DescribeSecurityGroupsByInstanceIDs(gomock.Any(), gomock.Eq([]string{"testinstance"})).
+					Return(testSecurityGroups, nil).
+					Times(2)
+				c.EXPECT().
+					WaitSecurityGroupApplied(gomock.Any(), gomock.Eq(testSecurityGroups[0].GroupName)).
+					Return(nil).
+					Times(5)
+				callAuthorizeSecurityGroupIngressTime := 0
+				callRevokeSecurityGroupIngressTime := 0
+				gomock.InOrder(
+					c.EXPECT().
+						AuthorizeSecurityGroupIngress(gomock.Any(), gomock.Eq(testSecurityGroups[0].GroupName), gomock.Any()).
+						Do(func(_ context.Context, _ string, securityGroupRule *nifcloud.SecurityGroupRule) {
+							Expect(*securityGroupRule).Should(Equal(authorizedSecurityGroupRules[callAuthorizeSecurityGroupIngressTime]))
+							callAuthorizeSecurityGroupIngressTime += 1
+						}).
+						Return(nil).
+						Times(3),
+					c.EXPECT().
+						ConfigureElasticLoadBalancerHealthCheck(gomock.Any(), gomock.Any()).
+						Do(func(_ context.Context, elasticLoadBalancer *nifcloud.ElasticLoadBalancer) {
+							Expect(elasticLoadBalancer.HealthCheckTarget).Should(Equal("ICMP"))
+							Expect(elasticLoadBalancer.HealthCheckInterval).Should(Equal(int32(30)))
+						}).
+						Return(nil).
+						Times(1),
+					c.EXPECT().
+						WaitElasticLoadBalancerApplied(gomock.Any(), gomock.Eq(loadBalancerName)).
+						Return(nil).
+						Times(1),
+					c.EXPECT().
+						RevokeSecurityGroupIngress(gomock.Any(), gomock.Eq(testSecurityGroups[0].GroupName), gomock.Any()).
+						Do(func(_ context.Context, _ string, securityGroupRule *nifcloud.SecurityGroupRule) {
+							Expect(*securityGroupRule).Should(Equal(revokedSecurityGroupRules[callRevokeSecurityGroupIngressTime]))
+							callRevokeSecurityGroupIngressTime += 1
+						}).
+						Return(nil).
+						Times(2),
+				)
+
+				cloud := &nifcloud.Cloud{}
+				cloud.SetClient(c)
+				cloud.SetRegion(region)
+
+				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
 			})
 		})
+
+		Context("update the network volume and the accounting type of the elastic load balancer", func() {
+			It("update the elastic load balancer and wait until applied", func() {
+				ctx := context.Background()
+				testIPAddress := "203.0.113.1"
+				existedELB := helper.NewTestElasticLoadBalancer(loadBalancerName)
+				existedELB[0].VIP = testIPAddress
+				testDesire := helper.NewTestElasticLoadBalancer(loadBalancerName)
+				testDesire[0].NetworkVolume = 500
+
+				c := nifcloud.NewMockCloudAPIClient(ctrl)
+				c.EXPECT().
+					DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+					Return(existedELB, nil).
+					Times(1)
+				gomock.InOrder(
+					c.EXPECT().
+						UpdateElasticLoadBalancer(gomock.Any(), gomock.Eq(&nifcloud.ElasticLoadBalancer{
+							Name:          loadBalancerName,
+							NetworkVolume: 500,
+						})).
+						Return(nil).
+						Times(1),
+					c.EXPECT().
+						WaitElasticLoadBalancerApplied(gomock.Any(), gomock.Eq(loadBalancerName)).
+						Return(nil).
+						Times(1),
+				)
+
+				cloud := &nifcloud.Cloud{}
+				cloud.SetClient(c)
+				cloud.SetRegion(region)
+
+				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(status.Ingress[0].IP).Should(Equal(testIPAddress))
+			})
+		})
+
+		Context("change the balancing type of the elastic load balancer", func() {
+			It("replace the ports", func() {
+				ctx := context.Background()
+				testIPAddress := "203.0.113.1"
+				existedELB := helper.NewTestElasticLoadBalancerWithTwoPort(loadBalancerName)
+				for i := range existedELB {
+					existedELB[i].VIP = testIPAddress
+				}
+				testDesire := helper.NewTestElasticLoadBalancerWithTwoPort(loadBalancerName)
+				for i := range testDesire {
+					testDesire[i].BalancingType = 2
+				}
+				testSecurityGroups := helper.NewTestEmptySecurityGroups()
+
+				c := nifcloud.NewMockCloudAPIClient(ctrl)
+				c.EXPECT().
+					DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+					Return(existedELB, nil).
+					Times(1)
+				for i := range existedELB {
+					gomock.InOrder(
+						c.EXPECT().
+							DeleteElasticLoadBalancer(gomock.Any(), gomock.Eq(&existedELB[i])).
+							Return(nil).
+							Times(1),
+						c.EXPECT().
+							RegisterPortWithElasticLoadBalancer(gomock.Any(), gomock.Eq(&testDesire[i])).
+							Return(nil).
+							Times(1),
+					)
+				}
+				c.EXPECT().
+					DescribeSecurityGroupsByInstanceIDs(gomock.Any(), gomock.Any()).
+					Return(testSecurityGroups, nil).
+					AnyTimes()
+				c.EXPECT().
+					RevokeSecurityGroupIngress(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil).
+					AnyTimes()
+				c.EXPECT().
+					AuthorizeSecurityGroupIngress(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil).
+					AnyTimes()
+				c.EXPECT().
+					WaitSecurityGroupApplied(gomock.Any(), gomock.Any()).
+					Return(nil).
+					AnyTimes()
+
+				cloud := &nifcloud.Cloud{}
+				cloud.SetClient(c)
+				cloud.SetRegion(region)
+
+				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(status.Ingress[0].IP).Should(Equal(testIPAddress))
+			})
+
+			It("keeps the security group rules shared with the other port", func() {
+				ctx := context.Background()
+				testIPAddress := "203.0.113.1"
+				systemIPAddresses := []string{"192.168.0.1", "192.168.0.2"}
+				existedELB := helper.NewTestElasticLoadBalancerWithTwoPort(loadBalancerName)
+				for i := range existedELB {
+					existedELB[i].VIP = testIPAddress
+					existedELB[i].HealthCheckTarget = "ICMP"
+					existedELB[i].NetworkInterfaces[0].SystemIpAddresses = systemIPAddresses
+				}
+				testDesire := helper.NewTestElasticLoadBalancerWithTwoPort(loadBalancerName)
+				for i := range testDesire {
+					testDesire[i].HealthCheckTarget = "ICMP"
+				}
+				testDesire[1].BalancingType = 2
+				testSecurityGroups := helper.NewTestEmptySecurityGroups()
+
+				c := nifcloud.NewMockCloudAPIClient(ctrl)
+				c.EXPECT().
+					DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+					Return(existedELB, nil).
+					Times(1)
+				gomock.InOrder(
+					c.EXPECT().
+						DeleteElasticLoadBalancer(gomock.Any(), gomock.Eq(&existedELB[1])).
+						Return(nil).
+						Times(1),
+					c.EXPECT().
+						RegisterPortWithElasticLoadBalancer(gomock.Any(), gomock.Eq(&testDesire[1])).
+						Return(nil).
+						Times(1),
+				)
+				c.EXPECT().
+					DescribeSecurityGroupsByInstanceIDs(gomock.Any(), gomock.Any()).
+					Return(testSecurityGroups, nil).
+					AnyTimes()
+				c.EXPECT().
+					AuthorizeSecurityGroupIngress(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil).
+					AnyTimes()
+				c.EXPECT().
+					WaitSecurityGroupApplied(gomock.Any(), gomock.Any()).
+					Return(nil).
+					AnyTimes()
+				// the ICMP rules from the VIP and the system IP addresses are still needed by port 80,
+				// and the rule of port 443 is needed by the registered listener
+				c.EXPECT().
+					RevokeSecurityGroupIngress(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				cloud := &nifcloud.Cloud{}
+				cloud.SetClient(c)
+				cloud.SetRegion(region)
+
+				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(status.Ingress[0].IP).Should(Equal(testIPAddress))
+			})
+
+			It("does not replace the only port", func() {
+				ctx := context.Background()
+				testIPAddress := "203.0.113.1"
+				existedELB := helper.NewTestElasticLoadBalancer(loadBalancerName)
+				existedELB[0].VIP = testIPAddress
+				testDesire := helper.NewTestElasticLoadBalancer(loadBalancerName)
+				testDesire[0].BalancingType = 2
+
+				c := nifcloud.NewMockCloudAPIClient(ctrl)
+				c.EXPECT().
+					DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+					Return(existedELB, nil).
+					Times(1)
+
+				cloud := &nifcloud.Cloud{}
+				cloud.SetClient(c)
+				cloud.SetRegion(region)
+
+				status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(status.Ingress[0].IP).Should(Equal(testIPAddress))
+			})
+
+			It("warns once that the protocol of the only port is not changed", func() {
+				ctx := context.Background()
+				testIPAddress := "203.0.113.1"
+				existedELB := helper.NewTestElasticLoadBalancer(loadBalancerName)
+				existedELB[0].VIP = testIPAddress
+				testService := &corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Name: "testservice", Namespace: "default", UID: loadBalancerUID},
+				}
+
+				c := nifcloud.NewMockCloudAPIClient(ctrl)
+				c.EXPECT().
+					DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+					Return(existedELB, nil).
+					Times(2)
+
+				recorder := record.NewFakeRecorder(2)
+				cloud := &nifcloud.Cloud{}
+				cloud.SetClient(c)
+				cloud.SetRegion(region)
+				cloud.SetEventRecorder(recorder)
+
+				for i := 0; i < 2; i++ {
+					testDesire := helper.NewTestElasticLoadBalancer(loadBalancerName)
+					testDesire[0].Protocol = "HTTP"
+					_, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, ctx, testService, loadBalancerName, testDesire)
+					Expect(err).ShouldNot(HaveOccurred())
+				}
+
+				Expect(recorder.Events).Should(HaveLen(1))
+				event := <-recorder.Events
+				Expect(event).Should(ContainSubstring("ListenerNotUpdated"))
+				Expect(event).Should(ContainSubstring("cannot change the protocol"))
+				Expect(event).Should(ContainSubstring("from TCP to HTTP"))
+			})
+		})
 	})
 })
 
@@ -976,6 +1286,90 @@ var _ = Describe("NewElasticLoadBalancerFromService", func() {
 			Expect(gotELB).Should(Equal(expectELB))
 		})
 	})
+
+	Context("given elastic load balancer that allows all source ranges", func() {
+		It("return the elastic load balancer", func() {
+			testService.Spec.LoadBalancerSourceRanges = []string{"0.0.0.0/0"}
+			testInstances := []nifcloud.Instance{*helper.NewTestInstance()}
+			expectELB := helper.NewTestElasticLoadBalancer(loadBalancerName)
+			gotELB, err := nifcloud.NewElasticLoadBalancerFromService(loadBalancerName, testInstances, &testService)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotELB).Should(Equal(expectELB))
+		})
+	})
+})
+
+var _ = Describe("checkElasticLoadBalancerSourceRanges", func() {
+	var ctrl *gomock.Controller
+	var testService corev1.Service
+	loadBalancerName := "testloadbalancer"
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		testService = corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "testsvc",
+				Namespace:   "default",
+				Annotations: map[string]string{nifcloud.ServiceAnnotationLoadBalancerType: "elb"},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("the service allows all source ranges", func() {
+		It("return nil", func() {
+			testService.Spec.LoadBalancerSourceRanges = []string{"0.0.0.0/0"}
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(nifcloud.NewMockCloudAPIClient(ctrl))
+
+			err := nifcloud.ExportCheckElasticLoadBalancerSourceRanges(cloud, context.Background(), loadBalancerName, &testService)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("the service restricts source ranges and the elastic load balancer is not existed", func() {
+		It("return error", func() {
+			testService.Annotations[corev1.AnnotationLoadBalancerSourceRangesKey] = "198.51.100.10/32"
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(nil, helper.NewMockAPIError(helper.ErrorCodeElasticLoadBalancerNotFound)).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+
+			err := nifcloud.ExportCheckElasticLoadBalancerSourceRanges(cloud, context.Background(), loadBalancerName, &testService)
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("198.51.100.10/32"))
+		})
+	})
+
+	Context("the service restricts source ranges and the elastic load balancer is existed", func() {
+		It("return nil with a warning event", func() {
+			testService.Spec.LoadBalancerSourceRanges = []string{"203.0.113.0/24"}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(helper.NewTestElasticLoadBalancer(loadBalancerName), nil).
+				Times(1)
+
+			recorder := record.NewFakeRecorder(1)
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetEventRecorder(recorder)
+
+			err := nifcloud.ExportCheckElasticLoadBalancerSourceRanges(cloud, context.Background(), loadBalancerName, &testService)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(recorder.Events).Should(Receive(And(ContainSubstring("SourceRangesNotEnforced"), ContainSubstring("203.0.113.0/24"))))
+		})
+	})
 })
 
 var _ = Describe("securityGroupRulesOfElasticLoadBalancer", func() {
@@ -1170,7 +1564,7 @@ var _ = Describe("updateElasticLoadBalancer", func() {
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		loadBalancerUID = types.UID(uuid.NewString())
-		loadBalancerName = strings.Replace(string(loadBalancerUID), "-", "", -1)[:nifcloud.ExportMaxLoadBalancerNameLength]
+		loadBalancerName = nifcloud.ExportLoadBalancerName("testCluster", loadBalancerUID)
 		testService = &corev1.Service{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: "testlbsvc",
@@ -1198,6 +1592,9 @@ var _ = Describe("updateElasticLoadBalancer", func() {
 			cloud := &nifcloud.Cloud{}
 			cloud.SetClient(c)
 			cloud.SetRegion(region)
+			cloud.SetKubeClient(fake.NewSimpleClientset(nifcloud.NewLoadBalancerRecords(map[string]string{
+				"elb." + loadBalancerName: nifcloud.ExportLoadBalancerOwner(clusterName, testService),
+			})))
 
 			err := nifcloud.ExportUpdateElasticLoadBalancer(cloud, ctx, clusterName, testService)
 			Expect(err).ShouldNot(HaveOccurred())
@@ -1209,13 +1606,17 @@ var _ = Describe("updateElasticLoadBalancer", func() {
 			ctx := context.Background()
 
 			testELB := []nifcloud.ElasticLoadBalancer{}
-			notFoundErr := helper.NewMockAPIError(nifcloud.ExportErrorCodeElasticLoadBalancerNotFound)
+			notFoundErr := helper.NewMockAPIError(helper.ErrorCodeElasticLoadBalancerNotFound)
 
 			c := nifcloud.NewMockCloudAPIClient(ctrl)
 			c.EXPECT().
 				DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
 				Return(testELB, notFoundErr).
 				Times(1)
+			c.EXPECT().
+				DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(nifcloud.ExportLegacyLoadBalancerName(testService))).
+				Return(testELB, notFoundErr).
+				Times(1)
 
 			cloud := &nifcloud.Cloud{}
 			cloud.SetClient(c)
@@ -1253,7 +1654,7 @@ var _ = Describe("ensureElasticLoadBalancerDeleted", func() {
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		loadBalancerUID = types.UID(uuid.NewString())
-		loadBalancerName = strings.Replace(string(loadBalancerUID), "-", "", -1)[:nifcloud.ExportMaxLoadBalancerNameLength]
+		loadBalancerName = nifcloud.ExportLoadBalancerName("testCluster", loadBalancerUID)
 		testService = &corev1.Service{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: "testlbsvc",
@@ -1332,9 +1733,13 @@ var _ = Describe("ensureElasticLoadBalancerDeleted", func() {
 			cloud := &nifcloud.Cloud{}
 			cloud.SetClient(c)
 			cloud.SetRegion(region)
+			cloud.SetKubeClient(fake.NewSimpleClientset(nifcloud.NewLoadBalancerRecords(map[string]string{
+				"elb." + loadBalancerName: nifcloud.ExportLoadBalancerOwner(clusterName, testService),
+			})))
 
 			err := nifcloud.ExportEnsureElasticLoadBalancerDeleted(cloud, ctx, clusterName, testService)
 			Expect(err).ShouldNot(HaveOccurred())
+			Expect(nifcloud.LoadBalancerRecordOwners(ctx, cloud)).ShouldNot(HaveKey("elb." + loadBalancerName))
 		})
 	})
 
@@ -1343,20 +1748,28 @@ var _ = Describe("ensureElasticLoadBalancerDeleted", func() {
 			ctx := context.Background()
 
 			testELB := []nifcloud.ElasticLoadBalancer{}
-			notFoundErr := helper.NewMockAPIError(nifcloud.ExportErrorCodeElasticLoadBalancerNotFound)
+			notFoundErr := helper.NewMockAPIError(helper.ErrorCodeElasticLoadBalancerNotFound)
 
 			c := nifcloud.NewMockCloudAPIClient(ctrl)
 			c.EXPECT().
 				DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
 				Return(testELB, notFoundErr).
 				Times(1)
+			c.EXPECT().
+				DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(nifcloud.ExportLegacyLoadBalancerName(testService))).
+				Return(testELB, notFoundErr).
+				Times(1)
 
 			cloud := &nifcloud.Cloud{}
 			cloud.SetClient(c)
 			cloud.SetRegion(region)
+			cloud.SetKubeClient(fake.NewSimpleClientset(nifcloud.NewLoadBalancerRecords(map[string]string{
+				"elb." + loadBalancerName: nifcloud.ExportLoadBalancerOwner(clusterName, testService),
+			})))
 
 			err := nifcloud.ExportEnsureElasticLoadBalancerDeleted(cloud, ctx, clusterName, testService)
 			Expect(err).ShouldNot(HaveOccurred())
+			Expect(nifcloud.LoadBalancerRecordOwners(ctx, cloud)).ShouldNot(HaveKey("elb." + loadBalancerName))
 		})
 	})
 })
@@ -1386,6 +1799,46 @@ var _ = Describe("findElasticLoadBalancer", func() {
 	})
 })
 
+var _ = DescribeTable("elasticLoadBalancerListenerEquals",
+	func(modify func(lb *nifcloud.ElasticLoadBalancer), expected bool) {
+		target := helper.NewTestElasticLoadBalancer("testloadbalancer")[0]
+		other := helper.NewTestElasticLoadBalancer("testloadbalancer")[0]
+		modify(&target)
+		Expect(nifcloud.ExportElasticLoadBalancerListenerEquals(target, other)).Should(Equal(expected))
+	},
+	Entry("the listener is the same", func(lb *nifcloud.ElasticLoadBalancer) { lb.HealthCheckTarget = "ICMP" }, true),
+	Entry("the balancing type is not specified", func(lb *nifcloud.ElasticLoadBalancer) { lb.BalancingType = 0 }, true),
+	Entry("the balancing type is changed", func(lb *nifcloud.ElasticLoadBalancer) { lb.BalancingType = 2 }, false),
+)
+
+var _ = DescribeTable("elasticLoadBalancerHealthCheckEquals",
+	func(modify func(lb *nifcloud.ElasticLoadBalancer), expected bool) {
+		target := helper.NewTestElasticLoadBalancer("testloadbalancer")[0]
+		other := helper.NewTestElasticLoadBalancer("testloadbalancer")[0]
+		modify(&other)
+		Expect(nifcloud.ExportElasticLoadBalancerHealthCheckEquals(target, other)).Should(Equal(expected))
+	},
+	Entry("the health check is the same", func(lb *nifcloud.ElasticLoadBalancer) { lb.BalancingType = 2 }, true),
+	Entry("the target is changed", func(lb *nifcloud.ElasticLoadBalancer) { lb.HealthCheckTarget = "ICMP" }, false),
+	Entry("the interval is changed", func(lb *nifcloud.ElasticLoadBalancer) { lb.HealthCheckInterval = 30 }, false),
+	Entry("the unhealthy threshold is changed", func(lb *nifcloud.ElasticLoadBalancer) { lb.HealthCheckUnhealthyThreshold = 3 }, false),
+)
+
+var _ = Describe("securityGroupRuleDifferences", func() {
+	It("return the rules not existed in other", func() {
+		tcpRule := nifcloud.SecurityGroupRule{IpProtocol: "TCP", FromPort: 30000, ToPort: 30000, InOut: "IN", IpRanges: []string{"203.0.113.1"}}
+		icmpRule := nifcloud.SecurityGroupRule{IpProtocol: "ICMP", InOut: "IN", IpRanges: []string{"203.0.113.1"}}
+		otherRangeRule := tcpRule
+		otherRangeRule.IpRanges = []string{"203.0.113.2"}
+
+		got := nifcloud.ExportSecurityGroupRuleDifferences(
+			[]nifcloud.SecurityGroupRule{tcpRule, icmpRule, otherRangeRule},
+			[]nifcloud.SecurityGroupRule{tcpRule},
+		)
+		Expect(got).Should(Equal([]nifcloud.SecurityGroupRule{icmpRule, otherRangeRule}))
+	})
+})
+
 var _ = Describe("elasticLoadBalancerDifferences", func() {
 	var loadBalancerName = "testloadbalancer"
 
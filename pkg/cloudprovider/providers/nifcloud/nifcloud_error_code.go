@@ -1,26 +1,187 @@
 package nifcloud
 
 import (
+	"context"
 	goerrors "errors"
+	"net/http"
+	"strings"
 
 	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	cloudprovider "k8s.io/cloud-provider"
 )
 
+// error codes which can not be categorized by their prefix.
+// The other error codes are categorized by errorCategoryOf
 const (
-	// Instance
-	errorCodeInstanceNotFound = "Client.InvalidParameterNotFound.Instance"
+	// Throttling
+	errorCodeRequestLimitExceeded = "Client.RequestLimitExceeded"
+	errorCodeThrottling           = "Throttling"
 
-	// LoadBalancer
-	errorCodeLoadBalancerNotFound = "Client.InvalidParameterNotFound.LoadBalancer"
-
-	// ElasticLoadBalancer
-	errorCodeElasticLoadBalancerNotFound = "Client.InvalidParameterNotFound.ElasticLoadBalancer"
+	// Authentication
+	errorCodeSignatureDoesNotMatch = "Client.SignatureDoesNotMatch"
+	errorCodeAuthFailure           = "Client.AuthFailure"
+)
 
-	// SecurityGroup
-	errorCodeSecurityGroupIngressNotFound = "Client.InvalidParameterNotFound.SecurityGroupIngress"
-	errorCodeSecurityGroupDuplicate       = "Client.InvalidParameterDuplicate.SecurityGroup"
+// The categories of NIFCLOUD API errors.
+// The errors returned by the API client match them with errors.Is,
+// and the predicates below also work with the raw API errors.
+var (
+	ErrNotFound         = goerrors.New("nifcloud: resource not found")
+	ErrDuplicate        = goerrors.New("nifcloud: resource already exists")
+	ErrThrottled        = goerrors.New("nifcloud: request is throttled")
+	ErrQuotaExceeded    = goerrors.New("nifcloud: quota exceeded")
+	ErrAuthFailure      = goerrors.New("nifcloud: authentication failed")
+	ErrInvalidParameter = goerrors.New("nifcloud: invalid parameter")
+	ErrConflict         = goerrors.New("nifcloud: resource is in use or being processed")
 )
 
+// errorCategoryNames are the names of the categories for metrics labels
+var errorCategoryNames = map[error]string{
+	ErrNotFound:         "not_found",
+	ErrDuplicate:        "duplicate",
+	ErrThrottled:        "throttled",
+	ErrQuotaExceeded:    "quota_exceeded",
+	ErrAuthFailure:      "auth_failure",
+	ErrInvalidParameter: "invalid_parameter",
+	ErrConflict:         "conflict",
+}
+
+// throttleErrorCodes are the error codes returned when the request rate exceeds the limit
+var throttleErrorCodes = map[string]struct{}{
+	errorCodeRequestLimitExceeded: {},
+	errorCodeThrottling:           {},
+}
+
+var authFailureErrorCodes = map[string]struct{}{
+	errorCodeSignatureDoesNotMatch: {},
+	errorCodeAuthFailure:           {},
+}
+
+// categorizedError is the API error with its category
+type categorizedError struct {
+	err      error
+	category error
+}
+
+func (e *categorizedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *categorizedError) Unwrap() []error {
+	return []error{e.err, e.category}
+}
+
+// errorCategoryOf returns the category of the NIFCLOUD API error, or nil if it is not categorized.
+//   - Client.InvalidParameterNotFound.* is ErrNotFound
+//   - Client.InvalidParameterDuplicate.* and Client.Inoperable.*.AlreadyExists are ErrDuplicate
+//   - the other Client.Inoperable.* (e.g. the load balancer still has instances) are ErrConflict
+//   - the other Client.InvalidParameter* and Client.MissingParameter* are ErrInvalidParameter
+func errorCategoryOf(err error) error {
+	for category := range errorCategoryNames {
+		if goerrors.Is(err, category) {
+			return category
+		}
+	}
+
+	var apiErr smithy.APIError
+	if !goerrors.As(err, &apiErr) {
+		return nil
+	}
+	code := apiErr.ErrorCode()
+
+	if _, ok := throttleErrorCodes[code]; ok {
+		return ErrThrottled
+	}
+	if _, ok := authFailureErrorCodes[code]; ok {
+		return ErrAuthFailure
+	}
+
+	var statusErr interface{ HTTPStatusCode() int }
+	if goerrors.As(err, &statusErr) {
+		switch statusErr.HTTPStatusCode() {
+		case http.StatusTooManyRequests:
+			return ErrThrottled
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return ErrAuthFailure
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(code, "Client.InvalidParameterNotFound."):
+		return ErrNotFound
+	case strings.HasPrefix(code, "Client.InvalidParameterDuplicate."):
+		return ErrDuplicate
+	case strings.HasPrefix(code, "Client.Inoperable.") && strings.HasSuffix(code, ".AlreadyExists"):
+		return ErrDuplicate
+	case strings.HasPrefix(code, "Client.Inoperable."):
+		return ErrConflict
+	case strings.HasPrefix(code, "Client.") && strings.Contains(code, "LimitExceeded"):
+		return ErrQuotaExceeded
+	case strings.HasPrefix(code, "Client.InvalidParameter"), strings.HasPrefix(code, "Client.MissingParameter"):
+		return ErrInvalidParameter
+	}
+
+	return nil
+}
+
+// errorCategoryNameOf returns the category name of the error for metrics labels
+func errorCategoryNameOf(err error) string {
+	if category := errorCategoryOf(err); category != nil {
+		return errorCategoryNames[category]
+	}
+	return "other"
+}
+
+// IsNotFound returns true if the error is ErrNotFound or cloudprovider.InstanceNotFound
+func IsNotFound(err error) bool {
+	return goerrors.Is(err, cloudprovider.InstanceNotFound) || errorCategoryOf(err) == ErrNotFound
+}
+
+// IsDuplicate returns true if the error is ErrDuplicate
+func IsDuplicate(err error) bool {
+	return errorCategoryOf(err) == ErrDuplicate
+}
+
+// IsThrottled returns true if the error is ErrThrottled
+func IsThrottled(err error) bool {
+	return errorCategoryOf(err) == ErrThrottled
+}
+
+// IsQuotaExceeded returns true if the error is ErrQuotaExceeded
+func IsQuotaExceeded(err error) bool {
+	return errorCategoryOf(err) == ErrQuotaExceeded
+}
+
+// IsAuthFailure returns true if the error is ErrAuthFailure
+func IsAuthFailure(err error) bool {
+	return errorCategoryOf(err) == ErrAuthFailure
+}
+
+// IsInvalidParameter returns true if the error is ErrInvalidParameter
+func IsInvalidParameter(err error) bool {
+	return errorCategoryOf(err) == ErrInvalidParameter
+}
+
+// IsConflict returns true if the error is ErrConflict
+func IsConflict(err error) bool {
+	return errorCategoryOf(err) == ErrConflict
+}
+
+// addErrorCategoryMiddleware adds the middleware wrapping API errors with their categories to the stack of computing.Client
+func addErrorCategoryMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc(
+		"NIFCLOUDErrorCategory",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			out, metadata, err := next.HandleInitialize(ctx, in)
+			if category := errorCategoryOf(err); category != nil && !goerrors.Is(err, category) {
+				err = &categorizedError{err: err, category: category}
+			}
+			return out, metadata, err
+		},
+	), middleware.After)
+}
+
 func IsAPIError(err error, code string) bool {
 	var awsErr smithy.APIError
 	if goerrors.As(err, &awsErr) {
@@ -28,3 +189,19 @@ func IsAPIError(err error, code string) bool {
 	}
 	return false
 }
+
+// errorCodeOf returns the error code of the NIFCLOUD API error for metrics labels
+func errorCodeOf(err error) string {
+	var awsErr smithy.APIError
+	switch {
+	case err == nil:
+		return ""
+	case goerrors.As(err, &awsErr):
+		return awsErr.ErrorCode()
+	case goerrors.Is(err, context.DeadlineExceeded):
+		return "DeadlineExceeded"
+	case goerrors.Is(err, context.Canceled):
+		return "Canceled"
+	}
+	return "Unknown"
+}
@@ -0,0 +1,91 @@
+package nifcloud_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	cloudprovider "k8s.io/cloud-provider"
+)
+
+var _ = Describe("error category", func() {
+	predicates := map[string]func(error) bool{
+		"not_found":         nifcloud.IsNotFound,
+		"duplicate":         nifcloud.IsDuplicate,
+		"throttled":         nifcloud.IsThrottled,
+		"quota_exceeded":    nifcloud.IsQuotaExceeded,
+		"auth_failure":      nifcloud.IsAuthFailure,
+		"invalid_parameter": nifcloud.IsInvalidParameter,
+		"conflict":          nifcloud.IsConflict,
+	}
+
+	DescribeTable("categorize the error code",
+		func(code string, expected string) {
+			err := fmt.Errorf("failed to call API: %w", helper.NewMockAPIError(code))
+			Expect(nifcloud.ExportErrorCategoryNameOf(err)).Should(Equal(expected))
+			for name, predicate := range predicates {
+				Expect(predicate(err)).Should(Equal(name == expected), "predicate of %s", name)
+			}
+		},
+		Entry(nil, "Client.InvalidParameterNotFound.Instance", "not_found"),
+		Entry(nil, "Client.InvalidParameterNotFound.LoadBalancer", "not_found"),
+		Entry(nil, "Client.InvalidParameterNotFound.LoadBalancerPort", "not_found"),
+		Entry(nil, "Client.InvalidParameterNotFound.ElasticLoadBalancer", "not_found"),
+		Entry(nil, "Client.InvalidParameterNotFound.Protocol.or.ElasticLoadBalancerPort", "not_found"),
+		Entry(nil, "Client.InvalidParameterNotFound.SecurityGroup", "not_found"),
+		Entry(nil, "Client.InvalidParameterNotFound.SecurityGroupIngress", "not_found"),
+		Entry(nil, "Client.InvalidParameterDuplicate.LoadBalancer", "duplicate"),
+		Entry(nil, "Client.InvalidParameterDuplicate.ElasticLoadBalancer", "duplicate"),
+		Entry(nil, "Client.InvalidParameterDuplicate.Protocol.and.ElasticLoadBalancerPort", "duplicate"),
+		Entry(nil, "Client.InvalidParameterDuplicate.IpAddress", "duplicate"),
+		Entry(nil, "Client.InvalidParameterDuplicate.SecurityGroup", "duplicate"),
+		Entry(nil, "Client.Inoperable.LoadBalancerPort.AlreadyExists", "duplicate"),
+		Entry(nil, "Client.Inoperable.LoadBalancer.HavingRegisteredInstance", "conflict"),
+		Entry(nil, "Client.Inoperable.ElasticLoadBalancer.HavingRegisteredInstance", "conflict"),
+		Entry(nil, "Client.RequestLimitExceeded", "throttled"),
+		Entry(nil, "Throttling", "throttled"),
+		Entry(nil, "Client.ResourceLimitExceeded.LoadBalancer", "quota_exceeded"),
+		Entry(nil, "Client.SignatureDoesNotMatch", "auth_failure"),
+		Entry(nil, "Client.InvalidParameter.NetworkVolume", "invalid_parameter"),
+		Entry(nil, "Client.MissingParameter.LoadBalancerName", "invalid_parameter"),
+		Entry(nil, "Server.InternalError", "other"),
+	)
+
+	Context("the error is cloudprovider.InstanceNotFound", func() {
+		It("is not found", func() {
+			Expect(nifcloud.IsNotFound(cloudprovider.InstanceNotFound)).Should(BeTrue())
+		})
+	})
+
+	Context("the error is not an API error", func() {
+		It("is not categorized", func() {
+			err := errors.New("connection reset")
+			Expect(nifcloud.ExportErrorCategoryNameOf(err)).Should(Equal("other"))
+			for _, predicate := range predicates {
+				Expect(predicate(err)).Should(BeFalse())
+			}
+		})
+	})
+
+	Context("the error is returned by the API client", func() {
+		It("match the category sentinel error", func() {
+			ts := helper.NewTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write(lo.Must(os.ReadFile("./testdata/describe_load_balancers_not_found_load_balancer.xml")))
+			}))
+			defer ts.Close()
+			testNifcloudAPIClient := nifcloud.NewNIFCLOUDAPIClientWithEndpoint("testkey", "testsecretkey", "jp-east-1", ts.URL)
+
+			_, err := testNifcloudAPIClient.DescribeLoadBalancers(context.Background(), "testlb")
+			Expect(err).Should(MatchError(nifcloud.ErrNotFound))
+			Expect(nifcloud.IsAPIError(err, helper.ErrorCodeLoadBalancerNotFound)).Should(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,77 @@
+package nifcloud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ForeignNodesLoadBalancerBackendsExclude does not register foreign nodes to load balancers
+	ForeignNodesLoadBalancerBackendsExclude = "exclude"
+	// ForeignNodesLoadBalancerBackendsInclude registers foreign nodes to load balancers
+	// if their instances are found by the instance resolver
+	ForeignNodesLoadBalancerBackendsInclude = "include"
+)
+
+func (fnc ForeignNodesConfig) validate() error {
+	switch fnc.LoadBalancerBackends {
+	case "", ForeignNodesLoadBalancerBackendsExclude, ForeignNodesLoadBalancerBackendsInclude:
+		return nil
+	default:
+		return fmt.Errorf("loadBalancerBackends %q is not supported", fnc.LoadBalancerBackends)
+	}
+}
+
+// isForeignProviderID returns true if the provider id is given by another cloud provider
+func isForeignProviderID(providerID string) bool {
+	scheme, _, found := strings.Cut(providerID, "://")
+	return found && scheme != ProviderName
+}
+
+// isForeignNode returns true if the node is not managed by this cloud provider.
+// Foreign nodes exist regardless of NIFCLOUD instances and are never shut down.
+func (c *Cloud) isForeignNode(node *v1.Node) bool {
+	if isForeignProviderID(node.Spec.ProviderID) {
+		return true
+	}
+	if label := c.config.Node.Foreign.Label; label != "" {
+		return node.Labels[label] == "true"
+	}
+	return false
+}
+
+// loadBalancerBackends returns the instances of the nodes to be registered to the load balancer
+func (c *Cloud) loadBalancerBackends(ctx context.Context, nodes []*v1.Node) ([]Instance, error) {
+	var managedNodes, foreignNodes []*v1.Node
+	for _, node := range nodes {
+		if c.isForeignNode(node) {
+			foreignNodes = append(foreignNodes, node)
+		} else {
+			managedNodes = append(managedNodes, node)
+		}
+	}
+
+	instances, err := c.instancesByNodes(ctx, managedNodes)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, node := range foreignNodes {
+		if c.config.Node.Foreign.LoadBalancerBackends != ForeignNodesLoadBalancerBackendsInclude {
+			klog.V(4).Infof("Foreign node %q is excluded from load balancer backends", node.Name)
+			continue
+		}
+		instance, err := c.instanceByNode(ctx, node)
+		if err != nil {
+			klog.Warningf("Foreign node %q is excluded from load balancer backends: %v", node.Name, err)
+			continue
+		}
+		instances = append(instances, *instance)
+	}
+
+	return instances, nil
+}
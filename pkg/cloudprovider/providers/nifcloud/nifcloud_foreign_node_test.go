@@ -0,0 +1,164 @@
+package nifcloud_test
+
+import (
+	"context"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cloudprovider "k8s.io/cloud-provider"
+)
+
+var _ = Describe("foreign node", func() {
+	var ctrl *gomock.Controller
+	var region string = "east1"
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	newCloud := func(client nifcloud.CloudAPIClient, foreign nifcloud.ForeignNodesConfig) *nifcloud.Cloud {
+		cloud := &nifcloud.Cloud{}
+		cloud.SetClient(client)
+		cloud.SetRegion(region)
+		cloud.SetConfig(nifcloud.CloudConfig{Node: nifcloud.NodeConfig{Foreign: foreign}})
+		return cloud
+	}
+
+	onPremNode := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "onprem-1",
+			Labels: map[string]string{"example.com/on-prem": "true"},
+		},
+		Status: v1.NodeStatus{
+			Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.1.0.1"}},
+		},
+	}
+	otherCloudNode := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-1"},
+		Spec:       v1.NodeSpec{ProviderID: "aws:///us-east-1a/i-0123456789abcdef0"},
+	}
+
+	Context("the node has a provider id of another cloud", func() {
+		It("exists, is not shut down and keeps its metadata without calling API", func() {
+			ctx := context.Background()
+			cloud := newCloud(nifcloud.NewMockCloudAPIClient(ctrl), nifcloud.ForeignNodesConfig{})
+
+			exists, err := cloud.InstanceExists(ctx, otherCloudNode)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(exists).Should(BeTrue())
+
+			shutdown, err := cloud.InstanceShutdown(ctx, otherCloudNode)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(shutdown).Should(BeFalse())
+
+			metadata, err := cloud.InstanceMetadata(ctx, otherCloudNode)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(metadata.ProviderID).Should(Equal(otherCloudNode.Spec.ProviderID))
+
+			exists, err = cloud.InstanceExistsByProviderID(ctx, otherCloudNode.Spec.ProviderID)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(exists).Should(BeTrue())
+
+			shutdown, err = cloud.InstanceShutdownByProviderID(ctx, otherCloudNode.Spec.ProviderID)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(shutdown).Should(BeFalse())
+		})
+	})
+
+	Context("the node has the foreign node label", func() {
+		It("exists and keeps its addresses without calling API", func() {
+			ctx := context.Background()
+			cloud := newCloud(nifcloud.NewMockCloudAPIClient(ctrl), nifcloud.ForeignNodesConfig{Label: "example.com/on-prem"})
+
+			exists, err := cloud.InstanceExists(ctx, onPremNode)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(exists).Should(BeTrue())
+
+			metadata, err := cloud.InstanceMetadata(ctx, onPremNode)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(metadata.NodeAddresses).Should(Equal(onPremNode.Status.Addresses))
+		})
+	})
+
+	Context("load balancer backends", func() {
+		testNode := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "testinstance"}}
+
+		It("exclude foreign nodes by default", func() {
+			ctx := context.Background()
+			testInstances := []nifcloud.Instance{*helper.NewTestInstance()}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstancesByInstanceID(gomock.Any(), []string{"testinstance"}).
+				Return(testInstances, nil).
+				Times(1)
+
+			cloud := newCloud(c, nifcloud.ForeignNodesConfig{Label: "example.com/on-prem"})
+
+			instances, err := nifcloud.ExportLoadBalancerBackends(cloud, ctx, []*v1.Node{testNode, onPremNode, otherCloudNode})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(instances).Should(Equal(testInstances))
+		})
+
+		It("include foreign nodes whose instances are found", func() {
+			ctx := context.Background()
+			testInstance := *helper.NewTestInstance()
+			foreignInstance := nifcloud.Instance{InstanceID: "onprem-1", InstanceUniqueID: "i-efgh5678"}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstancesByInstanceID(gomock.Any(), []string{"testinstance"}).
+				Return([]nifcloud.Instance{testInstance}, nil).
+				Times(1)
+			c.EXPECT().
+				DescribeInstancesByInstanceID(gomock.Any(), []string{"onprem-1"}).
+				Return([]nifcloud.Instance{foreignInstance}, nil).
+				Times(1)
+			c.EXPECT().
+				DescribeInstancesByInstanceID(gomock.Any(), []string{"other-1"}).
+				Return(nil, cloudprovider.InstanceNotFound).
+				Times(1)
+
+			cloud := newCloud(c, nifcloud.ForeignNodesConfig{
+				Label:                "example.com/on-prem",
+				LoadBalancerBackends: nifcloud.ForeignNodesLoadBalancerBackendsInclude,
+			})
+
+			instances, err := nifcloud.ExportLoadBalancerBackends(cloud, ctx, []*v1.Node{testNode, onPremNode, otherCloudNode})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(instances).Should(Equal([]nifcloud.Instance{testInstance, foreignInstance}))
+		})
+
+		It("look up the nodes with provider id by instance unique id", func() {
+			ctx := context.Background()
+			testInstances := []nifcloud.Instance{*helper.NewTestInstance()}
+			initializedNode := &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+				Spec:       v1.NodeSpec{ProviderID: "nifcloud:///east-11/i-abcd1234"},
+			}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstancesByInstanceUniqueID(gomock.Any(), []string{"i-abcd1234"}).
+				Return(testInstances, nil).
+				Times(1)
+
+			cloud := newCloud(c, nifcloud.ForeignNodesConfig{})
+
+			instances, err := nifcloud.ExportLoadBalancerBackends(cloud, ctx, []*v1.Node{initializedNode})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(instances).Should(Equal(testInstances))
+		})
+	})
+})
@@ -0,0 +1,108 @@
+package nifcloud
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultInstanceCacheTTL = 30 * time.Second
+	// instanceCacheFetchTimeout is the timeout of the fetch shared by the callers
+	instanceCacheFetchTimeout = 2 * time.Minute
+)
+
+// instanceCache is the inventory of all instances in the region shared by the controllers.
+// The instances are fetched again when the cache is expired or invalidated,
+// and concurrent fetches are coalesced into one API call.
+type instanceCache struct {
+	ttl          time.Duration
+	fetchTimeout time.Duration
+	fetch        func(ctx context.Context) ([]Instance, error)
+	now          func() time.Time
+
+	mu         sync.RWMutex
+	instances  []Instance
+	expiresAt  time.Time
+	generation uint64
+
+	group singleflight.Group
+}
+
+func newInstanceCache(ttl time.Duration, fetch func(ctx context.Context) ([]Instance, error)) *instanceCache {
+	if ttl <= 0 {
+		ttl = defaultInstanceCacheTTL
+	}
+	return &instanceCache{
+		ttl:          ttl,
+		fetchTimeout: instanceCacheFetchTimeout,
+		fetch:        fetch,
+		now:          time.Now,
+	}
+}
+
+// list returns the cached instances. fetched is true if the instances are fetched in this call.
+func (c *instanceCache) list(ctx context.Context) (instances []Instance, fetched bool, err error) {
+	c.mu.RLock()
+	instances, expiresAt := c.instances, c.expiresAt
+	c.mu.RUnlock()
+
+	if instances != nil && c.now().Before(expiresAt) {
+		return instances, false, nil
+	}
+
+	instances, err = c.refresh(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	return instances, true, nil
+}
+
+// invalidate drops the cached instances so that the next call fetches them again
+func (c *instanceCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.instances = nil
+	c.generation++
+}
+
+func (c *instanceCache) refresh(ctx context.Context) ([]Instance, error) {
+	c.mu.RLock()
+	generation := c.generation
+	c.mu.RUnlock()
+
+	ch := c.group.DoChan("instances", func() (interface{}, error) {
+		// the fetch is shared by the concurrent callers, so it must not be canceled with the context of the first caller.
+		// each caller stops waiting on its own context below.
+		fetchCtx, cancel := context.WithTimeout(context.Background(), c.fetchTimeout)
+		defer cancel()
+
+		instances, err := c.fetch(fetchCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		// do not overwrite the cache invalidated while fetching
+		if c.generation == generation {
+			c.instances = instances
+			c.expiresAt = c.now().Add(c.ttl)
+		}
+
+		return instances, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]Instance), nil
+	}
+}
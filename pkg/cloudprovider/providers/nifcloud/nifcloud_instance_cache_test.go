@@ -0,0 +1,219 @@
+package nifcloud_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	cloudprovider "k8s.io/cloud-provider"
+)
+
+var _ = Describe("instanceCache", func() {
+	var (
+		fetchCount atomic.Int32
+		fetchErr   error
+		release    chan struct{}
+		cache      *nifcloud.ExportInstanceCache
+	)
+
+	fetch := func(ctx context.Context) ([]nifcloud.Instance, error) {
+		fetchCount.Add(1)
+		if release != nil {
+			<-release
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return []nifcloud.Instance{*helper.NewTestInstance()}, nil
+	}
+
+	BeforeEach(func() {
+		fetchCount.Store(0)
+		fetchErr = nil
+		release = nil
+		cache = nifcloud.ExportNewInstanceCache(time.Hour, fetch)
+	})
+
+	Context("the cache is not expired", func() {
+		It("fetch the instances only once", func() {
+			ctx := context.Background()
+			instances, fetched, err := nifcloud.ExportInstanceCacheList(cache, ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(fetched).Should(BeTrue())
+			Expect(instances).Should(HaveLen(1))
+
+			instances, fetched, err = nifcloud.ExportInstanceCacheList(cache, ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(fetched).Should(BeFalse())
+			Expect(instances).Should(HaveLen(1))
+
+			Expect(fetchCount.Load()).Should(BeEquivalentTo(1))
+		})
+	})
+
+	Context("the cache is expired", func() {
+		It("fetch the instances again", func() {
+			cache = nifcloud.ExportNewInstanceCache(10*time.Millisecond, fetch)
+			ctx := context.Background()
+			_, _, err := nifcloud.ExportInstanceCacheList(cache, ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			time.Sleep(20 * time.Millisecond)
+			_, fetched, err := nifcloud.ExportInstanceCacheList(cache, ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(fetched).Should(BeTrue())
+			Expect(fetchCount.Load()).Should(BeEquivalentTo(2))
+		})
+	})
+
+	Context("the cache is invalidated", func() {
+		It("fetch the instances again", func() {
+			ctx := context.Background()
+			_, _, err := nifcloud.ExportInstanceCacheList(cache, ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			nifcloud.ExportInstanceCacheInvalidate(cache)
+			_, fetched, err := nifcloud.ExportInstanceCacheList(cache, ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(fetched).Should(BeTrue())
+			Expect(fetchCount.Load()).Should(BeEquivalentTo(2))
+		})
+	})
+
+	Context("fetching is failed", func() {
+		It("return error and does not cache it", func() {
+			fetchErr = errors.New("error")
+			ctx := context.Background()
+			_, _, err := nifcloud.ExportInstanceCacheList(cache, ctx)
+			Expect(err).Should(HaveOccurred())
+
+			fetchErr = nil
+			_, fetched, err := nifcloud.ExportInstanceCacheList(cache, ctx)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(fetched).Should(BeTrue())
+		})
+	})
+
+	Context("the instances are requested concurrently", func() {
+		It("coalesce the requests into one fetch", func() {
+			release = make(chan struct{})
+			ctx := context.Background()
+
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					instances, _, err := nifcloud.ExportInstanceCacheList(cache, ctx)
+					Expect(err).ShouldNot(HaveOccurred())
+					Expect(instances).Should(HaveLen(1))
+				}()
+			}
+
+			Eventually(fetchCount.Load).Should(BeEquivalentTo(1))
+			// wait for the other goroutines to join the in-flight fetch
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			Expect(fetchCount.Load()).Should(BeEquivalentTo(1))
+		})
+	})
+
+	Context("the context is canceled while waiting", func() {
+		It("return error", func() {
+			release = make(chan struct{})
+			defer close(release)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			_, _, err := nifcloud.ExportInstanceCacheList(cache, ctx)
+			Expect(err).Should(MatchError(context.DeadlineExceeded))
+		})
+
+		It("does not fail the other callers sharing the fetch", func() {
+			release = make(chan struct{})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			canceled := make(chan error)
+			go func() {
+				_, _, err := nifcloud.ExportInstanceCacheList(cache, ctx)
+				canceled <- err
+			}()
+			Eventually(fetchCount.Load).Should(BeEquivalentTo(1))
+
+			done := make(chan error)
+			go func() {
+				_, _, err := nifcloud.ExportInstanceCacheList(cache, context.Background())
+				done <- err
+			}()
+			// wait for the second caller to join the in-flight fetch
+			time.Sleep(50 * time.Millisecond)
+
+			cancel()
+			Eventually(canceled).Should(Receive(MatchError(context.Canceled)))
+			close(release)
+			Eventually(done).Should(Receive(BeNil()))
+			Expect(fetchCount.Load()).Should(BeEquivalentTo(1))
+		})
+	})
+})
+
+var _ = Describe("DescribeInstancesByInstanceUniqueID with instance cache", func() {
+	var (
+		ts                    *httptest.Server
+		requests              atomic.Int32
+		testNifcloudAPIClient *nifcloud.ExportNifcloudAPIClient
+	)
+
+	BeforeEach(func() {
+		requests.Store(0)
+		ts = helper.NewTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			_, _ = w.Write(lo.Must(os.ReadFile("./testdata/describe_instances.xml")))
+		}))
+		testNifcloudAPIClient = nifcloud.NewNIFCLOUDAPIClientWithEndpoint("testkey", "testsecretkey", "jp-east-1", ts.URL)
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	Context("the instance is cached", func() {
+		It("does not call the API again", func() {
+			ctx := context.Background()
+			for i := 0; i < 3; i++ {
+				instances, err := testNifcloudAPIClient.DescribeInstancesByInstanceUniqueID(ctx, []string{"i-abcd1234"})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(instances).Should(Equal([]nifcloud.Instance{*helper.NewTestInstanceWithNetworkInterfaces()}))
+			}
+			Expect(requests.Load()).Should(BeEquivalentTo(1))
+		})
+	})
+
+	Context("the instance is not in the cache", func() {
+		It("refresh the cache once and return InstanceNotFound", func() {
+			ctx := context.Background()
+			_, err := testNifcloudAPIClient.DescribeInstancesByInstanceUniqueID(ctx, []string{"i-abcd1234"})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = testNifcloudAPIClient.DescribeInstancesByInstanceUniqueID(ctx, []string{"i-xxxx0000"})
+			Expect(err).Should(Equal(cloudprovider.InstanceNotFound))
+			Expect(requests.Load()).Should(BeEquivalentTo(2))
+		})
+	})
+})
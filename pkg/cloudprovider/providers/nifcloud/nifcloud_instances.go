@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"regexp"
 
+	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	cloudprovider "k8s.io/cloud-provider"
@@ -13,19 +14,56 @@ import (
 
 var nifcloudInstanceRegMatch = regexp.MustCompile("^i-[^/]*$")
 
+// instanceStatePhase is how the node lifecycle controller should treat an instance state
+type instanceStatePhase string
+
+const (
+	// instanceStatePhaseRunning means the instance is running
+	instanceStatePhaseRunning instanceStatePhase = "running"
+	// instanceStatePhaseShutdown means the instance is not running and does not start by itself
+	instanceStatePhaseShutdown instanceStatePhase = "shutdown"
+	// instanceStatePhaseTransitional means the instance is changing its state or the state is not known
+	instanceStatePhaseTransitional instanceStatePhase = "transitional"
+)
+
+// instanceStatePhases maps the NIFCLOUD instance states to the phases.
+// The states not listed here are treated as transitional.
+var instanceStatePhases = map[string]instanceStatePhase{
+	"running":      instanceStatePhaseRunning,
+	"stopped":      instanceStatePhaseShutdown,
+	"suspending":   instanceStatePhaseShutdown,
+	"import_error": instanceStatePhaseShutdown,
+	"pending":      instanceStatePhaseTransitional,
+	"stopping":     instanceStatePhaseTransitional,
+	"waiting":      instanceStatePhaseTransitional,
+	"creating":     instanceStatePhaseTransitional,
+	"importing":    instanceStatePhaseTransitional,
+	"uploading":    instanceStatePhaseTransitional,
+	"warning":      instanceStatePhaseTransitional,
+	"unknown":      instanceStatePhaseTransitional,
+}
+
+func instanceStatePhaseOf(state string) instanceStatePhase {
+	if phase, ok := instanceStatePhases[state]; ok {
+		return phase
+	}
+	return instanceStatePhaseTransitional
+}
+
+// isInstanceShutdown returns true if the instance is shut down.
+// The transitional states are not shut down since the instance may be running soon.
+func isInstanceShutdown(instance Instance) bool {
+	return instanceStatePhaseOf(instance.State) == instanceStatePhaseShutdown
+}
+
 // NodeAddresses returns the addresses of the specified instance.
 func (c *Cloud) NodeAddresses(ctx context.Context, name types.NodeName) ([]v1.NodeAddress, error) {
-	instanceID := string(name)
-	instances, err := c.client.DescribeInstancesByInstanceID(ctx, []string{instanceID})
+	instance, err := c.instanceByNodeName(ctx, name)
 	if err != nil {
-		return nil, fmt.Errorf("cloud not fetch instance info for %q: %w", instanceID, err)
-	}
-
-	if err := isSingleInstance(instances, instanceID); err != nil {
 		return nil, err
 	}
 
-	return getNodeAddress(instances[0]), nil
+	return getNodeAddress(*instance, c.config.Node.Addresses), nil
 }
 
 // NodeAddressesByProviderID returns the addresses of the specified instance.
@@ -44,41 +82,31 @@ func (c *Cloud) NodeAddressesByProviderID(ctx context.Context, providerID string
 		return nil, err
 	}
 
-	return getNodeAddress(instances[0]), nil
+	return getNodeAddress(instances[0], c.config.Node.Addresses), nil
 }
 
 // InstanceID returns the cloud provider ID of the node with the specified NodeName.
 func (c *Cloud) InstanceID(ctx context.Context, name types.NodeName) (string, error) {
-	instanceID := string(name)
-	instances, err := c.client.DescribeInstancesByInstanceID(ctx, []string{instanceID})
+	instance, err := c.instanceByNodeName(ctx, name)
 	if err != nil {
-		return "", fmt.Errorf("cloud not fetch instance info for %q: %w", instanceID, err)
-	}
-
-	if err := isSingleInstance(instances, instanceID); err != nil {
 		return "", err
 	}
 
 	return fmt.Sprintf(
 		"/%s/%s",
-		instances[0].Zone,
-		instances[0].InstanceUniqueID,
+		instance.Zone,
+		instance.InstanceUniqueID,
 	), nil
 }
 
 // InstanceType returns the type of the specified instance.
 func (c *Cloud) InstanceType(ctx context.Context, name types.NodeName) (string, error) {
-	instanceID := string(name)
-	instances, err := c.client.DescribeInstancesByInstanceID(ctx, []string{instanceID})
+	instance, err := c.instanceByNodeName(ctx, name)
 	if err != nil {
-		return "", fmt.Errorf("cloud not fetch instance info for %q: %w", instanceID, err)
-	}
-
-	if err := isSingleInstance(instances, instanceID); err != nil {
 		return "", err
 	}
 
-	return instances[0].InstanceType, nil
+	return instance.InstanceType, nil
 }
 
 // InstanceTypeByProviderID returns the type of the specified instance.
@@ -113,6 +141,10 @@ func (c *Cloud) CurrentNodeName(ctx context.Context, hostname string) (types.Nod
 
 // InstanceExistsByProviderID returns true if the instance for the given provider exists.
 func (c *Cloud) InstanceExistsByProviderID(ctx context.Context, providerID string) (bool, error) {
+	if isForeignProviderID(providerID) {
+		return true, nil
+	}
+
 	instanceUniqueID, err := getInstanceUniqueIDFromProviderID(providerID)
 	if err != nil {
 		return false, fmt.Errorf("unable to convert provider id %q: %w", providerID, err)
@@ -132,11 +164,33 @@ func (c *Cloud) InstanceExistsByProviderID(ctx context.Context, providerID strin
 
 // InstanceShutdownByProviderID returns true if the instance is shutdown in cloudprovider
 func (c *Cloud) InstanceShutdownByProviderID(ctx context.Context, providerID string) (bool, error) {
-	return false, cloudprovider.NotImplemented
+	if isForeignProviderID(providerID) {
+		return false, nil
+	}
+
+	instanceUniqueID, err := getInstanceUniqueIDFromProviderID(providerID)
+	if err != nil {
+		return false, fmt.Errorf("unable to convert provider id %q: %w", providerID, err)
+	}
+
+	instances, err := c.client.DescribeInstancesByInstanceUniqueID(ctx, []string{instanceUniqueID})
+	if err != nil {
+		return false, fmt.Errorf("cloud not fetch instance info for %q: %w", instanceUniqueID, err)
+	}
+
+	if err := isSingleInstance(instances, instanceUniqueID); err != nil {
+		return false, err
+	}
+
+	return isInstanceShutdown(instances[0]), nil
 }
 
 // InstanceExists returns true if the instance for the given node exists according to the cloud provider.
 func (c *Cloud) InstanceExists(ctx context.Context, node *v1.Node) (bool, error) {
+	if c.isForeignNode(node) {
+		return true, nil
+	}
+
 	_, err := c.getInstance(ctx, node)
 	if err != nil {
 		if errors.Is(err, cloudprovider.InstanceNotFound) {
@@ -150,20 +204,28 @@ func (c *Cloud) InstanceExists(ctx context.Context, node *v1.Node) (bool, error)
 
 // InstanceShutdown returns true if the instance is shutdown according to the cloud provider.
 func (c *Cloud) InstanceShutdown(ctx context.Context, node *v1.Node) (bool, error) {
+	if c.isForeignNode(node) {
+		return false, nil
+	}
+
 	instance, err := c.getInstance(ctx, node)
 	if err != nil {
 		return false, err
 	}
 
-	if instance.State == "stopped" {
-		return true, nil
-	}
-
-	return false, nil
+	return isInstanceShutdown(*instance), nil
 }
 
 // InstanceMetadata returns the instance's metadata.
 func (c *Cloud) InstanceMetadata(ctx context.Context, node *v1.Node) (*cloudprovider.InstanceMetadata, error) {
+	if c.isForeignNode(node) {
+		// keep the metadata given by the other cloud provider or kubelet
+		return &cloudprovider.InstanceMetadata{
+			ProviderID:    node.Spec.ProviderID,
+			NodeAddresses: node.Status.Addresses,
+		}, nil
+	}
+
 	instance, err := c.getInstance(ctx, node)
 	if err != nil {
 		return nil, err
@@ -172,32 +234,25 @@ func (c *Cloud) InstanceMetadata(ctx context.Context, node *v1.Node) (*cloudprov
 	return &cloudprovider.InstanceMetadata{
 		ProviderID:    fmt.Sprintf("nifcloud:///%s/%s", instance.Zone, instance.InstanceUniqueID),
 		InstanceType:  instance.InstanceType,
-		NodeAddresses: getNodeAddress(*instance),
+		NodeAddresses: getNodeAddress(*instance, c.config.Node.Addresses),
 		Zone:          instance.Zone,
 		Region:        c.region,
 	}, nil
 }
 
 func (c *Cloud) getInstance(ctx context.Context, node *v1.Node) (*Instance, error) {
-	var (
-		instances []Instance
-		err       error
-	)
-	if node.Spec.ProviderID != "" {
-		instanceUniqueID, err := getInstanceUniqueIDFromProviderID(node.Spec.ProviderID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get instance unique id from provider id: %w", err)
-		}
+	if node.Spec.ProviderID == "" {
+		return c.instanceByNode(ctx, node)
+	}
 
-		instances, err = c.client.DescribeInstancesByInstanceUniqueID(ctx, []string{instanceUniqueID})
-		if err != nil {
-			return nil, fmt.Errorf("could not fetch instance info by instance unique id %s: %w", instanceUniqueID, err)
-		}
-	} else {
-		instances, err = c.client.DescribeInstancesByInstanceID(ctx, []string{node.Name})
-		if err != nil {
-			return nil, fmt.Errorf("could not fetch instance info by node name %s: %w", node.Name, err)
-		}
+	instanceUniqueID, err := getInstanceUniqueIDFromProviderID(node.Spec.ProviderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance unique id from provider id: %w", err)
+	}
+
+	instances, err := c.client.DescribeInstancesByInstanceUniqueID(ctx, []string{instanceUniqueID})
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch instance info by instance unique id %s: %w", instanceUniqueID, err)
 	}
 
 	if err := isSingleInstance(instances, node.Name); err != nil {
@@ -207,20 +262,68 @@ func (c *Cloud) getInstance(ctx context.Context, node *v1.Node) (*Instance, erro
 	return &instances[0], nil
 }
 
-func getNodeAddress(instance Instance) []v1.NodeAddress {
+// getNodeAddress returns the addresses of all network interfaces of the instance.
+// The addresses of the configured networks come first so that kubelet prefers them.
+func getNodeAddress(instance Instance, config NodeAddressesConfig) []v1.NodeAddress {
+	internalIPNetworkID := lo.Ternary(config.InternalIP != "", config.InternalIP, commonPrivateNetworkID)
+	externalIPNetworkID := lo.Ternary(config.ExternalIP != "", config.ExternalIP, commonGlobalNetworkID)
+	networkInterfaces := instanceNetworkInterfaces(instance)
+
 	address := []v1.NodeAddress{}
+	add := func(addressType v1.NodeAddressType, networkID string, value func(InstanceNetworkInterface) string) {
+		for _, networkInterface := range networkInterfaces {
+			if networkInterface.NetworkID != networkID || value(networkInterface) == "" {
+				continue
+			}
+			nodeAddress := v1.NodeAddress{Type: addressType, Address: value(networkInterface)}
+			if !lo.Contains(address, nodeAddress) {
+				address = append(address, nodeAddress)
+			}
+		}
+	}
+	ipAddress := func(networkInterface InstanceNetworkInterface) string { return networkInterface.IPAddress }
+	dnsName := func(networkInterface InstanceNetworkInterface) string { return networkInterface.DNSName }
+
+	add(v1.NodeExternalIP, externalIPNetworkID, ipAddress)
+	add(v1.NodeInternalIP, internalIPNetworkID, ipAddress)
+	for _, networkID := range lo.Uniq(lo.Map(networkInterfaces, func(networkInterface InstanceNetworkInterface, _ int) string {
+		return networkInterface.NetworkID
+	})) {
+		if networkID == commonGlobalNetworkID {
+			add(v1.NodeExternalIP, networkID, ipAddress)
+		} else {
+			add(v1.NodeInternalIP, networkID, ipAddress)
+		}
+	}
+	if config.Hostname != "" {
+		add(v1.NodeHostName, config.Hostname, dnsName)
+	}
+	if config.InternalDNS != "" {
+		add(v1.NodeInternalDNS, config.InternalDNS, dnsName)
+	}
+
+	return address
+}
+
+// instanceNetworkInterfaces returns the network interfaces of the instance.
+// They are built from the public and private ip addresses if the instance has no network interface details
+func instanceNetworkInterfaces(instance Instance) []InstanceNetworkInterface {
+	if len(instance.NetworkInterfaces) > 0 {
+		return instance.NetworkInterfaces
+	}
+
+	networkInterfaces := []InstanceNetworkInterface{}
 	if instance.PublicIPAddress != "" {
-		address = append(address, v1.NodeAddress{
-			Type:    v1.NodeExternalIP,
-			Address: instance.PublicIPAddress,
+		networkInterfaces = append(networkInterfaces, InstanceNetworkInterface{
+			NetworkID: commonGlobalNetworkID,
+			IPAddress: instance.PublicIPAddress,
 		})
 	}
 	if instance.PrivateIPAddress != "" {
-		address = append(address, v1.NodeAddress{
-			Type:    v1.NodeInternalIP,
-			Address: instance.PrivateIPAddress,
+		networkInterfaces = append(networkInterfaces, InstanceNetworkInterface{
+			NetworkID: commonPrivateNetworkID,
+			IPAddress: instance.PrivateIPAddress,
 		})
 	}
-
-	return address
+	return networkInterfaces
 }
@@ -782,6 +782,107 @@ var _ = Describe("InstanceShutdown", func() {
 	})
 })
 
+var _ = Describe("InstanceShutdownByProviderID", func() {
+	var ctrl *gomock.Controller
+	var region string = "east1"
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	DescribeTable("single instance is existed",
+		func(state string, expected bool) {
+			ctx := context.Background()
+			testInstances := []nifcloud.Instance{*helper.NewTestInstance()}
+			testInstances[0].State = state
+			testProviderID := "nifcloud:///east-11/i-abcd1234"
+			testInstanceUniqueID := "i-abcd1234"
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstancesByInstanceUniqueID(gomock.Any(), []string{testInstanceUniqueID}).
+				Return(testInstances, nil).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetRegion(region)
+
+			stopped, err := cloud.InstanceShutdownByProviderID(ctx, testProviderID)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(stopped).Should(Equal(expected))
+		},
+		Entry("the instance is stopped", "stopped", true),
+		Entry("the instance is suspending", "suspending", true),
+		Entry("the instance is running", "running", false),
+		Entry("the instance is stopping", "stopping", false),
+		Entry("the instance is pending", "pending", false),
+		Entry("the instance is warning", "warning", false),
+	)
+
+	Context("the instance is not existed", func() {
+		It("return false and error", func() {
+			ctx := context.Background()
+			testProviderID := "nifcloud:///east-11/i-abcd1234"
+			testInstanceUniqueID := "i-abcd1234"
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstancesByInstanceUniqueID(gomock.Any(), []string{testInstanceUniqueID}).
+				Return(nil, cloudprovider.InstanceNotFound).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetRegion(region)
+
+			stopped, err := cloud.InstanceShutdownByProviderID(ctx, testProviderID)
+			Expect(err).Should(MatchError(cloudprovider.InstanceNotFound))
+			Expect(stopped).Should(BeFalse())
+		})
+	})
+
+	Context("provider id is invalid", func() {
+		It("return false and error", func() {
+			ctx := context.Background()
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetRegion(region)
+
+			stopped, err := cloud.InstanceShutdownByProviderID(ctx, "nifcloud:///east-11/invalid")
+			Expect(err).Should(HaveOccurred())
+			Expect(stopped).Should(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("instanceStatePhaseOf", func() {
+	DescribeTable("map the instance state to the phase",
+		func(state string, expected string) {
+			Expect(nifcloud.ExportInstanceStatePhaseOf(state)).Should(Equal(expected))
+		},
+		Entry(nil, "running", "running"),
+		Entry(nil, "stopped", "shutdown"),
+		Entry(nil, "suspending", "shutdown"),
+		Entry(nil, "import_error", "shutdown"),
+		Entry(nil, "pending", "transitional"),
+		Entry(nil, "stopping", "transitional"),
+		Entry(nil, "waiting", "transitional"),
+		Entry(nil, "creating", "transitional"),
+		Entry(nil, "warning", "transitional"),
+		Entry(nil, "unknown", "transitional"),
+		Entry(nil, "", "transitional"),
+		Entry(nil, "newstate", "transitional"),
+	)
+})
+
 var _ = Describe("InstanceMetadata", func() {
 	var ctrl *gomock.Controller
 	var region string = "east1"
@@ -1009,7 +1110,7 @@ var _ = Describe("getNodeAddress", func() {
 				},
 			}
 
-			nodeAddress := nifcloud.ExportGetNodeAddress(testInstance)
+			nodeAddress := nifcloud.ExportGetNodeAddress(testInstance, nifcloud.NodeAddressesConfig{})
 			Expect(nodeAddress).Should(Equal(expectedNodeAddress))
 		})
 	})
@@ -1025,7 +1126,7 @@ var _ = Describe("getNodeAddress", func() {
 				},
 			}
 
-			nodeAddress := nifcloud.ExportGetNodeAddress(testInstance)
+			nodeAddress := nifcloud.ExportGetNodeAddress(testInstance, nifcloud.NodeAddressesConfig{})
 			Expect(nodeAddress).Should(Equal(expectedNodeAddress))
 		})
 	})
@@ -1044,7 +1145,59 @@ var _ = Describe("getNodeAddress", func() {
 				},
 			}
 
-			nodeAddress := nifcloud.ExportGetNodeAddress(testInstance)
+			nodeAddress := nifcloud.ExportGetNodeAddress(testInstance, nifcloud.NodeAddressesConfig{})
+			Expect(nodeAddress).Should(Equal(expectedNodeAddress))
+		})
+	})
+
+	Context("given an instance has private LAN", func() {
+		testInstance := *helper.NewTestInstanceWithNetworkInterfaces()
+		testInstance.NetworkInterfaces = append(testInstance.NetworkInterfaces, nifcloud.InstanceNetworkInterface{
+			NetworkID: "net-abcd1234",
+			IPAddress: "10.0.0.10",
+			DNSName:   "10.0.0.10",
+		})
+
+		It("return the addresses of all network interfaces", func() {
+			expectedNodeAddress := []v1.NodeAddress{
+				{Type: v1.NodeExternalIP, Address: "203.0.113.1"},
+				{Type: v1.NodeInternalIP, Address: "192.168.0.100"},
+				{Type: v1.NodeInternalIP, Address: "10.0.0.10"},
+			}
+
+			nodeAddress := nifcloud.ExportGetNodeAddress(testInstance, nifcloud.NodeAddressesConfig{})
+			Expect(nodeAddress).Should(Equal(expectedNodeAddress))
+		})
+
+		It("return the address of the configured network first", func() {
+			expectedNodeAddress := []v1.NodeAddress{
+				{Type: v1.NodeExternalIP, Address: "203.0.113.1"},
+				{Type: v1.NodeInternalIP, Address: "10.0.0.10"},
+				{Type: v1.NodeInternalIP, Address: "192.168.0.100"},
+				{Type: v1.NodeHostName, Address: "10.0.0.10"},
+				{Type: v1.NodeInternalDNS, Address: "10.0.0.10"},
+			}
+
+			nodeAddress := nifcloud.ExportGetNodeAddress(testInstance, nifcloud.NodeAddressesConfig{
+				InternalIP:  "net-abcd1234",
+				Hostname:    "net-abcd1234",
+				InternalDNS: "net-abcd1234",
+			})
+			Expect(nodeAddress).Should(Equal(expectedNodeAddress))
+		})
+
+		It("report the private network as external ip if configured", func() {
+			expectedNodeAddress := []v1.NodeAddress{
+				{Type: v1.NodeExternalIP, Address: "192.168.0.100"},
+				{Type: v1.NodeInternalIP, Address: "10.0.0.10"},
+				{Type: v1.NodeExternalIP, Address: "203.0.113.1"},
+				{Type: v1.NodeInternalIP, Address: "192.168.0.100"},
+			}
+
+			nodeAddress := nifcloud.ExportGetNodeAddress(testInstance, nifcloud.NodeAddressesConfig{
+				InternalIP: "net-abcd1234",
+				ExternalIP: "net-COMMON_PRIVATE",
+			})
 			Expect(nodeAddress).Should(Equal(expectedNodeAddress))
 		})
 	})
@@ -0,0 +1,419 @@
+package nifcloud_test
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/fake"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+var _ = Describe("integration with the fake NIFCLOUD API", func() {
+	var (
+		server           *fake.Server
+		client           *nifcloud.ExportNifcloudAPIClient
+		cloud            *nifcloud.Cloud
+		service          *corev1.Service
+		nodes            []*corev1.Node
+		loadBalancerName string
+	)
+	ctx := context.Background()
+	clusterName := "testcluster"
+
+	instanceIDsOf := func(instances []nifcloud.Instance) []string {
+		return lo.Map(instances, func(instance nifcloud.Instance, _ int) string { return instance.InstanceID })
+	}
+
+	BeforeEach(func() {
+		server = fake.NewServer()
+		server.AddInstance(nifcloud.Instance{
+			InstanceID:       "testinstance",
+			InstanceUniqueID: "i-abcd1234",
+			InstanceType:     "e-medium",
+			PublicIPAddress:  "203.0.113.1",
+			PrivateIPAddress: "192.168.0.1",
+			Zone:             "east-11",
+		})
+		server.AddInstance(nifcloud.Instance{
+			InstanceID:       "testinstance2",
+			InstanceUniqueID: "i-efgh5678",
+			InstanceType:     "e-medium",
+			PublicIPAddress:  "203.0.113.2",
+			PrivateIPAddress: "192.168.0.2",
+			Zone:             "east-11",
+		})
+
+		client = nifcloud.NewNIFCLOUDAPIClientWithCloudConfig("testkey", "testsecretkey", &nifcloud.CloudConfig{
+			Global: nifcloud.GlobalConfig{Region: "jp-east-1", Endpoint: server.URL},
+			RateLimit: nifcloud.RateLimitConfig{
+				Read:     nifcloud.TokenBucketConfig{QPS: 1000, Burst: 1000},
+				Mutating: nifcloud.TokenBucketConfig{QPS: 1000, Burst: 1000},
+			},
+		})
+		cloud = &nifcloud.Cloud{}
+		cloud.SetClient(client)
+		cloud.SetRegion("jp-east-1")
+		cloud.SetKubeClient(kubefake.NewSimpleClientset())
+
+		uid := types.UID(uuid.NewString())
+		loadBalancerName = nifcloud.ExportLoadBalancerName(clusterName, uid)
+		service = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "testsvc",
+				UID:         uid,
+				Annotations: map[string]string{},
+			},
+			Spec: corev1.ServiceSpec{
+				Type: corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{
+					{Port: 80, NodePort: 30000, Protocol: corev1.ProtocolTCP},
+					{Port: 443, NodePort: 30001, Protocol: corev1.ProtocolTCP},
+				},
+			},
+		}
+		nodes = []*corev1.Node{
+			{ObjectMeta: metav1.ObjectMeta{Name: "testinstance"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "testinstance2"}},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("l4 load balancer", func() {
+		It("creates, updates and deletes the load balancer", func() {
+			status, err := cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			loadBalancers := server.LoadBalancers()
+			Expect(loadBalancers).Should(HaveLen(2))
+			Expect(status.Ingress).Should(HaveLen(1))
+			Expect(status.Ingress[0].IP).Should(Equal(loadBalancers[0].VIP))
+			for _, lb := range loadBalancers {
+				Expect(lb.Name).Should(Equal(loadBalancerName))
+				Expect(instanceIDsOf(lb.BalancingTargets)).Should(ConsistOf("testinstance", "testinstance2"))
+			}
+			Expect(loadBalancers).Should(ContainElements(
+				HaveField("HealthCheckTarget", "TCP:30000"),
+				HaveField("HealthCheckTarget", "TCP:30001"),
+			))
+
+			gotStatus, exists, err := cloud.GetLoadBalancer(ctx, clusterName, service)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(exists).Should(BeTrue())
+			Expect(gotStatus).Should(Equal(status))
+
+			By("ensuring the same service again")
+			_, err = cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(server.RequestCount("CreateLoadBalancer")).Should(Equal(1))
+			Expect(server.RequestCount("RegisterPortWithLoadBalancer")).Should(Equal(1))
+			Expect(server.RequestCount("DeregisterInstancesFromLoadBalancer")).Should(Equal(0))
+
+			By("removing a port and a node, and restricting the source ranges")
+			service.Spec.Ports = service.Spec.Ports[:1]
+			service.Spec.LoadBalancerSourceRanges = []string{"198.51.100.10/32", "203.0.113.0/24"}
+			Expect(cloud.UpdateLoadBalancer(ctx, clusterName, service, nodes[:1])).Should(Succeed())
+
+			loadBalancers = server.LoadBalancers()
+			Expect(loadBalancers).Should(HaveLen(1))
+			Expect(loadBalancers[0].LoadBalancerPort).Should(Equal(int32(80)))
+			Expect(instanceIDsOf(loadBalancers[0].BalancingTargets)).Should(ConsistOf("testinstance"))
+			Expect(loadBalancers[0].Filters).Should(ConsistOf("198.51.100.10", "203.0.113.0/24"))
+
+			By("allowing all source ranges again")
+			service.Spec.LoadBalancerSourceRanges = nil
+			Expect(cloud.UpdateLoadBalancer(ctx, clusterName, service, nodes[:1])).Should(Succeed())
+			Expect(server.LoadBalancers()[0].Filters).Should(BeEmpty())
+
+			By("deleting the service")
+			Expect(cloud.EnsureLoadBalancerDeleted(ctx, clusterName, service)).Should(Succeed())
+			Expect(server.LoadBalancers()).Should(BeEmpty())
+
+			_, exists, err = cloud.GetLoadBalancer(ctx, clusterName, service)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(exists).Should(BeFalse())
+			Expect(cloud.EnsureLoadBalancerDeleted(ctx, clusterName, service)).Should(Succeed())
+		})
+
+		It("switches the filter between allowing and denying the source ranges", func() {
+			service.Annotations[corev1.AnnotationLoadBalancerSourceRangesKey] = "198.51.100.0/24,192.0.2.10/32"
+			_, err := cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			for _, lb := range server.LoadBalancers() {
+				Expect(lb.FilterType).Should(Equal("1"))
+				Expect(lb.Filters).Should(ConsistOf("198.51.100.0/24", "192.0.2.10"))
+			}
+
+			By("denying the source ranges")
+			service.Annotations[nifcloud.ServiceAnnotationLoadBalancerFilterType] = "2"
+			_, err = cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			for _, lb := range server.LoadBalancers() {
+				Expect(lb.FilterType).Should(Equal("2"))
+				Expect(lb.Filters).Should(ConsistOf("198.51.100.0/24", "192.0.2.10"))
+			}
+
+			By("ensuring the same service again")
+			setFilterCount := server.RequestCount("SetFilterForLoadBalancer")
+			_, err = cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(server.RequestCount("SetFilterForLoadBalancer")).Should(Equal(setFilterCount))
+		})
+
+		It("updates the network volume and the accounting type without changing the VIP", func() {
+			status, err := cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			service.Annotations[nifcloud.ServiceAnnotationLoadBalancerNetworkVolume] = "100"
+			service.Annotations[nifcloud.ServiceAnnotationLoadBalancerAccountingType] = "1"
+			updatedStatus, err := cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(updatedStatus).Should(Equal(status))
+			for _, lb := range server.LoadBalancers() {
+				Expect(lb.NetworkVolume).Should(Equal(int32(100)))
+				Expect(lb.AccountingType).Should(Equal("1"))
+			}
+			Expect(server.RequestCount("UpdateLoadBalancer")).Should(Equal(1))
+			Expect(server.RequestCount("CreateLoadBalancer")).Should(Equal(1))
+
+			By("ensuring the same service again")
+			_, err = cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(server.RequestCount("UpdateLoadBalancer")).Should(Equal(1))
+		})
+
+		It("returns the error of the API", func() {
+			server.InjectError("CreateLoadBalancer", &fake.APIError{Code: "Client.InvalidParameterDuplicate.LoadBalancer"})
+
+			_, err := cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).Should(HaveOccurred())
+			Expect(nifcloud.IsDuplicate(err)).Should(BeTrue())
+			Expect(server.LoadBalancers()).Should(BeEmpty())
+		})
+	})
+
+	Context("elastic load balancer", func() {
+		BeforeEach(func() {
+			service.Annotations[nifcloud.ServiceAnnotationLoadBalancerType] = "elb"
+			server.AddSecurityGroup("testgroup", "testinstance")
+			server.AddSecurityGroup("testgroup2", "testinstance2")
+		})
+
+		It("creates, updates and deletes the load balancer with the security group rules", func() {
+			status, err := cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			elasticLoadBalancers := server.ElasticLoadBalancers()
+			Expect(elasticLoadBalancers).Should(HaveLen(2))
+			vip := elasticLoadBalancers[0].VIP
+			Expect(vip).ShouldNot(BeEmpty())
+			Expect(status.Ingress[0].IP).Should(Equal(vip))
+			for _, elb := range elasticLoadBalancers {
+				Expect(elb.Name).Should(Equal(loadBalancerName))
+				Expect(elb.AvailabilityZone).Should(Equal("east-11"))
+				Expect(instanceIDsOf(elb.BalancingTargets)).Should(ConsistOf("testinstance", "testinstance2"))
+			}
+
+			vipRule := func(port int32) nifcloud.SecurityGroupRule {
+				return nifcloud.SecurityGroupRule{IpProtocol: "TCP", FromPort: port, ToPort: port, InOut: "IN", IpRanges: []string{vip}}
+			}
+			for _, groupName := range []string{"testgroup", "testgroup2"} {
+				Expect(server.SecurityGroupRules(groupName)).Should(ContainElements(vipRule(30000), vipRule(30001)))
+			}
+
+			By("removing a node")
+			Expect(cloud.UpdateLoadBalancer(ctx, clusterName, service, nodes[:1])).Should(Succeed())
+			for _, elb := range server.ElasticLoadBalancers() {
+				Expect(instanceIDsOf(elb.BalancingTargets)).Should(ConsistOf("testinstance"))
+			}
+			Expect(server.SecurityGroupRules("testgroup")).Should(ContainElements(vipRule(30000), vipRule(30001)))
+			Expect(server.SecurityGroupRules("testgroup2")).Should(BeEmpty())
+
+			By("deleting the service")
+			Expect(cloud.EnsureLoadBalancerDeleted(ctx, clusterName, service)).Should(Succeed())
+			Expect(server.ElasticLoadBalancers()).Should(BeEmpty())
+			Expect(server.SecurityGroupRules("testgroup")).Should(BeEmpty())
+
+			_, exists, err := cloud.GetLoadBalancer(ctx, clusterName, service)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(exists).Should(BeFalse())
+		})
+
+		It("refuses to create the load balancer restricting the source ranges", func() {
+			service.Spec.LoadBalancerSourceRanges = []string{"203.0.113.0/24"}
+
+			_, err := cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).Should(HaveOccurred())
+			Expect(server.ElasticLoadBalancers()).Should(BeEmpty())
+			Expect(server.SecurityGroupRules("testgroup")).Should(BeEmpty())
+		})
+
+		It("keeps reconciling the existing load balancer restricting the source ranges", func() {
+			_, err := cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			recorder := record.NewFakeRecorder(10)
+			cloud.SetEventRecorder(recorder)
+
+			By("restricting the source ranges of the existing load balancer")
+			service.Spec.LoadBalancerSourceRanges = []string{"203.0.113.0/24"}
+			Expect(cloud.UpdateLoadBalancer(ctx, clusterName, service, nodes[:1])).Should(Succeed())
+			for _, elb := range server.ElasticLoadBalancers() {
+				Expect(instanceIDsOf(elb.BalancingTargets)).Should(ConsistOf("testinstance"))
+			}
+			Expect(recorder.Events).Should(Receive(ContainSubstring("SourceRangesNotEnforced")))
+
+			By("deleting the service")
+			Expect(cloud.EnsureLoadBalancerDeleted(ctx, clusterName, service)).Should(Succeed())
+			Expect(server.ElasticLoadBalancers()).Should(BeEmpty())
+		})
+
+		It("updates the network volume and the accounting type without changing the VIP", func() {
+			status, err := cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			service.Annotations[nifcloud.ServiceAnnotationLoadBalancerNetworkVolume] = "100"
+			service.Annotations[nifcloud.ServiceAnnotationLoadBalancerAccountingType] = "1"
+			updatedStatus, err := cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(updatedStatus).Should(Equal(status))
+			for _, elb := range server.ElasticLoadBalancers() {
+				Expect(elb.NetworkVolume).Should(Equal(int32(100)))
+				Expect(elb.AccountingType).Should(Equal("1"))
+			}
+			Expect(server.RequestCount("NiftyUpdateElasticLoadBalancer")).Should(Equal(1))
+			Expect(server.RequestCount("NiftyCreateElasticLoadBalancer")).Should(Equal(1))
+		})
+	})
+
+	Context("load balancer type migration", func() {
+		BeforeEach(func() {
+			service.Namespace = "default"
+			server.AddSecurityGroup("testgroup", "testinstance")
+			server.AddSecurityGroup("testgroup2", "testinstance2")
+		})
+
+		getService := func() *corev1.Service {
+			svc, err := cloud.KubeClient().CoreV1().Services(service.Namespace).Get(ctx, service.Name, metav1.GetOptions{})
+			Expect(err).ShouldNot(HaveOccurred())
+			return svc
+		}
+
+		It("does not migrate the service without annotations whose ingress is another VIP", func() {
+			service.Annotations = nil
+			service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "192.0.2.250"}}
+			_, err := cloud.KubeClient().CoreV1().Services(service.Namespace).Create(ctx, service, metav1.CreateOptions{})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			status, err := cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(status.Ingress[0].IP).Should(Equal(server.LoadBalancers()[0].VIP))
+			Expect(server.ElasticLoadBalancers()).Should(BeEmpty())
+			Expect(getService().Annotations).ShouldNot(HaveKey(nifcloud.ServiceAnnotationLoadBalancerPreviousType))
+		})
+
+		It("deletes the l4 load balancer once the elastic load balancer is ready", func() {
+			status, err := cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			service.Status.LoadBalancer = *status
+			_, err = cloud.KubeClient().CoreV1().Services(service.Namespace).Create(ctx, service, metav1.CreateOptions{})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			By("changing the type to elb")
+			service = getService()
+			service.Annotations[nifcloud.ServiceAnnotationLoadBalancerType] = "elb"
+			status, err = cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(server.ElasticLoadBalancers()).Should(HaveLen(2))
+			Expect(status.Ingress[0].IP).Should(Equal(server.ElasticLoadBalancers()[0].VIP))
+			Expect(server.LoadBalancers()).Should(BeEmpty())
+			Expect(getService().Annotations).ShouldNot(HaveKey(nifcloud.ServiceAnnotationLoadBalancerPreviousType))
+		})
+
+		It("retries deleting the previous load balancer if it fails", func() {
+			status, err := cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			service.Status.LoadBalancer = *status
+			_, err = cloud.KubeClient().CoreV1().Services(service.Namespace).Create(ctx, service, metav1.CreateOptions{})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			By("failing to delete the l4 load balancer")
+			server.InjectError("DeleteLoadBalancer", &fake.APIError{Code: "Client.InvalidParameterIncorrect.LoadBalancer"})
+			service = getService()
+			service.Annotations[nifcloud.ServiceAnnotationLoadBalancerType] = "elb"
+			_, err = cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).Should(HaveOccurred())
+			Expect(server.ElasticLoadBalancers()).Should(HaveLen(2))
+			Expect(getService().Annotations).Should(HaveKeyWithValue(nifcloud.ServiceAnnotationLoadBalancerPreviousType, "lb"))
+
+			By("syncing again")
+			service = getService()
+			service.Annotations[nifcloud.ServiceAnnotationLoadBalancerType] = "elb"
+			_, err = cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(server.LoadBalancers()).Should(BeEmpty())
+			Expect(server.ElasticLoadBalancers()).Should(HaveLen(2))
+			Expect(getService().Annotations).ShouldNot(HaveKey(nifcloud.ServiceAnnotationLoadBalancerPreviousType))
+		})
+
+		It("deletes the load balancers of both types if the service is deleted during the migration", func() {
+			service.Annotations[nifcloud.ServiceAnnotationLoadBalancerType] = "elb"
+			_, err := cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			By("creating the l4 load balancer while the elastic one remains")
+			service.Annotations[nifcloud.ServiceAnnotationLoadBalancerType] = "lb"
+			_, err = cloud.EnsureLoadBalancer(ctx, clusterName, service, nodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(server.LoadBalancers()).Should(HaveLen(2))
+			Expect(server.ElasticLoadBalancers()).Should(HaveLen(2))
+
+			By("deleting the service")
+			service.Annotations[nifcloud.ServiceAnnotationLoadBalancerPreviousType] = "elb"
+			Expect(cloud.EnsureLoadBalancerDeleted(ctx, clusterName, service)).Should(Succeed())
+			Expect(server.LoadBalancers()).Should(BeEmpty())
+			Expect(server.ElasticLoadBalancers()).Should(BeEmpty())
+			Expect(server.SecurityGroupRules("testgroup")).Should(BeEmpty())
+		})
+	})
+
+	Context("security group", func() {
+		It("authorizes and revokes the rule with multiple ip ranges", func() {
+			server.AddSecurityGroup("testgroup", "testinstance")
+			rule := &nifcloud.SecurityGroupRule{
+				IpProtocol: "TCP",
+				FromPort:   30000,
+				ToPort:     30000,
+				InOut:      "IN",
+				IpRanges:   []string{"203.0.113.10", "203.0.113.11"},
+			}
+
+			Expect(client.AuthorizeSecurityGroupIngress(ctx, "testgroup", rule)).Should(Succeed())
+			Expect(client.WaitSecurityGroupApplied(ctx, "testgroup")).Should(Succeed())
+			Expect(server.SecurityGroupRules("testgroup")).Should(ConsistOf(
+				HaveField("IpRanges", []string{"203.0.113.10"}),
+				HaveField("IpRanges", []string{"203.0.113.11"}),
+			))
+
+			securityGroups, err := client.DescribeSecurityGroupsByInstanceIDs(ctx, []string{"testinstance"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(securityGroups).Should(HaveLen(1))
+			Expect(securityGroups[0].Rules).Should(HaveLen(2))
+
+			Expect(client.RevokeSecurityGroupIngress(ctx, "testgroup", rule)).Should(Succeed())
+			Expect(server.SecurityGroupRules("testgroup")).Should(BeEmpty())
+
+			err = client.RevokeSecurityGroupIngress(ctx, "testgroup", rule)
+			Expect(nifcloud.IsNotFound(err)).Should(BeTrue())
+		})
+	})
+})
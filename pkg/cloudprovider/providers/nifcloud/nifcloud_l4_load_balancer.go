@@ -2,16 +2,22 @@ package nifcloud
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	servicehelpers "k8s.io/cloud-provider/service/helpers"
 	"k8s.io/klog/v2"
 )
 
+// minFilterPrefixLength is the shortest prefix length of the CIDR in the filter of l4 load balancer
+const minFilterPrefixLength = 16
+
 func isL4LoadBalancer(annotations map[string]string) bool {
 	if t := annotations[ServiceAnnotationLoadBalancerType]; t == "lb" || t == "" {
 		return true
@@ -19,12 +25,31 @@ func isL4LoadBalancer(annotations map[string]string) bool {
 	return false
 }
 
-func (c *Cloud) getL4LoadBalancer(ctx context.Context, clusterName string, service *v1.Service) (status *v1.LoadBalancerStatus, exists bool, err error) {
+// describeL4LoadBalancersOfService returns the load balancers of the service and their name.
+// The load balancers under the legacy name are returned if not found under the name
+func (c *Cloud) describeL4LoadBalancersOfService(ctx context.Context, clusterName string, service *v1.Service) (string, []LoadBalancer, error) {
 	loadBalancerName := c.GetLoadBalancerName(ctx, clusterName, service)
 	loadBalancers, err := c.client.DescribeLoadBalancers(ctx, loadBalancerName)
+	legacyName := legacyLoadBalancerName(service)
+	if err == nil || !IsNotFound(err) || legacyName == "" {
+		return loadBalancerName, loadBalancers, err
+	}
+
+	legacyLoadBalancers, legacyErr := c.client.DescribeLoadBalancers(ctx, legacyName)
+	if legacyErr != nil {
+		if IsNotFound(legacyErr) {
+			return loadBalancerName, nil, err
+		}
+		return legacyName, nil, legacyErr
+	}
+	return legacyName, legacyLoadBalancers, nil
+}
+
+func (c *Cloud) getL4LoadBalancer(ctx context.Context, clusterName string, service *v1.Service) (status *v1.LoadBalancerStatus, exists bool, err error) {
+	loadBalancerName, loadBalancers, err := c.describeL4LoadBalancersOfService(ctx, clusterName, service)
 	if err != nil {
 		switch {
-		case IsAPIError(err, errorCodeLoadBalancerNotFound):
+		case IsNotFound(err):
 			return nil, false, nil
 		}
 		return nil, false, err
@@ -33,25 +58,38 @@ func (c *Cloud) getL4LoadBalancer(ctx context.Context, clusterName string, servi
 	if len(loadBalancers) == 0 {
 		return nil, false, fmt.Errorf("not found load balancer: %q", loadBalancerName)
 	}
+	if err := c.verifyL4LoadBalancerOwner(ctx, clusterName, service, loadBalancerName, loadBalancers); err != nil {
+		return nil, false, err
+	}
 
 	// service can have many ports, but the load balancer vip is the same
 	return toLoadBalancerStatus(loadBalancers[0].VIP), true, nil
 }
 
-func (c *Cloud) ensureL4LoadBalancer(ctx context.Context, loadBalancerName string, desire []LoadBalancer) (*v1.LoadBalancerStatus, error) {
+// ensureL4LoadBalancer creates or updates the load balancers to the desire.
+// The description of the desire is the ownership marker which the existing load balancers must have unless the service adopts them
+func (c *Cloud) ensureL4LoadBalancer(ctx context.Context, service *v1.Service, loadBalancerName string, desire []LoadBalancer) (*v1.LoadBalancerStatus, error) {
 	if len(desire) == 0 {
 		return nil, fmt.Errorf("desire LoadBalancer length must be larger than 1")
 	}
 
 	current, err := c.client.DescribeLoadBalancers(ctx, loadBalancerName)
 	if err != nil {
-		if IsAPIError(err, errorCodeLoadBalancerNotFound) {
+		if IsNotFound(err) {
+			// record the owner before creating, so that the created load balancers are never left unrecorded
+			if err := c.recordLoadBalancerOwner(ctx, "lb", loadBalancerName, desire[0].Description); err != nil {
+				return nil, err
+			}
+
 			// create all load balancers
 			var vip string
 			for i, lb := range desire {
 				klog.Infof("Creating LoadBalancer %q (%d -> %d)", lb.Name, lb.LoadBalancerPort, lb.InstancePort)
 				if i == 0 {
 					vip, err = c.client.CreateLoadBalancer(ctx, &lb)
+					if errors.Is(err, errDryRunCreated) {
+						return dryRunLoadBalancerStatus(service, loadBalancerName)
+					}
 					if err != nil {
 						return nil, fmt.Errorf("failed to create load balancer: %w", err)
 					}
@@ -68,6 +106,11 @@ func (c *Cloud) ensureL4LoadBalancer(ctx context.Context, loadBalancerName strin
 		return nil, fmt.Errorf("failed to describe load balancer %q: %w", loadBalancerName, err)
 	}
 
+	descriptions := lo.Map(current, func(lb LoadBalancer, _ int) string { return lb.Description })
+	if err := verifyLoadBalancerOwner(loadBalancerName, desire[0].Description, descriptions, isLoadBalancerAdopted(service)); err != nil {
+		return nil, err
+	}
+
 	klog.Infof("desire: %v, current: %v", desire, current)
 
 	loadBalancerResourceChanged := false
@@ -98,12 +141,42 @@ func (c *Cloud) ensureL4LoadBalancer(ctx context.Context, loadBalancerName strin
 
 	klog.Infof("desire: %v, current: %v", desire, current)
 
+	// reconcile the network volume and the accounting type shared by all ports
+	if toUpdate, ok := l4LoadBalancerSpecUpdate(desire[0], current[0]); ok {
+		klog.Infof(
+			"Updating load balancer %q: network volume %d -> %d, accounting type %q -> %q",
+			loadBalancerName, current[0].NetworkVolume, desire[0].NetworkVolume, current[0].AccountingType, desire[0].AccountingType,
+		)
+		if err := c.client.UpdateLoadBalancer(ctx, &toUpdate); err != nil {
+			return nil, fmt.Errorf("failed to update load balancer: %w", err)
+		}
+	}
+	// the policy type can not be updated in place, because only CreateLoadBalancer of the API accepts it
+	if desire[0].PolicyType != "" && desire[0].PolicyType != current[0].PolicyType {
+		klog.Warningf(
+			"Ignoring the policy type %q of load balancer %q: the policy type %q can not be changed by API, recreate the service to apply it",
+			desire[0].PolicyType, loadBalancerName, current[0].PolicyType,
+		)
+	}
+
 	for _, currentLB := range current {
 		desireLB, err := findL4LoadBalancer(desire, currentLB)
 		if err != nil {
 			return nil, err
 		}
 
+		// reconcile health check
+		if !l4LoadBalancerHealthCheckEquals(*desireLB, currentLB) {
+			klog.Infof(
+				"Configuring health check of load balancer %q (%d -> %d): %s (interval: %d, unhealthy threshold: %d)",
+				currentLB.Name, currentLB.LoadBalancerPort, currentLB.InstancePort,
+				desireLB.HealthCheckTarget, desireLB.HealthCheckInterval, desireLB.HealthCheckUnhealthyThreshold,
+			)
+			if err := c.client.ConfigureHealthCheck(ctx, desireLB); err != nil {
+				return nil, fmt.Errorf("failed to configure health check: %w", err)
+			}
+		}
+
 		// reconcile balancing targets
 		toRegister := l4LoadBalancingTargetsDifferences(desireLB.BalancingTargets, currentLB.BalancingTargets)
 		if len(toRegister) > 0 {
@@ -143,8 +216,10 @@ func (c *Cloud) ensureL4LoadBalancer(ctx context.Context, loadBalancerName strin
 			}
 			toSet = append(toSet, Filter{AddOnFilter: false, IPAddress: addr})
 		}
-		if len(toSet) > 0 {
-			klog.Infof("Applying filter: %v", toSet)
+		filterTypeChanged := desireLB.FilterType != "" && desireLB.FilterType != currentLB.FilterType
+		if len(toSet) > 0 || filterTypeChanged {
+			klog.Infof("Applying filter (type: %s): %v", desireLB.FilterType, toSet)
+			currentLB.FilterType = desireLB.FilterType
 			if err := c.client.SetFilterForLoadBalancer(ctx, &currentLB, toSet); err != nil {
 				return nil, fmt.Errorf("failed to set filter for load balancer: %w", err)
 			}
@@ -249,56 +324,118 @@ func NewL4LoadBalancerFromService(loadBalancerName string, instances []Instance,
 		desire[i].BalancingTargets = instances
 
 		// filter
-		sourceRanges, err := servicehelpers.GetLoadBalancerSourceRanges(service)
+		filterType, filters, err := l4LoadBalancerFilterOf(service)
 		if err != nil {
 			return nil, err
 		}
-		filters := []string{}
-		if !servicehelpers.IsAllowAll(sourceRanges) {
-			for cidr := range sourceRanges {
-				if strings.HasSuffix(cidr, "/32") {
-					filters = append(filters, strings.TrimSuffix(cidr, "/32"))
-				} else {
-					filters = append(filters, strings.Replace(cidr, "/32", "", 1))
-				}
-			}
-		}
-		desire[i].Filters = sort.StringSlice(filters)
+		desire[i].FilterType = filterType
+		desire[i].Filters = filters
 	}
 
 	return desire, nil
 }
 
-func (c *Cloud) updateL4LoadBalancer(ctx context.Context, clusterName string, service *v1.Service) error {
-	loadBalancerName := c.GetLoadBalancerName(ctx, clusterName, service)
+// l4LoadBalancerFilterOf returns the filter type and the IP addresses of the filter of the service.
+// The source ranges are allowed by default. With filter type 2, the source ranges in the annotation are denied
+// and the others are allowed; spec.loadBalancerSourceRanges is refused because it always means the allowed ranges
+func l4LoadBalancerFilterOf(service *v1.Service) (string, []string, error) {
+	filterType := loadBalancerFilterTypeAllow
+	if t, ok := service.Annotations[ServiceAnnotationLoadBalancerFilterType]; ok {
+		filterType = t
+	}
 
-	loadBalancers, err := c.client.DescribeLoadBalancers(ctx, loadBalancerName)
+	sourceRanges, err := servicehelpers.GetLoadBalancerSourceRanges(service)
+	if err != nil {
+		return "", nil, err
+	}
+
+	filters := []string{}
+	switch filterType {
+	case loadBalancerFilterTypeAllow:
+		if servicehelpers.IsAllowAll(sourceRanges) {
+			return filterType, filters, nil
+		}
+	case loadBalancerFilterTypeDeny:
+		if len(service.Spec.LoadBalancerSourceRanges) > 0 {
+			return "", nil, fmt.Errorf(
+				"spec.loadBalancerSourceRanges of service %q can not be denied: use annotation %s with %s=%s",
+				service.GetName(), v1.AnnotationLoadBalancerSourceRangesKey, ServiceAnnotationLoadBalancerFilterType, loadBalancerFilterTypeDeny,
+			)
+		}
+		// the source ranges default to allow all if the annotation is not set
+		if strings.TrimSpace(service.Annotations[v1.AnnotationLoadBalancerSourceRangesKey]) == "" {
+			return filterType, filters, nil
+		}
+	default:
+		return "", nil, fmt.Errorf("filter type %q is invalid for service %q", filterType, service.GetName())
+	}
+
+	for _, cidr := range sourceRanges {
+		ipAddress, err := filterIPAddressOf(cidr)
+		if err != nil {
+			return "", nil, fmt.Errorf("source ranges of service %q are invalid: %w", service.GetName(), err)
+		}
+		filters = append(filters, ipAddress)
+	}
+	sort.Strings(filters)
+
+	return filterType, filters, nil
+}
+
+// filterIPAddressOf translates the CIDR into the IP address of the filter of l4 load balancer,
+// that is the IPv4 address for /32 and the IPv4 CIDR from /16 to /31 otherwise
+func filterIPAddressOf(cidr *net.IPNet) (string, error) {
+	ones, bits := cidr.Mask.Size()
+	ip := cidr.IP.To4()
+	if ip == nil || bits != 8*net.IPv4len {
+		return "", fmt.Errorf("%s is not an IPv4 range", cidr)
+	}
+	if ones == 8*net.IPv4len {
+		return ip.String(), nil
+	}
+	if ones < minFilterPrefixLength {
+		return "", fmt.Errorf("%s is wider than /%d which the filter of load balancer accepts", cidr, minFilterPrefixLength)
+	}
+	return (&net.IPNet{IP: ip.Mask(cidr.Mask), Mask: cidr.Mask}).String(), nil
+}
+
+func (c *Cloud) updateL4LoadBalancer(ctx context.Context, clusterName string, service *v1.Service) error {
+	loadBalancerName, loadBalancers, err := c.describeL4LoadBalancersOfService(ctx, clusterName, service)
 	if err != nil {
 		return err
 	}
 	if len(loadBalancers) == 0 {
 		return fmt.Errorf("load balancer %q not found", loadBalancerName)
 	}
-	return nil
+	return c.verifyL4LoadBalancerOwner(ctx, clusterName, service, loadBalancerName, loadBalancers)
 }
 
 func (c *Cloud) ensureL4LoadBalancerDeleted(ctx context.Context, clusterName string, service *v1.Service) error {
-	loadBalancerName := c.GetLoadBalancerName(ctx, clusterName, service)
-
-	loadBalancers, err := c.client.DescribeLoadBalancers(ctx, loadBalancerName)
+	loadBalancerName, loadBalancers, err := c.describeL4LoadBalancersOfService(ctx, clusterName, service)
 	if err != nil {
 		switch {
-		case IsAPIError(err, errorCodeLoadBalancerNotFound):
+		case IsNotFound(err):
 			klog.Infof("load balancer %q is not found", loadBalancerName)
-			return nil
+			return c.forgetLoadBalancer(ctx, "lb", loadBalancerName)
 		}
 		return err
 	}
 	if len(loadBalancers) == 0 {
 		klog.Infof("load balancer %q already deleted", loadBalancerName)
-		return nil
+		return c.forgetLoadBalancer(ctx, "lb", loadBalancerName)
+	}
+	if err := c.verifyL4LoadBalancerOwner(ctx, clusterName, service, loadBalancerName, loadBalancers); err != nil {
+		return fmt.Errorf("refusing to delete load balancer: %w", err)
 	}
 
+	if err := c.deleteL4LoadBalancers(ctx, loadBalancers); err != nil {
+		return err
+	}
+	return c.forgetLoadBalancer(ctx, "lb", loadBalancerName)
+}
+
+// deleteL4LoadBalancers deletes all ports of the load balancer
+func (c *Cloud) deleteL4LoadBalancers(ctx context.Context, loadBalancers []LoadBalancer) error {
 	for _, lb := range loadBalancers {
 		klog.Infof("Deleting LoadBalancer %q (%d -> %d)", lb.Name, lb.LoadBalancerPort, lb.InstancePort)
 		if err := c.client.DeleteLoadBalancer(ctx, &lb); err != nil {
@@ -322,6 +459,26 @@ func findL4LoadBalancer(from []LoadBalancer, target LoadBalancer) (*LoadBalancer
 	)
 }
 
+// l4LoadBalancerHealthCheckEquals returns true if the health check settings of the load balancers are the same
+func l4LoadBalancerHealthCheckEquals(target, other LoadBalancer) bool {
+	return target.HealthCheckTarget == other.HealthCheckTarget &&
+		target.HealthCheckInterval == other.HealthCheckInterval &&
+		target.HealthCheckUnhealthyThreshold == other.HealthCheckUnhealthyThreshold
+}
+
+// l4LoadBalancerSpecUpdate returns the network volume and the accounting type of desire which differ from current.
+// The values not specified in desire are left as is. It returns false if there is nothing to update
+func l4LoadBalancerSpecUpdate(desire, current LoadBalancer) (LoadBalancer, bool) {
+	toUpdate := LoadBalancer{Name: current.Name}
+	if desire.NetworkVolume != 0 && desire.NetworkVolume != current.NetworkVolume {
+		toUpdate.NetworkVolume = desire.NetworkVolume
+	}
+	if desire.AccountingType != "" && desire.AccountingType != current.AccountingType {
+		toUpdate.AccountingType = desire.AccountingType
+	}
+	return toUpdate, toUpdate.NetworkVolume != 0 || toUpdate.AccountingType != ""
+}
+
 func l4LoadBalancerDifferences(target, other []LoadBalancer) []LoadBalancer {
 	diff := []LoadBalancer{}
 	for _, x := range target {
@@ -2,7 +2,7 @@ package nifcloud_test
 
 import (
 	"context"
-	"strings"
+	"net"
 
 	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
 	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
@@ -15,6 +15,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 var _ = Describe("isL4LoadBalancer", func() {
@@ -66,7 +67,7 @@ var _ = Describe("getL4LoadBalancer", func() {
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		loadBalancerUID = types.UID(uuid.NewString())
-		loadBalancerName = strings.Replace(string(loadBalancerUID), "-", "", -1)[:nifcloud.ExportMaxLoadBalancerNameLength]
+		loadBalancerName = nifcloud.ExportLoadBalancerName("testCluster", loadBalancerUID)
 	})
 
 	AfterEach(func() {
@@ -77,12 +78,6 @@ var _ = Describe("getL4LoadBalancer", func() {
 		It("return the status", func() {
 			ctx := context.Background()
 			clusterName := "testCluster"
-			service := &corev1.Service{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: clusterName,
-					UID:  loadBalancerUID,
-				},
-			}
 			testIPAddress := "203.0.113.1"
 
 			expectedStatus := &corev1.LoadBalancerStatus{
@@ -92,6 +87,13 @@ var _ = Describe("getL4LoadBalancer", func() {
 					},
 				},
 			}
+			service := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: clusterName,
+					UID:  loadBalancerUID,
+				},
+				Status: corev1.ServiceStatus{LoadBalancer: *expectedStatus},
+			}
 
 			c := nifcloud.NewMockCloudAPIClient(ctrl)
 			c.EXPECT().
@@ -107,6 +109,7 @@ var _ = Describe("getL4LoadBalancer", func() {
 			cloud := &nifcloud.Cloud{}
 			cloud.SetClient(c)
 			cloud.SetRegion(region)
+			cloud.SetKubeClient(fake.NewSimpleClientset())
 
 			status, exists, err := nifcloud.ExportGetL4LoadBalancer(cloud, ctx, clusterName, service)
 			Expect(err).ShouldNot(HaveOccurred())
@@ -126,13 +129,17 @@ var _ = Describe("getL4LoadBalancer", func() {
 				},
 			}
 
-			apiErr := helper.NewMockAPIError(nifcloud.ExportErrorCodeLoadBalancerNotFound)
+			apiErr := helper.NewMockAPIError(helper.ErrorCodeLoadBalancerNotFound)
 
 			c := nifcloud.NewMockCloudAPIClient(ctrl)
 			c.EXPECT().
 				DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
 				Return([]nifcloud.LoadBalancer{}, apiErr).
 				Times(1)
+			c.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), gomock.Eq(nifcloud.ExportLegacyLoadBalancerName(service))).
+				Return([]nifcloud.LoadBalancer{}, apiErr).
+				Times(1)
 
 			cloud := &nifcloud.Cloud{}
 			cloud.SetClient(c)
@@ -187,7 +194,7 @@ var _ = Describe("ensureL4LoadBalancer", func() {
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		loadBalancerUID = types.UID(uuid.NewString())
-		loadBalancerName = strings.Replace(string(loadBalancerUID), "-", "", -1)[:nifcloud.ExportMaxLoadBalancerNameLength]
+		loadBalancerName = nifcloud.ExportLoadBalancerName("testCluster", loadBalancerUID)
 	})
 
 	AfterEach(func() {
@@ -200,6 +207,7 @@ var _ = Describe("ensureL4LoadBalancer", func() {
 				ctx := context.Background()
 				testIPAddress := "203.0.113.1"
 				testDesire := helper.NewTestL4LoadBalancer(loadBalancerName)
+				testDesire[0].Description = "k8s:testcluster/default/testsvc/testuid"
 
 				expectedStatus := &corev1.LoadBalancerStatus{
 					Ingress: []corev1.LoadBalancerIngress{
@@ -210,7 +218,7 @@ var _ = Describe("ensureL4LoadBalancer", func() {
 				}
 
 				c := nifcloud.NewMockCloudAPIClient(ctrl)
-				notFoundErr := helper.NewMockAPIError(nifcloud.ExportErrorCodeLoadBalancerNotFound)
+				notFoundErr := helper.NewMockAPIError(helper.ErrorCodeLoadBalancerNotFound)
 				c.EXPECT().
 					DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
 					Return([]nifcloud.LoadBalancer{}, notFoundErr).
@@ -223,10 +231,12 @@ var _ = Describe("ensureL4LoadBalancer", func() {
 				cloud := &nifcloud.Cloud{}
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
+				cloud.SetKubeClient(fake.NewSimpleClientset())
 
-				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
+				Expect(nifcloud.LoadBalancerRecordOwners(ctx, cloud)).Should(Equal(map[string]string{"lb." + loadBalancerName: testDesire[0].Description}))
 			})
 		})
 
@@ -245,7 +255,7 @@ var _ = Describe("ensureL4LoadBalancer", func() {
 				}
 
 				c := nifcloud.NewMockCloudAPIClient(ctrl)
-				notFoundErr := helper.NewMockAPIError(nifcloud.ExportErrorCodeLoadBalancerNotFound)
+				notFoundErr := helper.NewMockAPIError(helper.ErrorCodeLoadBalancerNotFound)
 				c.EXPECT().
 					DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
 					Return([]nifcloud.LoadBalancer{}, notFoundErr).
@@ -262,8 +272,9 @@ var _ = Describe("ensureL4LoadBalancer", func() {
 				cloud := &nifcloud.Cloud{}
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
+				cloud.SetKubeClient(fake.NewSimpleClientset())
 
-				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
 			})
@@ -312,7 +323,7 @@ var _ = Describe("ensureL4LoadBalancer", func() {
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
 
-				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
 			})
@@ -359,7 +370,7 @@ var _ = Describe("ensureL4LoadBalancer", func() {
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
 
-				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
 			})
@@ -410,7 +421,7 @@ var _ = Describe("ensureL4LoadBalancer", func() {
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
 
-				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
 			})
@@ -453,7 +464,7 @@ var _ = Describe("ensureL4LoadBalancer", func() {
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
 
-				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
 			})
@@ -496,7 +507,7 @@ var _ = Describe("ensureL4LoadBalancer", func() {
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
 
-				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
 			})
@@ -540,7 +551,7 @@ var _ = Describe("ensureL4LoadBalancer", func() {
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
 
-				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
 			})
@@ -584,7 +595,7 @@ var _ = Describe("ensureL4LoadBalancer", func() {
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
 
-				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
 			})
@@ -634,7 +645,129 @@ var _ = Describe("ensureL4LoadBalancer", func() {
 				cloud.SetClient(c)
 				cloud.SetRegion(region)
 
-				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, loadBalancerName, testDesire)
+				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(*status).Should(Equal(*expectedStatus))
+			})
+		})
+
+		Context("change the filter type of the l4 load balancer", func() {
+			It("set the filter type without changing the addresses", func() {
+				ctx := context.Background()
+				testIPAddress := "203.0.113.1"
+				existedLB := helper.NewTestL4LoadBalancer(loadBalancerName)
+				existedLB[0].VIP = testIPAddress
+				existedLB[0].Filters = append(existedLB[0].Filters, "198.51.100.0/24")
+				testDesire := helper.NewTestL4LoadBalancer(loadBalancerName)
+				testDesire[0].FilterType = "2"
+				testDesire[0].Filters = append(testDesire[0].Filters, "198.51.100.0/24")
+
+				c := nifcloud.NewMockCloudAPIClient(ctrl)
+				c.EXPECT().
+					DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+					Return(existedLB, nil).
+					Times(1)
+
+				c.EXPECT().
+					SetFilterForLoadBalancer(gomock.Any(), gomock.Any(), []nifcloud.Filter{}).
+					Do(func(_ context.Context, loadBalancer *nifcloud.LoadBalancer, _ []nifcloud.Filter) {
+						Expect(loadBalancer.FilterType).Should(Equal("2"))
+					}).
+					Return(nil).
+					Times(1)
+
+				cloud := &nifcloud.Cloud{}
+				cloud.SetClient(c)
+				cloud.SetRegion(region)
+
+				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(status.Ingress[0].IP).Should(Equal(testIPAddress))
+			})
+		})
+
+		Context("update the health check of the l4 load balancer", func() {
+			It("configure the health check", func() {
+				ctx := context.Background()
+				testIPAddress := "203.0.113.1"
+				existedLB := helper.NewTestL4LoadBalancer(loadBalancerName)
+				existedLB[0].VIP = testIPAddress
+				testDesire := helper.NewTestL4LoadBalancer(loadBalancerName)
+				testDesire[0].HealthCheckTarget = "ICMP"
+				testDesire[0].HealthCheckInterval = 30
+				testDesire[0].HealthCheckUnhealthyThreshold = 3
+
+				expectedStatus := &corev1.LoadBalancerStatus{
+					Ingress: []corev1.LoadBalancerIngress{
+						{
+							IP: testIPAddress,
+						},
+					},
+				}
+
+				c := nifcloud.NewMockCloudAPIClient(ctrl)
+				c.EXPECT().
+					DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+					Return(existedLB, nil).
+					Times(1)
+
+				c.EXPECT().
+					ConfigureHealthCheck(gomock.Any(), gomock.Eq(&testDesire[0])).
+					Return(nil).
+					Times(1)
+
+				cloud := &nifcloud.Cloud{}
+				cloud.SetClient(c)
+				cloud.SetRegion(region)
+
+				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(*status).Should(Equal(*expectedStatus))
+			})
+		})
+
+		Context("update the network volume and the accounting type of the l4 load balancer", func() {
+			It("update the load balancer once", func() {
+				ctx := context.Background()
+				testIPAddress := "203.0.113.1"
+				existedLB := helper.NewTestL4LoadBalancerWithTwoPort(loadBalancerName)
+				for i := range existedLB {
+					existedLB[i].VIP = testIPAddress
+				}
+				testDesire := helper.NewTestL4LoadBalancerWithTwoPort(loadBalancerName)
+				for i := range testDesire {
+					testDesire[i].NetworkVolume = 500
+					testDesire[i].AccountingType = "2"
+				}
+
+				expectedStatus := &corev1.LoadBalancerStatus{
+					Ingress: []corev1.LoadBalancerIngress{
+						{
+							IP: testIPAddress,
+						},
+					},
+				}
+
+				c := nifcloud.NewMockCloudAPIClient(ctrl)
+				c.EXPECT().
+					DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+					Return(existedLB, nil).
+					Times(1)
+
+				c.EXPECT().
+					UpdateLoadBalancer(gomock.Any(), gomock.Eq(&nifcloud.LoadBalancer{
+						Name:           loadBalancerName,
+						NetworkVolume:  500,
+						AccountingType: "2",
+					})).
+					Return(nil).
+					Times(1)
+
+				cloud := &nifcloud.Cloud{}
+				cloud.SetClient(c)
+				cloud.SetRegion(region)
+
+				status, err := nifcloud.ExportEnsureL4LoadBalancer(cloud, ctx, &corev1.Service{}, loadBalancerName, testDesire)
 				Expect(err).ShouldNot(HaveOccurred())
 				Expect(*status).Should(Equal(*expectedStatus))
 			})
@@ -734,8 +867,69 @@ var _ = Describe("NewL4LoadBalancerFromService", func() {
 			Expect(gotLB).Should(Equal(expectLB))
 		})
 	})
+
+	Context("given l4 load balancer that has a filter the load balancer can not express", func() {
+		It("return error", func() {
+			testService.Spec.LoadBalancerSourceRanges = append(testService.Spec.LoadBalancerSourceRanges, "10.0.0.0/8")
+			testInstances := []nifcloud.Instance{*helper.NewTestInstance()}
+			_, err := nifcloud.NewL4LoadBalancerFromService(loadBalancerName, testInstances, &testService)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("given l4 load balancer that denies the source ranges", func() {
+		It("return the l4 load balancer", func() {
+			testService.Annotations[nifcloud.ServiceAnnotationLoadBalancerFilterType] = "2"
+			testService.Annotations[corev1.AnnotationLoadBalancerSourceRangesKey] = "198.51.100.0/24, 192.0.2.10/32"
+			testInstances := []nifcloud.Instance{*helper.NewTestInstance()}
+			expectLB := helper.NewTestL4LoadBalancer(loadBalancerName)
+			expectLB[0].FilterType = "2"
+			expectLB[0].Filters = []string{"192.0.2.10", "198.51.100.0/24"}
+			gotLB, err := nifcloud.NewL4LoadBalancerFromService(loadBalancerName, testInstances, &testService)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotLB).Should(Equal(expectLB))
+		})
+
+		It("return the l4 load balancer which denies nothing without the source ranges", func() {
+			testService.Annotations[nifcloud.ServiceAnnotationLoadBalancerFilterType] = "2"
+			testInstances := []nifcloud.Instance{*helper.NewTestInstance()}
+			expectLB := helper.NewTestL4LoadBalancer(loadBalancerName)
+			expectLB[0].FilterType = "2"
+			gotLB, err := nifcloud.NewL4LoadBalancerFromService(loadBalancerName, testInstances, &testService)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotLB).Should(Equal(expectLB))
+		})
+
+		It("return error if spec.loadBalancerSourceRanges is set", func() {
+			testService.Annotations[nifcloud.ServiceAnnotationLoadBalancerFilterType] = "2"
+			testService.Spec.LoadBalancerSourceRanges = append(testService.Spec.LoadBalancerSourceRanges, "198.51.100.0/24")
+			testInstances := []nifcloud.Instance{*helper.NewTestInstance()}
+			_, err := nifcloud.NewL4LoadBalancerFromService(loadBalancerName, testInstances, &testService)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
 })
 
+var _ = DescribeTable("filterIPAddressOf",
+	func(cidr string, expected string, expectError bool) {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		Expect(err).ShouldNot(HaveOccurred())
+		got, err := nifcloud.ExportFilterIPAddressOf(ipNet)
+		if expectError {
+			Expect(err).Should(HaveOccurred())
+			return
+		}
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(got).Should(Equal(expected))
+	},
+	Entry("a single address", "192.0.2.10/32", "192.0.2.10", false),
+	Entry("a range", "198.51.100.0/24", "198.51.100.0/24", false),
+	Entry("the widest range", "172.16.0.0/16", "172.16.0.0/16", false),
+	Entry("a range wider than /16", "10.0.0.0/8", "", true),
+	Entry("all addresses", "0.0.0.0/0", "", true),
+	Entry("an IPv6 range", "2001:db8::/64", "", true),
+)
+
 var _ = Describe("updateL4LoadBalancer", func() {
 	var ctrl *gomock.Controller
 	var region string = "east1"
@@ -747,7 +941,7 @@ var _ = Describe("updateL4LoadBalancer", func() {
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		loadBalancerUID = types.UID(uuid.NewString())
-		loadBalancerName = strings.Replace(string(loadBalancerUID), "-", "", -1)[:nifcloud.ExportMaxLoadBalancerNameLength]
+		loadBalancerName = nifcloud.ExportLoadBalancerName("testCluster", loadBalancerUID)
 		testService = &corev1.Service{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: "testlbsvc",
@@ -775,6 +969,9 @@ var _ = Describe("updateL4LoadBalancer", func() {
 			cloud := &nifcloud.Cloud{}
 			cloud.SetClient(c)
 			cloud.SetRegion(region)
+			cloud.SetKubeClient(fake.NewSimpleClientset(nifcloud.NewLoadBalancerRecords(map[string]string{
+				"lb." + loadBalancerName: nifcloud.ExportLoadBalancerOwner(clusterName, testService),
+			})))
 
 			err := nifcloud.ExportUpdateL4LoadBalancer(cloud, ctx, clusterName, testService)
 			Expect(err).ShouldNot(HaveOccurred())
@@ -786,13 +983,17 @@ var _ = Describe("updateL4LoadBalancer", func() {
 			ctx := context.Background()
 
 			testLB := []nifcloud.LoadBalancer{}
-			notFoundErr := helper.NewMockAPIError(nifcloud.ExportErrorCodeLoadBalancerNotFound)
+			notFoundErr := helper.NewMockAPIError(helper.ErrorCodeLoadBalancerNotFound)
 
 			c := nifcloud.NewMockCloudAPIClient(ctrl)
 			c.EXPECT().
 				DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
 				Return(testLB, notFoundErr).
 				Times(1)
+			c.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), gomock.Eq(nifcloud.ExportLegacyLoadBalancerName(testService))).
+				Return(testLB, notFoundErr).
+				Times(1)
 
 			cloud := &nifcloud.Cloud{}
 			cloud.SetClient(c)
@@ -816,7 +1017,7 @@ var _ = Describe("ensureL4LoadBalancerDeleted", func() {
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		loadBalancerUID = types.UID(uuid.NewString())
-		loadBalancerName = strings.Replace(string(loadBalancerUID), "-", "", -1)[:nifcloud.ExportMaxLoadBalancerNameLength]
+		loadBalancerName = nifcloud.ExportLoadBalancerName("testCluster", loadBalancerUID)
 		testService = &corev1.Service{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: "testlbsvc",
@@ -848,9 +1049,33 @@ var _ = Describe("ensureL4LoadBalancerDeleted", func() {
 			cloud := &nifcloud.Cloud{}
 			cloud.SetClient(c)
 			cloud.SetRegion(region)
+			cloud.SetKubeClient(fake.NewSimpleClientset(nifcloud.NewLoadBalancerRecords(map[string]string{
+				"lb." + loadBalancerName: nifcloud.ExportLoadBalancerOwner(clusterName, testService),
+			})))
 
 			err := nifcloud.ExportEnsureL4LoadBalancerDeleted(cloud, ctx, clusterName, testService)
 			Expect(err).ShouldNot(HaveOccurred())
+			Expect(nifcloud.LoadBalancerRecordOwners(ctx, cloud)).ShouldNot(HaveKey("lb." + loadBalancerName))
+		})
+
+		It("refuse to delete the l4 load balancer whose owner is unknown", func() {
+			ctx := context.Background()
+
+			testLB := helper.NewTestL4LoadBalancer(loadBalancerName)
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(testLB, nil).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetRegion(region)
+			cloud.SetKubeClient(fake.NewSimpleClientset())
+
+			err := nifcloud.ExportEnsureL4LoadBalancerDeleted(cloud, ctx, clusterName, testService)
+			Expect(err).Should(HaveOccurred())
 		})
 	})
 
@@ -859,20 +1084,28 @@ var _ = Describe("ensureL4LoadBalancerDeleted", func() {
 			ctx := context.Background()
 
 			testLB := []nifcloud.LoadBalancer{}
-			notFoundErr := helper.NewMockAPIError(nifcloud.ExportErrorCodeLoadBalancerNotFound)
+			notFoundErr := helper.NewMockAPIError(helper.ErrorCodeLoadBalancerNotFound)
 
 			c := nifcloud.NewMockCloudAPIClient(ctrl)
 			c.EXPECT().
 				DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
 				Return(testLB, notFoundErr).
 				Times(1)
+			c.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), gomock.Eq(nifcloud.ExportLegacyLoadBalancerName(testService))).
+				Return(testLB, notFoundErr).
+				Times(1)
 
 			cloud := &nifcloud.Cloud{}
 			cloud.SetClient(c)
 			cloud.SetRegion(region)
+			cloud.SetKubeClient(fake.NewSimpleClientset(nifcloud.NewLoadBalancerRecords(map[string]string{
+				"lb." + loadBalancerName: nifcloud.ExportLoadBalancerOwner(clusterName, testService),
+			})))
 
 			err := nifcloud.ExportEnsureL4LoadBalancerDeleted(cloud, ctx, clusterName, testService)
 			Expect(err).ShouldNot(HaveOccurred())
+			Expect(nifcloud.LoadBalancerRecordOwners(ctx, cloud)).ShouldNot(HaveKey("lb." + loadBalancerName))
 		})
 	})
 })
@@ -902,6 +1135,41 @@ var _ = Describe("findL4LoadBalancer", func() {
 	})
 })
 
+var _ = DescribeTable("l4LoadBalancerHealthCheckEquals",
+	func(modify func(lb *nifcloud.LoadBalancer), expected bool) {
+		target := helper.NewTestL4LoadBalancer("testloadbalancer")[0]
+		other := helper.NewTestL4LoadBalancer("testloadbalancer")[0]
+		modify(&other)
+		Expect(nifcloud.ExportL4LoadBalancerHealthCheckEquals(target, other)).Should(Equal(expected))
+	},
+	Entry("the health check is the same", func(lb *nifcloud.LoadBalancer) { lb.BalancingTargets = nil }, true),
+	Entry("the target is changed", func(lb *nifcloud.LoadBalancer) { lb.HealthCheckTarget = "ICMP" }, false),
+	Entry("the interval is changed", func(lb *nifcloud.LoadBalancer) { lb.HealthCheckInterval = 30 }, false),
+	Entry("the unhealthy threshold is changed", func(lb *nifcloud.LoadBalancer) { lb.HealthCheckUnhealthyThreshold = 3 }, false),
+)
+
+var _ = DescribeTable("l4LoadBalancerSpecUpdate",
+	func(modify func(lb *nifcloud.LoadBalancer), expected *nifcloud.LoadBalancer) {
+		desire := helper.NewTestL4LoadBalancer("testloadbalancer")[0]
+		current := helper.NewTestL4LoadBalancer("testloadbalancer")[0]
+		modify(&desire)
+		toUpdate, ok := nifcloud.ExportL4LoadBalancerSpecUpdate(desire, current)
+		Expect(ok).Should(Equal(expected != nil))
+		if expected != nil {
+			Expect(toUpdate).Should(Equal(*expected))
+		}
+	},
+	Entry("nothing is changed", func(lb *nifcloud.LoadBalancer) { lb.PolicyType = "ats" }, nil),
+	Entry("the values are not specified", func(lb *nifcloud.LoadBalancer) {
+		lb.NetworkVolume = 0
+		lb.AccountingType = ""
+	}, nil),
+	Entry("the network volume is changed", func(lb *nifcloud.LoadBalancer) { lb.NetworkVolume = 500 },
+		&nifcloud.LoadBalancer{Name: "testloadbalancer", NetworkVolume: 500}),
+	Entry("the accounting type is changed", func(lb *nifcloud.LoadBalancer) { lb.AccountingType = "2" },
+		&nifcloud.LoadBalancer{Name: "testloadbalancer", AccountingType: "2"}),
+)
+
 var _ = Describe("l4LoadBalancerDifferences", func() {
 	var loadBalancerName = "testloadbalancer"
 
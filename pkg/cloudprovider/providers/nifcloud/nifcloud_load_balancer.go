@@ -2,12 +2,16 @@ package nifcloud
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strconv"
 	"strings"
 
 	"golang.org/x/exp/slices"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
 )
 
 const (
@@ -15,6 +19,9 @@ const (
 	maxLoadBalancerNameLength   = 15
 	maxPortCountPerLoadBalancer = 3
 
+	// the load balancer name is the cluster hash followed by the service hash
+	loadBalancerNameClusterHashLength = 5
+
 	// default health check parameter values
 	defaultHealthCheckInterval           = 10
 	defaultHealthCheckUnhealthyThreshold = 1
@@ -50,6 +57,11 @@ const (
 	// See https://docs.nifcloud.com/cp/api/NiftyCreateElasticLoadBalancer.htm for elastic load balancer
 	ServiceAnnotationLoadBalancerBalancingType = "service.beta.kubernetes.io/nifcloud-load-balancer-balancing-type"
 
+	// ServiceAnnotationLoadBalancerFilterType is the annotation that specify filter type for l4 load balancer
+	// 1: allow the source ranges (default), 2: deny the source ranges in annotation service.beta.kubernetes.io/load-balancer-source-ranges
+	// See https://docs.nifcloud.com/cp/api/SetFilterForLoadBalancer.htm
+	ServiceAnnotationLoadBalancerFilterType = "service.beta.kubernetes.io/nifcloud-load-balancer-filter-type"
+
 	// ServiceAnnotationLoadBalancerHCProtocol is the annotation that specify health check protocol for load balancer
 	// valid values are 'TCP' or 'ICMP'
 	// See https://docs.nifcloud.com/cp/api/ConfigureHealthCheck.htm for l4 load balancer
@@ -89,6 +101,15 @@ const (
 	// valid values are '1' or '2'
 	// See https://docs.nifcloud.com/cp/api/NiftyCreateElasticLoadBalancer.htm
 	ServiceAnnotationLoadBalancerVipNetwork = "service.beta.kubernetes.io/nifcloud-load-balancer-vip-network"
+
+	// ServiceAnnotationLoadBalancerAdopt is the annotation that allows to modify and delete the load balancer
+	// whose ownership marker is of another cluster or service
+	// valid values are 'true' or 'false'(default)
+	ServiceAnnotationLoadBalancerAdopt = "service.beta.kubernetes.io/nifcloud-load-balancer-adopt"
+
+	// ServiceAnnotationLoadBalancerPreviousType is the annotation recorded by the controller while the load balancer type
+	// of the service is changed. The value is the previous type whose load balancer is deleted after the new one is published
+	ServiceAnnotationLoadBalancerPreviousType = "service.beta.kubernetes.io/nifcloud-load-balancer-previous-type"
 )
 
 var allowedElasticLoadBalancerNetworkVolume = []string{"10", "20", "30", "40", "100", "200", "300", "400", "500"}
@@ -99,6 +120,17 @@ var allowedL4LoadBalancerNetworkVolume = []string{
 
 // GetLoadBalancer returns whether the specified load balancer exists, and if so, what its status is
 func (c *Cloud) GetLoadBalancer(ctx context.Context, clusterName string, service *v1.Service) (status *v1.LoadBalancerStatus, exists bool, err error) {
+	service = c.withLoadBalancerDefaults(service)
+	status, exists, err = c.getLoadBalancerOfType(ctx, clusterName, service)
+	if err != nil || exists || !isLoadBalancerTypeMigrating(service) {
+		return status, exists, err
+	}
+	// the load balancer of the previous type is still in use until the new one is created
+	return c.getLoadBalancerOfType(ctx, clusterName, withPreviousLoadBalancerType(service))
+}
+
+// getLoadBalancerOfType returns the load balancer of the type in the annotation of the service
+func (c *Cloud) getLoadBalancerOfType(ctx context.Context, clusterName string, service *v1.Service) (*v1.LoadBalancerStatus, bool, error) {
 	if isElasticLoadBalancer(service.Annotations) {
 		return c.getElasticLoadBalancer(ctx, clusterName, service)
 	}
@@ -108,13 +140,93 @@ func (c *Cloud) GetLoadBalancer(ctx context.Context, clusterName string, service
 	return nil, false, nil
 }
 
-// GetLoadBalancerName returns the name of the load balancer
+// GetLoadBalancerName returns the name of the load balancer.
+// The name is the hash of the cluster name followed by the hash of the service UID,
+// so that the load balancers of the clusters in the same account do not collide
 func (c *Cloud) GetLoadBalancerName(ctx context.Context, clusterName string, service *v1.Service) string {
-	return strings.Replace(string(service.UID), "-", "", -1)[:maxLoadBalancerNameLength]
+	return loadBalancerName(clusterName, service.UID)
+}
+
+func loadBalancerName(clusterName string, uid types.UID) string {
+	serviceHash := sha256.Sum256([]byte(uid))
+	name := loadBalancerNamePrefix(clusterName) + hex.EncodeToString(serviceHash[:])
+	return name[:maxLoadBalancerNameLength]
+}
+
+// loadBalancerNamePrefix returns the hash of the cluster name which all load balancers of the cluster start with
+func loadBalancerNamePrefix(clusterName string) string {
+	clusterHash := sha256.Sum256([]byte(clusterName))
+	return hex.EncodeToString(clusterHash[:])[:loadBalancerNameClusterHashLength]
+}
+
+// legacyLoadBalancerName returns the name of the load balancer created before the cluster name was encoded,
+// that is the service UID without dashes. It is empty if the UID is too short
+func legacyLoadBalancerName(service *v1.Service) string {
+	name := strings.ReplaceAll(string(service.UID), "-", "")
+	if len(name) < maxLoadBalancerNameLength {
+		return ""
+	}
+	return name[:maxLoadBalancerNameLength]
+}
+
+// migrateLoadBalancerName checks the existing load balancer with the name of the service is owned by the service
+// before it is ensured, and renames the load balancer created under the legacy name.
+// It is checked even if the service has no ingress yet, so that a new service never takes over the load balancer of another
+func (c *Cloud) migrateLoadBalancerName(ctx context.Context, clusterName string, service *v1.Service) error {
+	loadBalancerName := c.GetLoadBalancerName(ctx, clusterName, service)
+	var (
+		foundName string
+		err       error
+	)
+	if isElasticLoadBalancer(service.Annotations) {
+		var elbs []ElasticLoadBalancer
+		foundName, elbs, err = c.describeElasticLoadBalancersOfService(ctx, clusterName, service)
+		if err != nil {
+			if IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if err := c.verifyElasticLoadBalancerOwner(ctx, clusterName, service, foundName, elbs); err != nil {
+			return err
+		}
+	} else {
+		var lbs []LoadBalancer
+		foundName, lbs, err = c.describeL4LoadBalancersOfService(ctx, clusterName, service)
+		if err != nil {
+			if IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if err := c.verifyL4LoadBalancerOwner(ctx, clusterName, service, foundName, lbs); err != nil {
+			return err
+		}
+	}
+
+	if foundName == loadBalancerName {
+		return nil
+	}
+
+	klog.Infof("Renaming load balancer %q of service %s/%s to %q", foundName, service.Namespace, service.Name, loadBalancerName)
+	loadBalancerType := loadBalancerTypeOf(service.Annotations)
+	if err := c.recordLoadBalancerOwner(ctx, loadBalancerType, loadBalancerName, loadBalancerOwner(clusterName, service)); err != nil {
+		return err
+	}
+	if isElasticLoadBalancer(service.Annotations) {
+		err = c.client.UpdateElasticLoadBalancerName(ctx, foundName, loadBalancerName)
+	} else {
+		err = c.client.UpdateLoadBalancerName(ctx, foundName, loadBalancerName)
+	}
+	if err != nil {
+		return err
+	}
+	return c.forgetLoadBalancer(ctx, loadBalancerType, foundName)
 }
 
 // EnsureLoadBalancer creates a new load balancer 'name', or updates the existing one. Returns the status of the balancer
 func (c *Cloud) EnsureLoadBalancer(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node) (*v1.LoadBalancerStatus, error) {
+	service = c.withLoadBalancerDefaults(service)
 	portCount := len(service.Spec.Ports)
 	if portCount == 0 {
 		return nil, fmt.Errorf("requested load balancer with no ports")
@@ -127,41 +239,63 @@ func (c *Cloud) EnsureLoadBalancer(ctx context.Context, clusterName string, serv
 	}
 
 	// check nodes exist
-	instanceIDs := make([]string, len(nodes))
-	for i, node := range nodes {
-		instanceIDs[i] = node.GetName()
-	}
-	instances, err := c.client.DescribeInstancesByInstanceID(ctx, instanceIDs)
+	instances, err := c.loadBalancerBackends(ctx, nodes)
 	if err != nil {
-		return nil, fmt.Errorf("could not fetch instances info for %v: %w", instanceIDs, err)
+		return nil, err
 	}
 
-	loadBalancerName := c.GetLoadBalancerName(ctx, clusterName, service)
-
 	err = validateLoadBalancerAnnotations(service.Annotations)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := c.migrateLoadBalancerName(ctx, clusterName, service); err != nil {
+		return nil, err
+	}
+	loadBalancerName := c.GetLoadBalancerName(ctx, clusterName, service)
+	owner := loadBalancerOwner(clusterName, service)
+
+	var status *v1.LoadBalancerStatus
 	if isElasticLoadBalancer(service.Annotations) {
+		if err := c.checkElasticLoadBalancerSourceRanges(ctx, loadBalancerName, service); err != nil {
+			return nil, err
+		}
 		elb, err := NewElasticLoadBalancerFromService(loadBalancerName, instances, service)
 		if err != nil {
 			return nil, err
 		}
-		return c.ensureElasticLoadBalancer(ctx, loadBalancerName, elb)
-	}
-	if isL4LoadBalancer(service.Annotations) {
+		for i := range elb {
+			elb[i].Description = owner
+		}
+		status, err = c.ensureElasticLoadBalancer(ctx, service, loadBalancerName, elb)
+		if err != nil {
+			return nil, err
+		}
+	} else if isL4LoadBalancer(service.Annotations) {
 		l4lb, err := NewL4LoadBalancerFromService(loadBalancerName, instances, service)
 		if err != nil {
 			return nil, err
 		}
-		return c.ensureL4LoadBalancer(ctx, loadBalancerName, l4lb)
+		for i := range l4lb {
+			l4lb[i].Description = owner
+		}
+		status, err = c.ensureL4LoadBalancer(ctx, service, loadBalancerName, l4lb)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, fmt.Errorf("the load balancer type is not supported")
 	}
-	return nil, fmt.Errorf("the load balancer type is not supported")
+
+	if err := c.migrateLoadBalancerType(ctx, clusterName, service, status); err != nil {
+		return nil, err
+	}
+	return status, nil
 }
 
 // UpdateLoadBalancer updates hosts under the specified load balancer
 func (c *Cloud) UpdateLoadBalancer(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node) error {
+	service = c.withLoadBalancerDefaults(service)
 	err := validateLoadBalancerAnnotations(service.Annotations)
 	if err != nil {
 		return err
@@ -187,6 +321,17 @@ func (c *Cloud) UpdateLoadBalancer(ctx context.Context, clusterName string, serv
 
 // EnsureLoadBalancerDeleted deletes the specified load balancer if it exists
 func (c *Cloud) EnsureLoadBalancerDeleted(ctx context.Context, clusterName string, service *v1.Service) error {
+	service = c.withLoadBalancerDefaults(service)
+	if isLoadBalancerTypeMigrating(service) {
+		if err := c.ensureLoadBalancerOfTypeDeleted(ctx, clusterName, withPreviousLoadBalancerType(service)); err != nil {
+			return err
+		}
+	}
+	return c.ensureLoadBalancerOfTypeDeleted(ctx, clusterName, service)
+}
+
+// ensureLoadBalancerOfTypeDeleted deletes the load balancer of the type in the annotation of the service
+func (c *Cloud) ensureLoadBalancerOfTypeDeleted(ctx context.Context, clusterName string, service *v1.Service) error {
 	if isElasticLoadBalancer(service.Annotations) {
 		return c.ensureElasticLoadBalancerDeleted(ctx, clusterName, service)
 	}
@@ -196,6 +341,43 @@ func (c *Cloud) EnsureLoadBalancerDeleted(ctx context.Context, clusterName strin
 	return fmt.Errorf("the load balancer type is not supported")
 }
 
+// withLoadBalancerDefaults returns a copy of the service whose missing annotations are
+// filled with the cluster-wide defaults in the cloud config.
+// The defaults specific to load balancer type (network volume and policy type) are only applied
+// when the service uses the default load balancer type.
+func (c *Cloud) withLoadBalancerDefaults(service *v1.Service) *v1.Service {
+	defaults := c.config.LoadBalancer.annotations()
+	if len(defaults) == 0 {
+		return service
+	}
+
+	defaultType := defaults[ServiceAnnotationLoadBalancerType]
+	if defaultType == "" {
+		defaultType = "lb"
+	}
+	serviceType, ok := service.Annotations[ServiceAnnotationLoadBalancerType]
+	if !ok {
+		serviceType = defaultType
+	}
+
+	svc := service.DeepCopy()
+	if svc.Annotations == nil {
+		svc.Annotations = map[string]string{}
+	}
+	for key, value := range defaults {
+		if _, ok := svc.Annotations[key]; ok {
+			continue
+		}
+		if serviceType != defaultType &&
+			(key == ServiceAnnotationLoadBalancerNetworkVolume || key == ServiceAnnotationLoadBalancerPolicyType) {
+			continue
+		}
+		svc.Annotations[key] = value
+	}
+
+	return svc
+}
+
 func validateLoadBalancerAnnotations(annotations map[string]string) error {
 	// validation of both l4 load balancer and elastic load balancer
 	loadBalancerType, ok := annotations[ServiceAnnotationLoadBalancerType]
@@ -253,6 +435,12 @@ func validateLoadBalancerAnnotations(annotations map[string]string) error {
 			}
 		}
 
+		if filterType, ok := annotations[ServiceAnnotationLoadBalancerFilterType]; ok {
+			if filterType != loadBalancerFilterTypeAllow && filterType != loadBalancerFilterTypeDeny {
+				return fmt.Errorf("annotation %s=%s is invalid", ServiceAnnotationLoadBalancerFilterType, filterType)
+			}
+		}
+
 		if networkInterface, ok := annotations[ServiceAnnotationLoadBalancerNetworkInterface1]; ok {
 			if networkInterface != "" {
 				return fmt.Errorf("annotation %s is only enabled for %s=elb", ServiceAnnotationLoadBalancerNetworkInterface1, ServiceAnnotationLoadBalancerType)
@@ -309,6 +497,12 @@ func validateLoadBalancerAnnotations(annotations map[string]string) error {
 			}
 		}
 
+		if filterType, ok := annotations[ServiceAnnotationLoadBalancerFilterType]; ok {
+			if filterType != "" {
+				return fmt.Errorf("annotation %s is only enabled for %s=lb", ServiceAnnotationLoadBalancerFilterType, ServiceAnnotationLoadBalancerType)
+			}
+		}
+
 		if networkInterface1, ok := annotations[ServiceAnnotationLoadBalancerNetworkInterface1]; ok {
 			if isPrivateLanNetworkID(networkInterface1) {
 				if ipAddress, ok := annotations[ServiceAnnotationLoadBalancerNetworkInterface1IPAddress]; ok {
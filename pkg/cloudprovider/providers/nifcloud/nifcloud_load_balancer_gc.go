@@ -0,0 +1,198 @@
+package nifcloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultLoadBalancerGCInterval    = 10 * time.Minute
+	defaultLoadBalancerGCGracePeriod = time.Hour
+)
+
+func (gcc LoadBalancerGCConfig) validate() error {
+	if gcc.Interval.Duration < 0 {
+		return fmt.Errorf("interval must not be negative")
+	}
+	if gcc.GracePeriod.Duration < 0 {
+		return fmt.Errorf("gracePeriod must not be negative")
+	}
+	return nil
+}
+
+func (gcc LoadBalancerGCConfig) gracePeriod() time.Duration {
+	if gcc.GracePeriod.Duration > 0 {
+		return gcc.GracePeriod.Duration
+	}
+	return defaultLoadBalancerGCGracePeriod
+}
+
+// isOwnedByOtherCluster returns true if any of the descriptions is the ownership marker of another cluster
+func isOwnedByOtherCluster(clusterName string, descriptions []string) bool {
+	return lo.ContainsBy(descriptions, func(description string) bool {
+		owner, _, ok := parseLoadBalancerOwner(description)
+		return ok && owner != clusterName
+	})
+}
+
+// runLoadBalancerGC deletes the orphaned load balancers periodically until stop is closed
+func (c *Cloud) runLoadBalancerGC(stop <-chan struct{}) {
+	interval := c.config.LoadBalancerGC.Interval.Duration
+	if interval <= 0 {
+		interval = defaultLoadBalancerGCInterval
+	}
+
+	klog.Infof(
+		"Starting load balancer garbage collector (cluster: %q, interval: %s, grace period: %s, dry run: %t)",
+		c.clusterName, interval, c.config.LoadBalancerGC.gracePeriod(), c.config.LoadBalancerGC.DryRun,
+	)
+	orphanedSince := map[string]time.Time{}
+	wait.UntilWithContext(wait.ContextForChannel(stop), func(ctx context.Context) {
+		if err := c.collectLoadBalancerGarbage(ctx, orphanedSince, time.Now()); err != nil {
+			klog.Errorf("Failed to collect orphaned load balancers: %v", err)
+		}
+	}, interval)
+}
+
+// collectLoadBalancerGarbage deletes the load balancers recorded as owned by the services of the cluster which no longer exist,
+// and revokes the security group rules left behind by the elastic load balancers already deleted.
+// The load balancers without the record (e.g. of another cluster with the same name) are never deleted.
+// orphanedSince is when each record is found orphaned, and it is collected after it has been orphaned for the grace period
+func (c *Cloud) collectLoadBalancerGarbage(ctx context.Context, orphanedSince map[string]time.Time, now time.Time) error {
+	services, err := c.kubeClient.CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+	inUse := map[types.UID]bool{}
+	for i := range services.Items {
+		service := &services.Items[i]
+		if service.Spec.Type != v1.ServiceTypeLoadBalancer {
+			continue
+		}
+		inUse[service.UID] = true
+	}
+
+	records, err := c.loadBalancerRecords(ctx)
+	if err != nil {
+		return err
+	}
+	loadBalancers, err := c.client.DescribeAllLoadBalancers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to describe load balancers: %w", err)
+	}
+	elasticLoadBalancers, err := c.client.DescribeAllElasticLoadBalancers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to describe elastic load balancers: %w", err)
+	}
+	l4ByName := lo.GroupBy(loadBalancers, func(lb LoadBalancer) string { return lb.Name })
+	elasticByName := lo.GroupBy(elasticLoadBalancers, func(lb ElasticLoadBalancer) string { return lb.Name })
+
+	// orphans are the functions collecting the orphaned records keyed by the record key
+	orphans := map[string]func() error{}
+	orphanCounts := map[string]int{"lb": 0, "elb": 0}
+	for key, record := range records {
+		loadBalancerType, name, ok := parseLoadBalancerRecordKey(key)
+		if !ok {
+			continue
+		}
+		cluster, uid, ok := parseLoadBalancerOwner(record.Owner)
+		if !ok || cluster != c.clusterName || inUse[uid] {
+			continue
+		}
+
+		switch loadBalancerType {
+		case "lb":
+			lbs := l4ByName[name]
+			if isOwnedByOtherCluster(c.clusterName, lo.Map(lbs, func(lb LoadBalancer, _ int) string { return lb.Description })) {
+				continue
+			}
+			orphans[key] = func() error {
+				if err := c.deleteL4LoadBalancers(ctx, lbs); err != nil {
+					return err
+				}
+				return c.forgetLoadBalancer(ctx, "lb", name)
+			}
+		case "elb":
+			lbs := elasticByName[name]
+			if isOwnedByOtherCluster(c.clusterName, lo.Map(lbs, func(lb ElasticLoadBalancer, _ int) string { return lb.Description })) {
+				continue
+			}
+			record := record
+			orphans[key] = func() error {
+				if len(lbs) == 0 {
+					// the load balancer is deleted, but the security group rules may be left behind
+					instances := lo.Map(record.InstanceIDs, func(instanceID string, _ int) Instance { return Instance{InstanceID: instanceID} })
+					if err := c.revokeSecurityGroupRules(ctx, instances, record.SecurityGroupRules); err != nil {
+						return fmt.Errorf("failed to revoke security group rules: %w", err)
+					}
+				} else if err := c.deleteElasticLoadBalancers(ctx, lbs); err != nil {
+					return err
+				}
+				return c.forgetLoadBalancer(ctx, "elb", name)
+			}
+		default:
+			continue
+		}
+		orphanCounts[loadBalancerType]++
+	}
+	for loadBalancerType, count := range orphanCounts {
+		loadBalancerGCOrphansMetric.With(prometheus.Labels{"type": loadBalancerType}).Set(float64(count))
+	}
+
+	// forget the records which are collected or in use again
+	for key := range orphanedSince {
+		if _, ok := orphans[key]; !ok {
+			delete(orphanedSince, key)
+		}
+	}
+
+	errs := []error{}
+	for _, key := range lo.Keys(orphans) {
+		if !c.isLoadBalancerGarbage(key, orphanedSince, now) {
+			continue
+		}
+		loadBalancerType, _, _ := parseLoadBalancerRecordKey(key)
+		errs = append(errs, c.deleteLoadBalancerGarbage(key, loadBalancerType, orphanedSince, orphans[key]))
+	}
+
+	return errors.Join(errs...)
+}
+
+// isLoadBalancerGarbage records when the load balancer is found orphaned,
+// and returns true if it has been orphaned for the grace period
+func (c *Cloud) isLoadBalancerGarbage(name string, orphanedSince map[string]time.Time, now time.Time) bool {
+	since, ok := orphanedSince[name]
+	if !ok {
+		klog.Infof("Load balancer %q is orphaned, it will be deleted after %s", name, c.config.LoadBalancerGC.gracePeriod())
+		orphanedSince[name] = now
+		since = now
+	}
+	return now.Sub(since) >= c.config.LoadBalancerGC.gracePeriod()
+}
+
+func (c *Cloud) deleteLoadBalancerGarbage(name, loadBalancerType string, orphanedSince map[string]time.Time, deleteFunc func() error) error {
+	if c.config.LoadBalancerGC.DryRun {
+		klog.Infof("Dry run: would delete orphaned load balancer %q", name)
+		loadBalancerGCDeletionsMetric.With(prometheus.Labels{"type": loadBalancerType, "result": "dry_run"}).Inc()
+		return nil
+	}
+
+	klog.Infof("Deleting orphaned load balancer %q", name)
+	if err := deleteFunc(); err != nil {
+		loadBalancerGCDeletionsMetric.With(prometheus.Labels{"type": loadBalancerType, "result": "error"}).Inc()
+		return fmt.Errorf("failed to delete orphaned load balancer %q: %w", name, err)
+	}
+	loadBalancerGCDeletionsMetric.With(prometheus.Labels{"type": loadBalancerType, "result": "deleted"}).Inc()
+	delete(orphanedSince, name)
+	return nil
+}
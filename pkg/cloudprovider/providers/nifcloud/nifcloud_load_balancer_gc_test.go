@@ -0,0 +1,258 @@
+package nifcloud_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("collectLoadBalancerGarbage", func() {
+	var ctrl *gomock.Controller
+	testClusterName := "testcluster"
+	inUseUID := types.UID("0123abcd-4567-89ef-0123-456789abcdef")
+	orphanUID := types.UID("fedcba98-7654-3210-fedc-ba9876543210")
+	inUseName := nifcloud.ExportLoadBalancerName(testClusterName, inUseUID)
+	orphanName := nifcloud.ExportLoadBalancerName(testClusterName, orphanUID)
+	inUseOwner := "k8s:testcluster/default/testlbsvc/" + string(inUseUID)
+	orphanOwner := "k8s:testcluster/default/deleted/" + string(orphanUID)
+	gracePeriod := time.Hour
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	newCloud := func(client nifcloud.CloudAPIClient, dryRun bool, owners map[string]string) *nifcloud.Cloud {
+		kubeClient := fake.NewSimpleClientset(&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "testlbsvc", Namespace: "default", UID: inUseUID},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		}, nifcloud.NewLoadBalancerRecords(owners))
+		cloud := &nifcloud.Cloud{}
+		cloud.SetClient(client)
+		cloud.SetKubeClient(kubeClient)
+		cloud.SetClusterName(testClusterName)
+		cloud.SetConfig(nifcloud.CloudConfig{
+			LoadBalancerGC: nifcloud.LoadBalancerGCConfig{
+				Enabled:     true,
+				GracePeriod: metav1.Duration{Duration: gracePeriod},
+				DryRun:      dryRun,
+			},
+		})
+		return cloud
+	}
+
+	Context("the l4 load balancer recorded as owned by a deleted service is found", func() {
+		It("delete it after the grace period", func() {
+			ctx := context.Background()
+			inUseLB := helper.NewTestL4LoadBalancer(inUseName)
+			orphanLB := helper.NewTestL4LoadBalancer(orphanName)
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeAllLoadBalancers(gomock.Any()).
+				Return(append(inUseLB, orphanLB...), nil).
+				Times(3)
+			c.EXPECT().
+				DescribeAllElasticLoadBalancers(gomock.Any()).
+				Return([]nifcloud.ElasticLoadBalancer{}, nil).
+				Times(3)
+			c.EXPECT().
+				DeleteLoadBalancer(gomock.Any(), &orphanLB[0]).
+				Return(nil).
+				Times(1)
+
+			cloud := newCloud(c, false, map[string]string{"lb." + inUseName: inUseOwner, "lb." + orphanName: orphanOwner})
+			orphanedSince := map[string]time.Time{}
+
+			err := nifcloud.ExportCollectLoadBalancerGarbage(cloud, ctx, orphanedSince, now)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(orphanedSince).Should(HaveKeyWithValue("lb."+orphanName, now))
+
+			err = nifcloud.ExportCollectLoadBalancerGarbage(cloud, ctx, orphanedSince, now.Add(gracePeriod/2))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			err = nifcloud.ExportCollectLoadBalancerGarbage(cloud, ctx, orphanedSince, now.Add(gracePeriod))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(orphanedSince).Should(BeEmpty())
+			Expect(nifcloud.LoadBalancerRecordOwners(ctx, cloud)).Should(Equal(map[string]string{"lb." + inUseName: inUseOwner}))
+		})
+	})
+
+	Context("the elastic load balancer recorded as owned by a deleted service is found", func() {
+		It("delete it and the security group rules from it", func() {
+			ctx := context.Background()
+			orphanELB := helper.NewTestElasticLoadBalancer(orphanName)
+			orphanELB[0].VIP = "203.0.113.1"
+			orphanELB[0].Description = orphanOwner
+			testSecurityGroups := []nifcloud.SecurityGroup{{GroupName: "testsg"}}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeAllLoadBalancers(gomock.Any()).
+				Return([]nifcloud.LoadBalancer{}, nil).
+				Times(1)
+			c.EXPECT().
+				DescribeAllElasticLoadBalancers(gomock.Any()).
+				Return(orphanELB, nil).
+				Times(1)
+			c.EXPECT().
+				DeleteElasticLoadBalancer(gomock.Any(), &orphanELB[0]).
+				Return(nil).
+				Times(1)
+			c.EXPECT().
+				DescribeSecurityGroupsByInstanceIDs(gomock.Any(), []string{"testinstance"}).
+				Return(testSecurityGroups, nil).
+				Times(1)
+			c.EXPECT().
+				RevokeSecurityGroupIngress(gomock.Any(), "testsg", gomock.Any()).
+				Return(nil).
+				MinTimes(1)
+			c.EXPECT().
+				WaitSecurityGroupApplied(gomock.Any(), "testsg").
+				Return(nil).
+				MinTimes(1)
+
+			cloud := newCloud(c, false, map[string]string{"elb." + orphanName: orphanOwner})
+			orphanedSince := map[string]time.Time{"elb." + orphanName: now.Add(-gracePeriod)}
+
+			err := nifcloud.ExportCollectLoadBalancerGarbage(cloud, ctx, orphanedSince, now)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(orphanedSince).Should(BeEmpty())
+			Expect(nifcloud.LoadBalancerRecordOwners(ctx, cloud)).Should(BeEmpty())
+		})
+	})
+
+	Context("the security group rules of the deleted elastic load balancer are left behind", func() {
+		It("revoke them", func() {
+			ctx := context.Background()
+			testRules := []nifcloud.SecurityGroupRule{{IpProtocol: "TCP", FromPort: 30000, ToPort: 30000, InOut: "IN", IpRanges: []string{"203.0.113.1"}}}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeAllLoadBalancers(gomock.Any()).
+				Return([]nifcloud.LoadBalancer{}, nil).
+				Times(1)
+			c.EXPECT().
+				DescribeAllElasticLoadBalancers(gomock.Any()).
+				Return([]nifcloud.ElasticLoadBalancer{}, nil).
+				Times(1)
+			c.EXPECT().
+				DescribeSecurityGroupsByInstanceIDs(gomock.Any(), []string{"testinstance"}).
+				Return([]nifcloud.SecurityGroup{{GroupName: "testsg"}}, nil).
+				Times(1)
+			c.EXPECT().
+				RevokeSecurityGroupIngress(gomock.Any(), "testsg", &testRules[0]).
+				Return(nil).
+				Times(1)
+			c.EXPECT().
+				WaitSecurityGroupApplied(gomock.Any(), "testsg").
+				Return(nil).
+				Times(1)
+
+			cloud := newCloud(c, false, map[string]string{"elb." + orphanName: orphanOwner})
+			err := nifcloud.ExportRecordSecurityGroupRulesToRevoke(cloud, ctx, orphanName, []string{"testinstance"}, testRules)
+			Expect(err).ShouldNot(HaveOccurred())
+			orphanedSince := map[string]time.Time{"elb." + orphanName: now.Add(-gracePeriod)}
+
+			err = nifcloud.ExportCollectLoadBalancerGarbage(cloud, ctx, orphanedSince, now)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(orphanedSince).Should(BeEmpty())
+			Expect(nifcloud.LoadBalancerRecordOwners(ctx, cloud)).Should(BeEmpty())
+		})
+	})
+
+	Context("the load balancers not recorded as owned by the cluster are found", func() {
+		It("does not delete them", func() {
+			ctx := context.Background()
+			// another cluster with the same cluster name creates the load balancer with the same prefix
+			unrecordedLB := helper.NewTestL4LoadBalancer(orphanName)
+			otherClusterLB := helper.NewTestL4LoadBalancer(nifcloud.ExportLoadBalancerName("othercluster", orphanUID))
+			markedELB := helper.NewTestElasticLoadBalancer(orphanName)
+			markedELB[0].Description = "k8s:othercluster/default/testlbsvc/" + string(orphanUID)
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeAllLoadBalancers(gomock.Any()).
+				Return([]nifcloud.LoadBalancer{unrecordedLB[0], otherClusterLB[0]}, nil).
+				Times(1)
+			c.EXPECT().
+				DescribeAllElasticLoadBalancers(gomock.Any()).
+				Return(markedELB, nil).
+				Times(1)
+
+			cloud := newCloud(c, false, map[string]string{
+				"lb." + otherClusterLB[0].Name: "k8s:othercluster/default/testlbsvc/" + string(orphanUID),
+				"elb." + orphanName:            orphanOwner,
+			})
+			orphanedSince := map[string]time.Time{}
+
+			err := nifcloud.ExportCollectLoadBalancerGarbage(cloud, ctx, orphanedSince, now)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(orphanedSince).Should(BeEmpty())
+		})
+	})
+
+	Context("dry run", func() {
+		It("does not delete the orphaned load balancer", func() {
+			ctx := context.Background()
+			orphanLB := helper.NewTestL4LoadBalancer(orphanName)
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeAllLoadBalancers(gomock.Any()).
+				Return(orphanLB, nil).
+				Times(1)
+			c.EXPECT().
+				DescribeAllElasticLoadBalancers(gomock.Any()).
+				Return([]nifcloud.ElasticLoadBalancer{}, nil).
+				Times(1)
+
+			cloud := newCloud(c, true, map[string]string{"lb." + orphanName: orphanOwner})
+			orphanedSince := map[string]time.Time{"lb." + orphanName: now.Add(-gracePeriod)}
+
+			err := nifcloud.ExportCollectLoadBalancerGarbage(cloud, ctx, orphanedSince, now)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(orphanedSince).Should(HaveKey("lb." + orphanName))
+			Expect(nifcloud.LoadBalancerRecordOwners(ctx, cloud)).Should(HaveKey("lb." + orphanName))
+		})
+	})
+
+	Context("the service of the orphaned load balancer is created again", func() {
+		It("forget the load balancer", func() {
+			ctx := context.Background()
+			inUseLB := helper.NewTestL4LoadBalancer(inUseName)
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeAllLoadBalancers(gomock.Any()).
+				Return(inUseLB, nil).
+				Times(1)
+			c.EXPECT().
+				DescribeAllElasticLoadBalancers(gomock.Any()).
+				Return([]nifcloud.ElasticLoadBalancer{}, nil).
+				Times(1)
+
+			cloud := newCloud(c, false, map[string]string{"lb." + inUseName: inUseOwner})
+			orphanedSince := map[string]time.Time{"lb." + inUseName: now.Add(-gracePeriod)}
+
+			err := nifcloud.ExportCollectLoadBalancerGarbage(cloud, ctx, orphanedSince, now)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(orphanedSince).Should(BeEmpty())
+		})
+	})
+})
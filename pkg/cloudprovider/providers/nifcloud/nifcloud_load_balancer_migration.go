@@ -0,0 +1,103 @@
+package nifcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// loadBalancerTypeOf returns the load balancer type in the annotations, 'lb' or 'elb'
+func loadBalancerTypeOf(annotations map[string]string) string {
+	if isElasticLoadBalancer(annotations) {
+		return "elb"
+	}
+	return "lb"
+}
+
+// isLoadBalancerTypeMigrating returns true if the load balancer of the previous type may remain
+func isLoadBalancerTypeMigrating(service *v1.Service) bool {
+	_, ok := service.Annotations[ServiceAnnotationLoadBalancerPreviousType]
+	return ok
+}
+
+// withPreviousLoadBalancerType returns a copy of the service whose load balancer type is the previous one.
+// There are only two types, so the previous type is the other one even if the type is changed back during the migration
+func withPreviousLoadBalancerType(service *v1.Service) *v1.Service {
+	previousType := "elb"
+	if isElasticLoadBalancer(service.Annotations) {
+		previousType = "lb"
+	}
+
+	svc := service.DeepCopy()
+	if svc.Annotations == nil {
+		svc.Annotations = map[string]string{}
+	}
+	svc.Annotations[ServiceAnnotationLoadBalancerType] = previousType
+	return svc
+}
+
+// migrateLoadBalancerType deletes the load balancer of the previous type after the type of the service is changed.
+// A service whose ingress is not the VIP of the load balancer is migrating if the load balancer of the other type exists.
+// The load balancer of the previous type and its security group rules are deleted in the same sync once the new load balancer is ready,
+// because the ingress is published only after the sync succeeds and nothing else triggers a later sync.
+// The previous type is recorded on the service before the deletion, so that a failed deletion is retried by the next sync
+func (c *Cloud) migrateLoadBalancerType(ctx context.Context, clusterName string, service *v1.Service, status *v1.LoadBalancerStatus) error {
+	if status == nil || len(status.Ingress) == 0 {
+		return nil
+	}
+
+	if !isLoadBalancerTypeMigrating(service) {
+		published := lo.ContainsBy(service.Status.LoadBalancer.Ingress, func(ingress v1.LoadBalancerIngress) bool { return ingress.IP == status.Ingress[0].IP })
+		if len(service.Status.LoadBalancer.Ingress) == 0 || published {
+			return nil
+		}
+		previous := withPreviousLoadBalancerType(service)
+		_, exists, err := c.getLoadBalancerOfType(ctx, clusterName, previous)
+		if err != nil {
+			return fmt.Errorf("failed to get the load balancer of the previous type: %w", err)
+		}
+		if !exists {
+			return nil
+		}
+
+		previousType := loadBalancerTypeOf(previous.Annotations)
+		klog.Infof("Migrating load balancer of service %s/%s from %s to %s", service.Namespace, service.Name, previousType, loadBalancerTypeOf(service.Annotations))
+		if err := c.patchServiceAnnotation(ctx, service, ServiceAnnotationLoadBalancerPreviousType, &previousType); err != nil {
+			return err
+		}
+	}
+
+	if err := c.ensureLoadBalancerOfTypeDeleted(ctx, clusterName, withPreviousLoadBalancerType(service)); err != nil {
+		return fmt.Errorf("failed to delete the load balancer of the previous type: %w", err)
+	}
+	klog.Infof("Migrated load balancer of service %s/%s to %s (%s)", service.Namespace, service.Name, loadBalancerTypeOf(service.Annotations), status.Ingress[0].IP)
+	return c.patchServiceAnnotation(ctx, service, ServiceAnnotationLoadBalancerPreviousType, nil)
+}
+
+// patchServiceAnnotation sets the annotation of the service. The annotation is removed if value is nil
+func (c *Cloud) patchServiceAnnotation(ctx context.Context, service *v1.Service, key string, value *string) error {
+	if c.config.DryRun {
+		klog.Infof("Dry run: would patch annotation %s of service %s/%s", key, service.Namespace, service.Name)
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]*string{key: value},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal annotation patch of service %s/%s: %w", service.Namespace, service.Name, err)
+	}
+
+	if _, err := c.kubeClient.CoreV1().Services(service.Namespace).Patch(ctx, service.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch annotation of service %s/%s: %w", service.Namespace, service.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package nifcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// loadBalancerOwnerPrefix is the prefix of the ownership marker in the description of load balancers
+	loadBalancerOwnerPrefix = "k8s:"
+
+	// the config map recording the owners of the load balancers created by the cluster
+	loadBalancerRecordsNamespace     = metav1.NamespaceSystem
+	loadBalancerRecordsConfigMapName = "nifcloud-load-balancers"
+)
+
+// loadBalancerOwner returns the ownership marker of the load balancers of the service,
+// that is the cluster name, the namespace/name and the UID of the service
+func loadBalancerOwner(clusterName string, service *v1.Service) string {
+	return fmt.Sprintf("%s%s/%s/%s/%s", loadBalancerOwnerPrefix, clusterName, service.Namespace, service.Name, service.UID)
+}
+
+// parseLoadBalancerOwner returns the cluster name and the service UID in the ownership marker.
+// It returns false if the description is not a marker
+func parseLoadBalancerOwner(description string) (string, types.UID, bool) {
+	owner, ok := strings.CutPrefix(description, loadBalancerOwnerPrefix)
+	if !ok {
+		return "", "", false
+	}
+	// the cluster name is followed by the namespace, the name and the UID
+	parts := strings.Split(owner, "/")
+	if len(parts) < 4 {
+		return "", "", false
+	}
+	return strings.Join(parts[:len(parts)-3], "/"), types.UID(parts[len(parts)-1]), true
+}
+
+// isLoadBalancerAdopted returns true if the service adopts the load balancer owned by another
+func isLoadBalancerAdopted(service *v1.Service) bool {
+	return service.Annotations[ServiceAnnotationLoadBalancerAdopt] == "true"
+}
+
+// verifyLoadBalancerOwner returns error if any of the descriptions has the ownership marker other than owner.
+// The descriptions without the marker are accepted, and the owner of such load balancers is
+// verified by checkLoadBalancerOwner with the record in the cluster
+func verifyLoadBalancerOwner(loadBalancerName, owner string, descriptions []string, adopt bool) error {
+	for _, description := range descriptions {
+		if !strings.HasPrefix(description, loadBalancerOwnerPrefix) || description == owner {
+			continue
+		}
+		if adopt {
+			klog.Warningf("Adopting load balancer %q owned by %q", loadBalancerName, strings.TrimPrefix(description, loadBalancerOwnerPrefix))
+			return nil
+		}
+		return fmt.Errorf(
+			"load balancer %q is owned by %q, not by %q: set annotation %s=true to adopt it",
+			loadBalancerName, strings.TrimPrefix(description, loadBalancerOwnerPrefix), strings.TrimPrefix(owner, loadBalancerOwnerPrefix), ServiceAnnotationLoadBalancerAdopt,
+		)
+	}
+	return nil
+}
+
+// checkLoadBalancerOwner returns error unless the load balancer is owned by the service, and records the owner in the cluster.
+// The owner is the marker in the descriptions, or the record in the cluster if there is no marker
+// (l4 load balancers have no description writable by API).
+// A load balancer with neither is the one created by the older versions only if its VIP is the ingress of the service,
+// otherwise its owner is unknown. The load balancers owned by another or unknown are refused unless the service adopts them
+func (c *Cloud) checkLoadBalancerOwner(ctx context.Context, clusterName string, service *v1.Service, loadBalancerType, loadBalancerName, vip string, descriptions []string) error {
+	owner := loadBalancerOwner(clusterName, service)
+
+	// the marker in the descriptions takes precedence over the record
+	markers := lo.Filter(descriptions, func(description string, _ int) bool { return strings.HasPrefix(description, loadBalancerOwnerPrefix) })
+	current, foreign := lo.Find(markers, func(marker string) bool { return marker != owner })
+	if !foreign && len(markers) > 0 {
+		current = owner
+	} else if !foreign {
+		records, err := c.loadBalancerRecords(ctx)
+		if err != nil {
+			return err
+		}
+		current = records[loadBalancerRecordKey(loadBalancerType, loadBalancerName)].Owner
+	}
+
+	switch {
+	case current == owner:
+	case current == "" && vip != "" && lo.ContainsBy(service.Status.LoadBalancer.Ingress, func(ingress v1.LoadBalancerIngress) bool { return ingress.IP == vip }):
+		klog.Infof("Recording load balancer %q (%s) as owned by service %s/%s which already uses it", loadBalancerName, vip, service.Namespace, service.Name)
+	case isLoadBalancerAdopted(service):
+		klog.Warningf("Adopting load balancer %q owned by %q", loadBalancerName, lo.Ternary(current == "", "unknown", strings.TrimPrefix(current, loadBalancerOwnerPrefix)))
+	case current == "":
+		return fmt.Errorf(
+			"load balancer %q (%s) is not recorded as owned by %q: set annotation %s=true to adopt it",
+			loadBalancerName, vip, strings.TrimPrefix(owner, loadBalancerOwnerPrefix), ServiceAnnotationLoadBalancerAdopt,
+		)
+	default:
+		return fmt.Errorf(
+			"load balancer %q is owned by %q, not by %q: set annotation %s=true to adopt it",
+			loadBalancerName, strings.TrimPrefix(current, loadBalancerOwnerPrefix), strings.TrimPrefix(owner, loadBalancerOwnerPrefix), ServiceAnnotationLoadBalancerAdopt,
+		)
+	}
+
+	return c.recordLoadBalancerOwner(ctx, loadBalancerType, loadBalancerName, owner)
+}
+
+// verifyL4LoadBalancerOwner verifies the l4 load balancers are owned by the service
+func (c *Cloud) verifyL4LoadBalancerOwner(ctx context.Context, clusterName string, service *v1.Service, loadBalancerName string, loadBalancers []LoadBalancer) error {
+	descriptions := lo.Map(loadBalancers, func(lb LoadBalancer, _ int) string { return lb.Description })
+	vip := ""
+	if len(loadBalancers) > 0 {
+		vip = loadBalancers[0].VIP
+	}
+	return c.checkLoadBalancerOwner(ctx, clusterName, service, "lb", loadBalancerName, vip, descriptions)
+}
+
+// verifyElasticLoadBalancerOwner verifies the elastic load balancers are owned by the service
+func (c *Cloud) verifyElasticLoadBalancerOwner(ctx context.Context, clusterName string, service *v1.Service, loadBalancerName string, loadBalancers []ElasticLoadBalancer) error {
+	descriptions := lo.Map(loadBalancers, func(lb ElasticLoadBalancer, _ int) string { return lb.Description })
+	vip := ""
+	if len(loadBalancers) > 0 {
+		vip = loadBalancers[0].VIP
+	}
+	return c.checkLoadBalancerOwner(ctx, clusterName, service, "elb", loadBalancerName, vip, descriptions)
+}
+
+// loadBalancerRecord is the record of a load balancer created by the cluster
+type loadBalancerRecord struct {
+	// Owner is the ownership marker of the load balancer
+	Owner string `json:"owner"`
+	// SecurityGroupRules are the rules allowing the traffic from the elastic load balancer being deleted,
+	// which are revoked from the security groups of InstanceIDs by the garbage collector if left behind
+	SecurityGroupRules []SecurityGroupRule `json:"securityGroupRules,omitempty"`
+	InstanceIDs        []string            `json:"instanceIDs,omitempty"`
+}
+
+func loadBalancerRecordKey(loadBalancerType, loadBalancerName string) string {
+	return loadBalancerType + "." + loadBalancerName
+}
+
+// parseLoadBalancerRecordKey returns the type and the name of the load balancer of the record key
+func parseLoadBalancerRecordKey(key string) (string, string, bool) {
+	return strings.Cut(key, ".")
+}
+
+// loadBalancerRecords returns the records of the load balancers keyed by the type and the name
+func (c *Cloud) loadBalancerRecords(ctx context.Context) (map[string]loadBalancerRecord, error) {
+	if c.kubeClient == nil {
+		return nil, fmt.Errorf("could not get load balancer records: kubernetes client is not initialized")
+	}
+
+	configMap, err := c.kubeClient.CoreV1().ConfigMaps(loadBalancerRecordsNamespace).Get(ctx, loadBalancerRecordsConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return map[string]loadBalancerRecord{}, nil
+		}
+		return nil, fmt.Errorf("failed to get load balancer records: %w", err)
+	}
+
+	return decodeLoadBalancerRecords(configMap)
+}
+
+func decodeLoadBalancerRecords(configMap *v1.ConfigMap) (map[string]loadBalancerRecord, error) {
+	records := map[string]loadBalancerRecord{}
+	for key, value := range configMap.Data {
+		var record loadBalancerRecord
+		if err := json.Unmarshal([]byte(value), &record); err != nil {
+			return nil, fmt.Errorf("load balancer record %q is invalid: %w", key, err)
+		}
+		records[key] = record
+	}
+	return records, nil
+}
+
+// updateLoadBalancerRecords updates the records with the function, which returns false if nothing is changed
+func (c *Cloud) updateLoadBalancerRecords(ctx context.Context, update func(records map[string]loadBalancerRecord) bool) error {
+	if c.kubeClient == nil {
+		return fmt.Errorf("could not update load balancer records: kubernetes client is not initialized")
+	}
+	configMaps := c.kubeClient.CoreV1().ConfigMaps(loadBalancerRecordsNamespace)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := configMaps.Get(ctx, loadBalancerRecordsConfigMapName, metav1.GetOptions{})
+		exists := err == nil
+		if apierrors.IsNotFound(err) {
+			configMap = &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: loadBalancerRecordsConfigMapName, Namespace: loadBalancerRecordsNamespace}}
+		} else if err != nil {
+			return fmt.Errorf("failed to get load balancer records: %w", err)
+		}
+
+		records, err := decodeLoadBalancerRecords(configMap)
+		if err != nil {
+			return err
+		}
+		if !update(records) {
+			return nil
+		}
+		if c.config.DryRun {
+			klog.Infof("Dry run: would update load balancer records")
+			return nil
+		}
+
+		configMap.Data = map[string]string{}
+		for key, record := range records {
+			value, err := json.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("failed to marshal load balancer record %q: %w", key, err)
+			}
+			configMap.Data[key] = string(value)
+		}
+
+		if !exists {
+			_, err = configMaps.Create(ctx, configMap, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				// created concurrently, retry with the latest one
+				return apierrors.NewConflict(v1.Resource("configmaps"), loadBalancerRecordsConfigMapName, err)
+			}
+		} else {
+			_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update load balancer records: %w", err)
+		}
+		return nil
+	})
+}
+
+// recordLoadBalancerOwner records the owner of the load balancer
+func (c *Cloud) recordLoadBalancerOwner(ctx context.Context, loadBalancerType, loadBalancerName, owner string) error {
+	return c.updateLoadBalancerRecords(ctx, func(records map[string]loadBalancerRecord) bool {
+		key := loadBalancerRecordKey(loadBalancerType, loadBalancerName)
+		record := records[key]
+		if record.Owner == owner {
+			return false
+		}
+		record.Owner = owner
+		records[key] = record
+		return true
+	})
+}
+
+// forgetLoadBalancer removes the record of the deleted load balancer
+func (c *Cloud) forgetLoadBalancer(ctx context.Context, loadBalancerType, loadBalancerName string) error {
+	return c.updateLoadBalancerRecords(ctx, func(records map[string]loadBalancerRecord) bool {
+		key := loadBalancerRecordKey(loadBalancerType, loadBalancerName)
+		if _, ok := records[key]; !ok {
+			return false
+		}
+		delete(records, key)
+		return true
+	})
+}
+
+// recordSecurityGroupRulesToRevoke records the security group rules to be revoked after the elastic load balancer is deleted,
+// so that the garbage collector revokes them even if the load balancer is deleted but the rules are not
+func (c *Cloud) recordSecurityGroupRulesToRevoke(ctx context.Context, loadBalancerName string, instanceIDs []string, securityGroupRules []SecurityGroupRule) error {
+	return c.updateLoadBalancerRecords(ctx, func(records map[string]loadBalancerRecord) bool {
+		key := loadBalancerRecordKey("elb", loadBalancerName)
+		record := records[key]
+		record.SecurityGroupRules = securityGroupRules
+		record.InstanceIDs = instanceIDs
+		records[key] = record
+		return true
+	})
+}
@@ -0,0 +1,310 @@
+package nifcloud_test
+
+import (
+	"context"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("loadBalancerOwner", func() {
+	It("return the cluster name, the namespace/name and the UID of the service", func() {
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testlbsvc",
+				Namespace: "default",
+				UID:       "testuid",
+			},
+		}
+		Expect(nifcloud.ExportLoadBalancerOwner("testcluster", service)).Should(Equal("k8s:testcluster/default/testlbsvc/testuid"))
+	})
+})
+
+var _ = DescribeTable("verifyLoadBalancerOwner",
+	func(descriptions []string, adopt bool, expectErr bool) {
+		err := nifcloud.ExportVerifyLoadBalancerOwner("testlb", "k8s:testcluster/default/testlbsvc/testuid", descriptions, adopt)
+		if expectErr {
+			Expect(err).Should(HaveOccurred())
+		} else {
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+	},
+	Entry("the load balancer has no marker", []string{"", ""}, false, false),
+	Entry("the load balancer has a memo which is not a marker", []string{"memo"}, false, false),
+	Entry("the load balancer is owned by the service", []string{"k8s:testcluster/default/testlbsvc/testuid"}, false, false),
+	Entry("the load balancer is owned by another cluster", []string{"k8s:othercluster/default/testlbsvc/testuid"}, false, true),
+	Entry("a port of the load balancer is owned by another service", []string{"k8s:testcluster/default/testlbsvc/testuid", "k8s:testcluster/default/other/otheruid"}, false, true),
+	Entry("the load balancer owned by another cluster is adopted", []string{"k8s:othercluster/default/testlbsvc/testuid"}, true, false),
+)
+
+var _ = Describe("load balancer ownership", func() {
+	var ctrl *gomock.Controller
+	var clusterName string = "testcluster"
+	var loadBalancerName string
+	var testService *corev1.Service
+	var foreignOwner string = "k8s:othercluster/default/testlbsvc/otheruid"
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		testService = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "testlbsvc",
+				Namespace:   "default",
+				UID:         "testuid",
+				Annotations: map[string]string{},
+			},
+		}
+		loadBalancerName = nifcloud.ExportLoadBalancerName(clusterName, testService.UID)
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("the elastic load balancer is owned by another", func() {
+		It("getElasticLoadBalancer return error", func() {
+			testELB := helper.NewTestElasticLoadBalancer(loadBalancerName)
+			testELB[0].Description = foreignOwner
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(testELB, nil).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+
+			status, exists, err := nifcloud.ExportGetElasticLoadBalancer(cloud, context.Background(), clusterName, testService)
+			Expect(err).Should(HaveOccurred())
+			Expect(exists).Should(BeFalse())
+			Expect(status).Should(BeNil())
+		})
+
+		It("ensureElasticLoadBalancer refuse to modify it", func() {
+			testDesire := helper.NewTestElasticLoadBalancerWithTwoPort(loadBalancerName)
+			for i := range testDesire {
+				testDesire[i].Description = nifcloud.ExportLoadBalancerOwner(clusterName, testService)
+			}
+			testCurrent := helper.NewTestElasticLoadBalancer(loadBalancerName)
+			testCurrent[0].Description = foreignOwner
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(testCurrent, nil).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+
+			status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, context.Background(), testService, loadBalancerName, testDesire)
+			Expect(err).Should(HaveOccurred())
+			Expect(status).Should(BeNil())
+		})
+
+		It("ensureElasticLoadBalancer modify it if adopted", func() {
+			testDesire := helper.NewTestElasticLoadBalancer(loadBalancerName)
+			testDesire[0].Description = nifcloud.ExportLoadBalancerOwner(clusterName, testService)
+			testCurrent := helper.NewTestElasticLoadBalancer(loadBalancerName)
+			testCurrent[0].VIP = "203.0.113.1"
+			testCurrent[0].Description = foreignOwner
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(testCurrent, nil).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+
+			testService.Annotations[nifcloud.ServiceAnnotationLoadBalancerAdopt] = "true"
+			status, err := nifcloud.ExportEnsureElasticLoadBalancer(cloud, context.Background(), testService, loadBalancerName, testDesire)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(status.Ingress[0].IP).Should(Equal("203.0.113.1"))
+		})
+
+		It("ensureElasticLoadBalancerDeleted refuse to delete it", func() {
+			testELB := helper.NewTestElasticLoadBalancer(loadBalancerName)
+			testELB[0].Description = foreignOwner
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(testELB, nil).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+
+			err := nifcloud.ExportEnsureElasticLoadBalancerDeleted(cloud, context.Background(), clusterName, testService)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("the l4 load balancer is recorded as owned by another", func() {
+		var kubeClient *fake.Clientset
+
+		BeforeEach(func() {
+			kubeClient = fake.NewSimpleClientset(nifcloud.NewLoadBalancerRecords(map[string]string{
+				"lb." + loadBalancerName: foreignOwner,
+			}))
+		})
+
+		It("getL4LoadBalancer return error", func() {
+			testLB := helper.NewTestL4LoadBalancer(loadBalancerName)
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(testLB, nil).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetKubeClient(kubeClient)
+
+			status, exists, err := nifcloud.ExportGetL4LoadBalancer(cloud, context.Background(), clusterName, testService)
+			Expect(err).Should(HaveOccurred())
+			Expect(exists).Should(BeFalse())
+			Expect(status).Should(BeNil())
+		})
+
+		It("ensureL4LoadBalancerDeleted refuse to delete it", func() {
+			testLB := helper.NewTestL4LoadBalancer(loadBalancerName)
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(testLB, nil).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetKubeClient(kubeClient)
+
+			err := nifcloud.ExportEnsureL4LoadBalancerDeleted(cloud, context.Background(), clusterName, testService)
+			Expect(err).Should(HaveOccurred())
+		})
+
+		It("ensureL4LoadBalancerDeleted delete it if adopted", func() {
+			testService.Annotations[nifcloud.ServiceAnnotationLoadBalancerAdopt] = "true"
+			testLB := helper.NewTestL4LoadBalancer(loadBalancerName)
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(testLB, nil).
+				Times(1)
+			c.EXPECT().
+				DeleteLoadBalancer(gomock.Any(), &testLB[0]).
+				Return(nil).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetKubeClient(kubeClient)
+
+			err := nifcloud.ExportEnsureL4LoadBalancerDeleted(cloud, context.Background(), clusterName, testService)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(nifcloud.LoadBalancerRecordOwners(context.Background(), cloud)).Should(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("checkLoadBalancerOwner", func() {
+	var clusterName string = "testcluster"
+	var loadBalancerName string = "testlb"
+	var vip string = "203.0.113.1"
+	var testService *corev1.Service
+	var owner string
+
+	BeforeEach(func() {
+		testService = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "testlbsvc",
+				Namespace:   "default",
+				UID:         "testuid",
+				Annotations: map[string]string{},
+			},
+		}
+		owner = nifcloud.ExportLoadBalancerOwner(clusterName, testService)
+	})
+
+	newCloud := func(records map[string]string) *nifcloud.Cloud {
+		cloud := &nifcloud.Cloud{}
+		cloud.SetKubeClient(fake.NewSimpleClientset(nifcloud.NewLoadBalancerRecords(records)))
+		return cloud
+	}
+
+	It("accept and record the load balancer marked as owned by the service", func() {
+		ctx := context.Background()
+		cloud := newCloud(map[string]string{})
+
+		err := nifcloud.ExportCheckLoadBalancerOwner(cloud, ctx, clusterName, testService, "elb", loadBalancerName, vip, []string{owner})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(nifcloud.LoadBalancerRecordOwners(ctx, cloud)).Should(Equal(map[string]string{"elb.testlb": owner}))
+	})
+
+	It("accept the load balancer recorded as owned by the service", func() {
+		ctx := context.Background()
+		cloud := newCloud(map[string]string{"lb.testlb": owner})
+
+		err := nifcloud.ExportCheckLoadBalancerOwner(cloud, ctx, clusterName, testService, "lb", loadBalancerName, vip, []string{""})
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("refuse the load balancer recorded as owned by another service", func() {
+		ctx := context.Background()
+		cloud := newCloud(map[string]string{"lb.testlb": "k8s:testcluster/default/other/otheruid"})
+
+		err := nifcloud.ExportCheckLoadBalancerOwner(cloud, ctx, clusterName, testService, "lb", loadBalancerName, vip, []string{""})
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("refuse the load balancer whose owner is unknown", func() {
+		ctx := context.Background()
+		cloud := newCloud(map[string]string{"elb.testlb": owner})
+
+		err := nifcloud.ExportCheckLoadBalancerOwner(cloud, ctx, clusterName, testService, "lb", loadBalancerName, vip, []string{""})
+		Expect(err).Should(HaveOccurred())
+		Expect(nifcloud.LoadBalancerRecordOwners(ctx, cloud)).ShouldNot(HaveKey("lb.testlb"))
+	})
+
+	It("accept and record the unknown load balancer whose VIP is the ingress of the service", func() {
+		ctx := context.Background()
+		testService.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: vip}}
+		cloud := newCloud(map[string]string{})
+
+		err := nifcloud.ExportCheckLoadBalancerOwner(cloud, ctx, clusterName, testService, "lb", loadBalancerName, vip, []string{""})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(nifcloud.LoadBalancerRecordOwners(ctx, cloud)).Should(Equal(map[string]string{"lb.testlb": owner}))
+	})
+
+	It("accept and record the unknown load balancer if adopted", func() {
+		ctx := context.Background()
+		testService.Annotations[nifcloud.ServiceAnnotationLoadBalancerAdopt] = "true"
+		cloud := newCloud(map[string]string{})
+
+		err := nifcloud.ExportCheckLoadBalancerOwner(cloud, ctx, clusterName, testService, "lb", loadBalancerName, vip, []string{""})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(nifcloud.LoadBalancerRecordOwners(ctx, cloud)).Should(Equal(map[string]string{"lb.testlb": owner}))
+	})
+
+	It("refuse the load balancer marked as owned by another even if it is recorded as owned by the service", func() {
+		ctx := context.Background()
+		cloud := newCloud(map[string]string{"elb.testlb": owner})
+
+		err := nifcloud.ExportCheckLoadBalancerOwner(cloud, ctx, clusterName, testService, "elb", loadBalancerName, vip, []string{"k8s:othercluster/default/testlbsvc/testuid"})
+		Expect(err).Should(HaveOccurred())
+	})
+})
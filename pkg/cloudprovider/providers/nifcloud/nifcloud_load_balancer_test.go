@@ -3,7 +3,6 @@ package nifcloud_test
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
 	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
@@ -16,6 +15,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 var _ = Describe("EnsureLoadBalancer", func() {
@@ -27,7 +27,7 @@ var _ = Describe("EnsureLoadBalancer", func() {
 	BeforeEach(func() {
 		ctrl = gomock.NewController(GinkgoT())
 		loadBalancerUID = types.UID(uuid.NewString())
-		loadBalancerName = strings.Replace(string(loadBalancerUID), "-", "", -1)[:nifcloud.ExportMaxLoadBalancerNameLength]
+		loadBalancerName = nifcloud.ExportLoadBalancerName("testcluster", loadBalancerUID)
 	})
 
 	AfterEach(func() {
@@ -70,6 +70,7 @@ var _ = Describe("EnsureLoadBalancer", func() {
 				},
 			}
 			testDesire := helper.NewTestL4LoadBalancer(loadBalancerName)
+			testDesire[0].Description = nifcloud.ExportLoadBalancerOwner(testClusterName, &testService)
 			testInstances := []nifcloud.Instance{*helper.NewTestInstance()}
 			testInstanceID := "testinstance"
 
@@ -86,10 +87,15 @@ var _ = Describe("EnsureLoadBalancer", func() {
 				DescribeInstancesByInstanceID(gomock.Any(), gomock.Eq([]string{testInstanceID})).
 				Return(testInstances, nil).
 				Times(1)
-			notFoundErr := helper.NewMockAPIError(nifcloud.ExportErrorCodeLoadBalancerNotFound)
+			notFoundErr := helper.NewMockAPIError(helper.ErrorCodeLoadBalancerNotFound)
+			// the name is checked before ensured
 			c.EXPECT().
 				DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
 				Return([]nifcloud.LoadBalancer{}, notFoundErr).
+				Times(2)
+			c.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), gomock.Eq(nifcloud.ExportLegacyLoadBalancerName(&testService))).
+				Return([]nifcloud.LoadBalancer{}, notFoundErr).
 				Times(1)
 			c.EXPECT().
 				CreateLoadBalancer(gomock.Any(), gomock.Eq(&testDesire[0])).
@@ -99,10 +105,12 @@ var _ = Describe("EnsureLoadBalancer", func() {
 			cloud := &nifcloud.Cloud{}
 			cloud.SetClient(c)
 			cloud.SetRegion(region)
+			cloud.SetKubeClient(fake.NewSimpleClientset())
 
 			status, err := cloud.EnsureLoadBalancer(ctx, testClusterName, &testService, []*corev1.Node{testNode})
 			Expect(err).ShouldNot(HaveOccurred())
 			Expect(*status).Should(Equal(*expectedStatus))
+			Expect(nifcloud.LoadBalancerRecordOwners(ctx, cloud)).Should(Equal(map[string]string{"lb." + loadBalancerName: testDesire[0].Description}))
 		})
 	})
 
@@ -295,6 +303,246 @@ var _ = Describe("EnsureLoadBalancer", func() {
 	})
 })
 
+var _ = Describe("GetLoadBalancerName", func() {
+	It("return the name within the max length", func() {
+		name := nifcloud.ExportLoadBalancerName("testcluster", types.UID(uuid.NewString()))
+		Expect(name).Should(HaveLen(nifcloud.ExportMaxLoadBalancerNameLength))
+		Expect(name).Should(MatchRegexp("^[0-9a-f]+$"))
+	})
+
+	It("return the different names for the same service in the different clusters", func() {
+		uid := types.UID(uuid.NewString())
+		Expect(nifcloud.ExportLoadBalancerName("testcluster1", uid)).ShouldNot(Equal(nifcloud.ExportLoadBalancerName("testcluster2", uid)))
+	})
+
+	It("return the same prefix for the services in the same cluster", func() {
+		name1 := nifcloud.ExportLoadBalancerName("testcluster", types.UID(uuid.NewString()))
+		name2 := nifcloud.ExportLoadBalancerName("testcluster", types.UID(uuid.NewString()))
+		Expect(name1[:5]).Should(Equal(name2[:5]))
+		Expect(name1).ShouldNot(Equal(name2))
+	})
+})
+
+var _ = Describe("legacyLoadBalancerName", func() {
+	It("return the uid without dashes", func() {
+		service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{UID: "0123abcd-4567-89ef-0123-456789abcdef"}}
+		Expect(nifcloud.ExportLegacyLoadBalancerName(service)).Should(Equal("0123abcd456789e"))
+	})
+
+	It("return empty if the uid is too short", func() {
+		service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{UID: "short-uid"}}
+		Expect(nifcloud.ExportLegacyLoadBalancerName(service)).Should(BeEmpty())
+	})
+})
+
+var _ = Describe("migrateLoadBalancerName", func() {
+	var ctrl *gomock.Controller
+	var clusterName string = "testcluster"
+	var testIPAddress string = "203.0.113.1"
+	var testService *corev1.Service
+	var loadBalancerName string
+	var legacyName string
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		testService = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testlbsvc",
+				Namespace: "default",
+				UID:       types.UID(uuid.NewString()),
+				Annotations: map[string]string{
+					nifcloud.ServiceAnnotationLoadBalancerType: "lb",
+				},
+			},
+			Status: corev1.ServiceStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{
+					Ingress: []corev1.LoadBalancerIngress{{IP: testIPAddress}},
+				},
+			},
+		}
+		loadBalancerName = nifcloud.ExportLoadBalancerName(clusterName, testService.UID)
+		legacyName = nifcloud.ExportLegacyLoadBalancerName(testService)
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("the load balancer does not exist", func() {
+		It("does nothing", func() {
+			testService.Status.LoadBalancer.Ingress = nil
+			notFoundErr := helper.NewMockAPIError(helper.ErrorCodeLoadBalancerNotFound)
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(nil, notFoundErr).
+				Times(1)
+			c.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), gomock.Eq(legacyName)).
+				Return(nil, notFoundErr).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetKubeClient(fake.NewSimpleClientset())
+
+			err := nifcloud.ExportMigrateLoadBalancerName(cloud, context.Background(), clusterName, testService)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("the service has no ingress", func() {
+		var c *nifcloud.MockCloudAPIClient
+
+		BeforeEach(func() {
+			testService.Status.LoadBalancer.Ingress = nil
+			testLB := helper.NewTestL4LoadBalancer(loadBalancerName)
+			testLB[0].VIP = testIPAddress
+
+			c = nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(testLB, nil).
+				Times(1)
+		})
+
+		It("return error if the load balancer with the name is owned by another service", func() {
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetKubeClient(fake.NewSimpleClientset(nifcloud.NewLoadBalancerRecords(map[string]string{
+				"lb." + loadBalancerName: "k8s:testcluster/default/anothersvc/anotheruid",
+			})))
+
+			err := nifcloud.ExportMigrateLoadBalancerName(cloud, context.Background(), clusterName, testService)
+			Expect(err).Should(HaveOccurred())
+			Expect(err.Error()).Should(ContainSubstring("anothersvc"))
+		})
+
+		It("return error if the owner of the load balancer with the name is unknown", func() {
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetKubeClient(fake.NewSimpleClientset())
+
+			err := nifcloud.ExportMigrateLoadBalancerName(cloud, context.Background(), clusterName, testService)
+			Expect(err).Should(HaveOccurred())
+			Expect(nifcloud.LoadBalancerRecordOwners(context.Background(), cloud)).Should(BeEmpty())
+		})
+
+		It("accepts the load balancer recorded as owned by the service", func() {
+			owner := nifcloud.ExportLoadBalancerOwner(clusterName, testService)
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetKubeClient(fake.NewSimpleClientset(nifcloud.NewLoadBalancerRecords(map[string]string{
+				"lb." + loadBalancerName: owner,
+			})))
+
+			err := nifcloud.ExportMigrateLoadBalancerName(cloud, context.Background(), clusterName, testService)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("the load balancer is created under the legacy name", func() {
+		It("rename the load balancer", func() {
+			notFoundErr := helper.NewMockAPIError(helper.ErrorCodeLoadBalancerNotFound)
+			testLB := helper.NewTestL4LoadBalancer(legacyName)
+			testLB[0].VIP = testIPAddress
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(nil, notFoundErr).
+				Times(1)
+			c.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), gomock.Eq(legacyName)).
+				Return(testLB, nil).
+				Times(1)
+			c.EXPECT().
+				UpdateLoadBalancerName(gomock.Any(), legacyName, loadBalancerName).
+				Return(nil).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetKubeClient(fake.NewSimpleClientset())
+
+			err := nifcloud.ExportMigrateLoadBalancerName(cloud, context.Background(), clusterName, testService)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(nifcloud.LoadBalancerRecordOwners(context.Background(), cloud)).Should(Equal(map[string]string{
+				"lb." + loadBalancerName: nifcloud.ExportLoadBalancerOwner(clusterName, testService),
+			}))
+		})
+	})
+
+	Context("the elastic load balancer is created under the legacy name", func() {
+		It("rename the elastic load balancer", func() {
+			testService.Annotations[nifcloud.ServiceAnnotationLoadBalancerType] = "elb"
+			notFoundErr := helper.NewMockAPIError(helper.ErrorCodeElasticLoadBalancerNotFound)
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(nil, notFoundErr).
+				Times(1)
+			c.EXPECT().
+				DescribeElasticLoadBalancers(gomock.Any(), gomock.Eq(legacyName)).
+				Return([]nifcloud.ElasticLoadBalancer{{Name: legacyName, VIP: testIPAddress}}, nil).
+				Times(1)
+			c.EXPECT().
+				UpdateElasticLoadBalancerName(gomock.Any(), legacyName, loadBalancerName).
+				Return(nil).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetKubeClient(fake.NewSimpleClientset())
+
+			err := nifcloud.ExportMigrateLoadBalancerName(cloud, context.Background(), clusterName, testService)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("the load balancer is already named by the current scheme", func() {
+		It("does nothing", func() {
+			testLB := helper.NewTestL4LoadBalancer(loadBalancerName)
+			testLB[0].VIP = testIPAddress
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(testLB, nil).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetKubeClient(fake.NewSimpleClientset())
+
+			err := nifcloud.ExportMigrateLoadBalancerName(cloud, context.Background(), clusterName, testService)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("the load balancer with the name has another VIP", func() {
+		It("return error without renaming", func() {
+			testLB := helper.NewTestL4LoadBalancer(loadBalancerName)
+			testLB[0].VIP = "203.0.113.99"
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeLoadBalancers(gomock.Any(), gomock.Eq(loadBalancerName)).
+				Return(testLB, nil).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetKubeClient(fake.NewSimpleClientset())
+
+			err := nifcloud.ExportMigrateLoadBalancerName(cloud, context.Background(), clusterName, testService)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
+
 var _ = Describe("validateLoadBalancerAnnotations", func() {
 	var testAnnotations map[string]string
 
@@ -345,6 +593,8 @@ var _ = Describe("validateLoadBalancerAnnotations", func() {
 			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerNetworkVolume, "2000"),
 			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerPolicyType, "standard"),
 			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerPolicyType, "ats"),
+			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerFilterType, "1"),
+			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerFilterType, "2"),
 		)
 
 		DescribeTable("given invalid annotations",
@@ -378,6 +628,7 @@ var _ = Describe("validateLoadBalancerAnnotations", func() {
 			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerNetworkVolume, "2100"),
 			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerNetworkVolume, "notNumber"),
 			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerPolicyType, "undefined"),
+			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerFilterType, "3"),
 			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerNetworkInterface1, "any"),
 			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerNetworkInterface2, "any"),
 			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerNetworkInterface1IPAddress, "any"),
@@ -452,6 +703,7 @@ var _ = Describe("validateLoadBalancerAnnotations", func() {
 			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerNetworkVolume, "600"),
 			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerNetworkVolume, "notNumber"),
 			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerPolicyType, "any"),
+			Entry(nil, nifcloud.ServiceAnnotationLoadBalancerFilterType, "1"),
 		)
 
 		Context("annotations has common global network or common private network", func() {
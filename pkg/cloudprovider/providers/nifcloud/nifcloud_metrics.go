@@ -1,8 +1,13 @@
 package nifcloud
 
 import (
+	"context"
 	"sync"
+	"time"
 
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
 	"github.com/prometheus/client_golang/prometheus"
 	"k8s.io/component-base/metrics"
 	"k8s.io/component-base/metrics/legacyregistry"
@@ -23,22 +28,90 @@ var (
 			Help:           "NIFCLOUD API errors",
 			StabilityLevel: metrics.ALPHA,
 		},
-		[]string{"request"})
+		[]string{"request", "code", "category"})
+
+	nifcloudAPIRetryMetric = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Name:           "cloudprovider_nifcloud_api_request_retries",
+			Help:           "Retries of NIFCLOUD API calls",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"request", "class"})
+
+	nifcloudAPIRateLimiterMetric = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Name:           "cloudprovider_nifcloud_api_rate_limiter_wait_duration_seconds",
+			Help:           "Time spent waiting on the client-side rate limiter before NIFCLOUD API calls",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"request", "budget"})
+
+	loadBalancerGCOrphansMetric = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Name:           "cloudprovider_nifcloud_load_balancer_gc_orphans",
+			Help:           "Orphaned load balancers found by the last garbage collection",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"type"})
+
+	loadBalancerGCDeletionsMetric = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Name:           "cloudprovider_nifcloud_load_balancer_gc_deletions",
+			Help:           "Orphaned load balancers deleted by the garbage collector",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"type", "result"})
 )
 
 func recordNIFCLOUDMetric(actionName string, timeTaken float64, err error) {
 	if err != nil {
-		nifcloudAPIErrorMetric.With(prometheus.Labels{"request": actionName}).Inc()
+		nifcloudAPIErrorMetric.With(prometheus.Labels{"request": actionName, "code": errorCodeOf(err), "category": errorCategoryNameOf(err)}).Inc()
 	} else {
 		nifcloudAPIMetric.With(prometheus.Labels{"request": actionName}).Observe(timeTaken)
 	}
 }
 
+// recordNIFCLOUDRetryMetric counts the retried attempts of the API call
+func recordNIFCLOUDRetryMetric(actionName string, metadata middleware.Metadata) {
+	results, ok := retry.GetAttemptResults(metadata)
+	if !ok {
+		return
+	}
+	for _, result := range results.Results {
+		if result.Retried {
+			nifcloudAPIRetryMetric.With(prometheus.Labels{"request": actionName, "class": string(classifyRetry(result.Err))}).Inc()
+		}
+	}
+}
+
+func recordNIFCLOUDRateLimiterMetric(actionName, budget string, timeTaken float64) {
+	nifcloudAPIRateLimiterMetric.With(prometheus.Labels{"request": actionName, "budget": budget}).Observe(timeTaken)
+}
+
+// addMetricsMiddleware adds the middleware recording metrics of every API call to the stack of computing.Client.
+// It is added to the end of initialize step to get the operation name registered by the SDK.
+func addMetricsMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc(
+		"NIFCLOUDMetrics",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			start := time.Now()
+			out, metadata, err := next.HandleInitialize(ctx, in)
+			recordNIFCLOUDMetric(awsmiddleware.GetOperationName(ctx), time.Since(start).Seconds(), err)
+			recordNIFCLOUDRetryMetric(awsmiddleware.GetOperationName(ctx), metadata)
+			return out, metadata, err
+		},
+	), middleware.After)
+}
+
 var registerOnce sync.Once
 
 func registerMetrics() {
 	registerOnce.Do(func() {
 		legacyregistry.MustRegister(nifcloudAPIMetric)
 		legacyregistry.MustRegister(nifcloudAPIErrorMetric)
+		legacyregistry.MustRegister(nifcloudAPIRetryMetric)
+		legacyregistry.MustRegister(nifcloudAPIRateLimiterMetric)
+		legacyregistry.MustRegister(loadBalancerGCOrphansMetric)
+		legacyregistry.MustRegister(loadBalancerGCDeletionsMetric)
 	})
 }
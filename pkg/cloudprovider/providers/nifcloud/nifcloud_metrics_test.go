@@ -0,0 +1,69 @@
+package nifcloud_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+var _ = Describe("metrics", func() {
+	var (
+		ts                    *httptest.Server
+		handler               http.HandlerFunc
+		testNifcloudAPIClient *nifcloud.ExportNifcloudAPIClient
+	)
+
+	JustBeforeEach(func() {
+		ts = helper.NewTestServer(handler)
+		testNifcloudAPIClient = nifcloud.NewNIFCLOUDAPIClientWithEndpoint("testkey", "testsecretkey", "jp-east-1", ts.URL)
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	Describe("API call is succeeded", func() {
+		BeforeEach(func() {
+			handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(lo.Must(os.ReadFile("./testdata/describe_instances_instance_id.xml")))
+			})
+		})
+
+		It("record the latency with the action name", func() {
+			histogram := nifcloud.ExportNIFCLOUDAPIMetric.WithLabelValues("DescribeInstances")
+			before := lo.Must(testutil.GetHistogramMetricCount(histogram))
+
+			_, err := testNifcloudAPIClient.DescribeInstancesByInstanceID(context.Background(), []string{"testinstance"})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(testutil.GetHistogramMetricCount(histogram)).Should(Equal(before + 1))
+		})
+	})
+
+	Describe("API call is failed", func() {
+		BeforeEach(func() {
+			handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write(lo.Must(os.ReadFile("./testdata/describe_instances_not_found_instance_error.xml")))
+			})
+		})
+
+		It("count the error with the action name, error code and category", func() {
+			counter := nifcloud.ExportNIFCLOUDAPIErrorMetric.WithLabelValues("DescribeInstances", helper.ErrorCodeInstanceNotFound, "not_found")
+			before := lo.Must(testutil.GetCounterMetricValue(counter))
+
+			_, err := testNifcloudAPIClient.DescribeInstancesByInstanceID(context.Background(), []string{"noinstance"})
+			Expect(err).Should(HaveOccurred())
+
+			Expect(testutil.GetCounterMetricValue(counter)).Should(Equal(before + 1))
+		})
+	})
+})
@@ -0,0 +1,207 @@
+package nifcloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// NodeLabelInstanceFamily is the label of the instance family (e.g. h2 of h2-large16)
+	NodeLabelInstanceFamily = "nifcloud.com/instance-family"
+	// NodeLabelInstanceSize is the label of the instance size (e.g. large16 of h2-large16)
+	NodeLabelInstanceSize = "nifcloud.com/instance-size"
+	// NodeLabelAccountingType is the label of the accounting type (monthly or pay-per-use)
+	NodeLabelAccountingType = "nifcloud.com/accounting-type"
+	// NodeLabelImageID is the label of the image id the instance is created from
+	NodeLabelImageID = "nifcloud.com/image-id"
+	// NodeLabelDedicated is the label whether the instance runs on a dedicated host ("true" or "false")
+	NodeLabelDedicated = "nifcloud.com/dedicated"
+	// NodeLabelDescription is the label of the description memo of the instance
+	NodeLabelDescription = "nifcloud.com/description"
+
+	defaultNodeLabelSyncPeriod = 5 * time.Minute
+)
+
+type nodeLabel struct {
+	key   string
+	value func(instance Instance) string
+}
+
+// nodeLabels maps the attribute names in the cloud config to the labels
+var nodeLabels = map[string]nodeLabel{
+	"instanceFamily": {
+		key: NodeLabelInstanceFamily,
+		value: func(instance Instance) string {
+			family, _, _ := strings.Cut(instance.InstanceType, "-")
+			return family
+		},
+	},
+	"instanceSize": {
+		key: NodeLabelInstanceSize,
+		value: func(instance Instance) string {
+			_, size, _ := strings.Cut(instance.InstanceType, "-")
+			return size
+		},
+	},
+	"accountingType": {
+		key: NodeLabelAccountingType,
+		value: func(instance Instance) string {
+			switch instance.AccountingType {
+			case "1":
+				return "monthly"
+			case "2":
+				return "pay-per-use"
+			default:
+				return instance.AccountingType
+			}
+		},
+	},
+	"imageID": {
+		key:   NodeLabelImageID,
+		value: func(instance Instance) string { return instance.ImageID },
+	},
+	"dedicated": {
+		key: NodeLabelDedicated,
+		value: func(instance Instance) string {
+			if instance.Tenancy == "" {
+				return ""
+			}
+			return fmt.Sprint(instance.Tenancy == "dedicated")
+		},
+	},
+	"description": {
+		key:   NodeLabelDescription,
+		value: func(instance Instance) string { return instance.Description },
+	},
+}
+
+func (nlc NodeLabelsConfig) validate() error {
+	for _, attribute := range nlc.Attributes {
+		if _, ok := nodeLabels[attribute]; !ok {
+			return fmt.Errorf("attribute %q is not supported", attribute)
+		}
+	}
+	if nlc.SyncPeriod.Duration < 0 {
+		return fmt.Errorf("syncPeriod must not be negative")
+	}
+	return nil
+}
+
+// instanceLabels returns the labels of the attributes of the instance.
+// The value is empty if the attribute is empty or can not be a label value, so that the label is removed.
+func instanceLabels(instance Instance, attributes []string) map[string]string {
+	labels := map[string]string{}
+	for _, attribute := range attributes {
+		label, ok := nodeLabels[attribute]
+		if !ok {
+			continue
+		}
+		value := label.value(instance)
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			klog.V(4).Infof("Instance %s has %s %q that can not be a label value: %s", instance.InstanceID, attribute, value, strings.Join(errs, ", "))
+			value = ""
+		}
+		labels[label.key] = value
+	}
+	return labels
+}
+
+// runNodeLabelController keeps the node labels in sync with the attributes of the instances until stop is closed
+func (c *Cloud) runNodeLabelController(stop <-chan struct{}) {
+	period := c.config.Node.Labels.SyncPeriod.Duration
+	if period <= 0 {
+		period = defaultNodeLabelSyncPeriod
+	}
+
+	klog.Infof("Starting node label controller (attributes: %v, sync period: %s)", c.config.Node.Labels.Attributes, period)
+	wait.UntilWithContext(wait.ContextForChannel(stop), func(ctx context.Context) {
+		if err := c.syncNodeLabels(ctx); err != nil {
+			klog.Errorf("Failed to sync node labels: %v", err)
+		}
+	}, period)
+}
+
+// syncNodeLabels updates the labels of all nodes with provider id from the instance inventory
+func (c *Cloud) syncNodeLabels(ctx context.Context) error {
+	nodes, err := c.kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	instances, err := c.client.DescribeInstances(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to describe instances: %w", err)
+	}
+	instancesByUniqueID := lo.KeyBy(instances, func(instance Instance) string { return instance.InstanceUniqueID })
+
+	errs := []error{}
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if node.Spec.ProviderID == "" || c.isForeignNode(node) {
+			// the node is not initialized by the node controller yet or not managed by this cloud provider
+			continue
+		}
+		instanceUniqueID, err := getInstanceUniqueIDFromProviderID(node.Spec.ProviderID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("node %q has invalid provider id: %w", node.Name, err))
+			continue
+		}
+		instance, ok := instancesByUniqueID[instanceUniqueID]
+		if !ok {
+			// the node lifecycle controller deletes the node
+			continue
+		}
+
+		labels := instanceLabels(instance, c.config.Node.Labels.Attributes)
+		if err := c.patchNodeLabels(ctx, node, labels); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// patchNodeLabels patches the changed labels. The labels with empty value are removed
+func (c *Cloud) patchNodeLabels(ctx context.Context, node *v1.Node, labels map[string]string) error {
+	changes := map[string]interface{}{}
+	for key, value := range labels {
+		current, ok := node.Labels[key]
+		switch {
+		case value == "" && ok:
+			changes[key] = nil
+		case value != "" && current != value:
+			changes[key] = value
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": changes,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal labels patch of node %q: %w", node.Name, err)
+	}
+
+	klog.Infof("Updating labels of node %q: %v", node.Name, changes)
+	if _, err := c.kubeClient.CoreV1().Nodes().Patch(ctx, node.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch labels of node %q: %w", node.Name, err)
+	}
+
+	return nil
+}
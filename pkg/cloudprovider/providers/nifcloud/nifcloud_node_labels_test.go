@@ -0,0 +1,151 @@
+package nifcloud_test
+
+import (
+	"context"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var allNodeLabelAttributes = []string{"instanceFamily", "instanceSize", "accountingType", "imageID", "dedicated", "description"}
+
+var _ = Describe("instanceLabels", func() {
+	Context("the instance has all attributes", func() {
+		It("return the labels of the attributes", func() {
+			testInstance := *helper.NewTestInstanceWithNetworkInterfaces()
+			testInstance.Description = "gpu-worker"
+
+			labels := nifcloud.ExportInstanceLabels(testInstance, allNodeLabelAttributes)
+			Expect(labels).Should(Equal(map[string]string{
+				nifcloud.NodeLabelInstanceFamily: "h2",
+				nifcloud.NodeLabelInstanceSize:   "large16",
+				nifcloud.NodeLabelAccountingType: "monthly",
+				nifcloud.NodeLabelImageID:        "283",
+				nifcloud.NodeLabelDedicated:      "false",
+				nifcloud.NodeLabelDescription:    "gpu-worker",
+			}))
+		})
+	})
+
+	Context("the attributes can not be label values", func() {
+		It("return empty values to remove the labels", func() {
+			testInstance := *helper.NewTestInstance()
+			testInstance.InstanceType = "mini"
+			testInstance.Description = "web server for the staging environment"
+
+			labels := nifcloud.ExportInstanceLabels(testInstance, []string{"instanceFamily", "instanceSize", "description"})
+			Expect(labels).Should(Equal(map[string]string{
+				nifcloud.NodeLabelInstanceFamily: "mini",
+				nifcloud.NodeLabelInstanceSize:   "",
+				nifcloud.NodeLabelDescription:    "",
+			}))
+		})
+	})
+})
+
+var _ = Describe("syncNodeLabels", func() {
+	var ctrl *gomock.Controller
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("update the labels of the nodes with provider id", func() {
+		ctx := context.Background()
+		testInstance := *helper.NewTestInstanceWithNetworkInterfaces()
+		testInstance.Tenancy = "dedicated"
+		initializedNode := &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "worker-1",
+				Labels: map[string]string{
+					"app":                            "web",
+					nifcloud.NodeLabelInstanceFamily: "e",
+					nifcloud.NodeLabelDescription:    "old",
+				},
+			},
+			Spec: v1.NodeSpec{ProviderID: "nifcloud:///east-11/i-abcd1234"},
+		}
+		uninitializedNode := &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-2"},
+		}
+
+		c := nifcloud.NewMockCloudAPIClient(ctrl)
+		c.EXPECT().
+			DescribeInstances(gomock.Any()).
+			Return([]nifcloud.Instance{testInstance}, nil).
+			Times(1)
+
+		kubeClient := fake.NewSimpleClientset(initializedNode, uninitializedNode)
+		cloud := &nifcloud.Cloud{}
+		cloud.SetClient(c)
+		cloud.SetKubeClient(kubeClient)
+		cloud.SetConfig(nifcloud.CloudConfig{
+			Node: nifcloud.NodeConfig{
+				Labels: nifcloud.NodeLabelsConfig{Attributes: allNodeLabelAttributes},
+			},
+		})
+
+		Expect(nifcloud.ExportSyncNodeLabels(cloud, ctx)).ShouldNot(HaveOccurred())
+
+		gotNode, err := kubeClient.CoreV1().Nodes().Get(ctx, initializedNode.Name, metav1.GetOptions{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(gotNode.Labels).Should(Equal(map[string]string{
+			"app":                            "web",
+			nifcloud.NodeLabelInstanceFamily: "h2",
+			nifcloud.NodeLabelInstanceSize:   "large16",
+			nifcloud.NodeLabelAccountingType: "monthly",
+			nifcloud.NodeLabelImageID:        "283",
+			nifcloud.NodeLabelDedicated:      "true",
+		}))
+
+		gotNode, err = kubeClient.CoreV1().Nodes().Get(ctx, uninitializedNode.Name, metav1.GetOptions{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(gotNode.Labels).Should(BeEmpty())
+	})
+
+	It("does not patch the nodes whose labels are up to date", func() {
+		ctx := context.Background()
+		testInstance := *helper.NewTestInstanceWithNetworkInterfaces()
+		node := &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "worker-1",
+				Labels: map[string]string{nifcloud.NodeLabelInstanceFamily: "h2"},
+			},
+			Spec: v1.NodeSpec{ProviderID: "nifcloud:///east-11/i-abcd1234"},
+		}
+
+		c := nifcloud.NewMockCloudAPIClient(ctrl)
+		c.EXPECT().
+			DescribeInstances(gomock.Any()).
+			Return([]nifcloud.Instance{testInstance}, nil).
+			Times(1)
+
+		kubeClient := fake.NewSimpleClientset(node)
+		cloud := &nifcloud.Cloud{}
+		cloud.SetClient(c)
+		cloud.SetKubeClient(kubeClient)
+		cloud.SetConfig(nifcloud.CloudConfig{
+			Node: nifcloud.NodeConfig{
+				Labels: nifcloud.NodeLabelsConfig{Attributes: []string{"instanceFamily"}},
+			},
+		})
+
+		kubeClient.ClearActions()
+		Expect(nifcloud.ExportSyncNodeLabels(cloud, ctx)).ShouldNot(HaveOccurred())
+		for _, action := range kubeClient.Actions() {
+			Expect(action.GetVerb()).ShouldNot(Equal("patch"))
+		}
+	})
+})
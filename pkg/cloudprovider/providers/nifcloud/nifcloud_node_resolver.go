@@ -0,0 +1,232 @@
+package nifcloud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	cloudproviderapi "k8s.io/cloud-provider/api"
+)
+
+const (
+	// NodeInstanceResolverInstanceID resolves the instance whose instance id is the node name
+	NodeInstanceResolverInstanceID = "instanceID"
+	// NodeInstanceResolverHostname resolves the instance whose instance id or description matches the hostname of the node
+	NodeInstanceResolverHostname = "hostname"
+	// NodeInstanceResolverPrivateIP resolves the instance whose private or private LAN ip address is an internal ip address of the node
+	NodeInstanceResolverPrivateIP = "privateIP"
+	// NodeInstanceResolverLabel resolves the instance whose instance id is the value of the node label
+	NodeInstanceResolverLabel = "label"
+	// NodeInstanceResolverAnnotation resolves the instance whose instance id is the value of the node annotation
+	NodeInstanceResolverAnnotation = "annotation"
+)
+
+func (nc NodeConfig) validate() error {
+	switch nc.InstanceResolver {
+	case "", NodeInstanceResolverInstanceID, NodeInstanceResolverHostname, NodeInstanceResolverPrivateIP:
+	case NodeInstanceResolverLabel, NodeInstanceResolverAnnotation:
+		if nc.InstanceIDKey == "" {
+			return fmt.Errorf("instanceIDKey is required when instanceResolver is %q", nc.InstanceResolver)
+		}
+	default:
+		return fmt.Errorf("instanceResolver %q is not supported", nc.InstanceResolver)
+	}
+
+	for name, networkID := range map[string]string{
+		"addresses.internalIP":  nc.Addresses.InternalIP,
+		"addresses.externalIP":  nc.Addresses.ExternalIP,
+		"addresses.hostname":    nc.Addresses.Hostname,
+		"addresses.internalDNS": nc.Addresses.InternalDNS,
+	} {
+		if networkID != "" && !strings.HasPrefix(networkID, "net-") {
+			return fmt.Errorf("%s %q must be a network id", name, networkID)
+		}
+	}
+
+	if err := nc.Labels.validate(); err != nil {
+		return fmt.Errorf("labels is invalid: %w", err)
+	}
+	if err := nc.Foreign.validate(); err != nil {
+		return fmt.Errorf("foreign is invalid: %w", err)
+	}
+
+	return nil
+}
+
+// instanceByNodeName returns the instance of the node with the specified name.
+// The node is fetched from the API server when the resolver needs more than the name.
+func (c *Cloud) instanceByNodeName(ctx context.Context, name types.NodeName) (*Instance, error) {
+	switch c.config.Node.InstanceResolver {
+	case "", NodeInstanceResolverInstanceID:
+		return c.instanceByInstanceID(ctx, string(name))
+	case NodeInstanceResolverHostname:
+		return c.instanceByHostname(ctx, string(name))
+	}
+
+	if c.kubeClient == nil {
+		return nil, fmt.Errorf("could not get node %q: kubernetes client is not initialized", name)
+	}
+	node, err := c.kubeClient.CoreV1().Nodes().Get(ctx, string(name), metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not get node %q: %w", name, err)
+	}
+
+	return c.instanceByNode(ctx, node)
+}
+
+// instancesByNodes returns the instances of the nodes.
+// The nodes with provider ids are looked up by the instance unique ids and the others by the configured resolver,
+// so the load balancer backends are found whatever the nodes are named.
+func (c *Cloud) instancesByNodes(ctx context.Context, nodes []*v1.Node) ([]Instance, error) {
+	var (
+		instanceIDs       []string
+		instanceUniqueIDs []string
+		unresolvedNodes   []*v1.Node
+	)
+	for _, node := range nodes {
+		switch {
+		case node.Spec.ProviderID != "":
+			instanceUniqueID, err := getInstanceUniqueIDFromProviderID(node.Spec.ProviderID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get instance unique id of node %q: %w", node.Name, err)
+			}
+			instanceUniqueIDs = append(instanceUniqueIDs, instanceUniqueID)
+		case c.config.Node.InstanceResolver == "" || c.config.Node.InstanceResolver == NodeInstanceResolverInstanceID:
+			// fetch the instances at once
+			instanceIDs = append(instanceIDs, node.Name)
+		default:
+			unresolvedNodes = append(unresolvedNodes, node)
+		}
+	}
+
+	instances := []Instance{}
+	if len(instanceIDs) > 0 {
+		found, err := c.client.DescribeInstancesByInstanceID(ctx, instanceIDs)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch instances info for %v: %w", instanceIDs, err)
+		}
+		instances = append(instances, found...)
+	}
+	if len(instanceUniqueIDs) > 0 {
+		found, err := c.client.DescribeInstancesByInstanceUniqueID(ctx, instanceUniqueIDs)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch instances info for %v: %w", instanceUniqueIDs, err)
+		}
+		if len(found) < len(instanceUniqueIDs) {
+			return nil, fmt.Errorf("could not fetch instances info for %v: %d of them are not found", instanceUniqueIDs, len(instanceUniqueIDs)-len(found))
+		}
+		instances = append(instances, found...)
+	}
+	for _, node := range unresolvedNodes {
+		instance, err := c.instanceByNode(ctx, node)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch instance info for node %q: %w", node.Name, err)
+		}
+		instances = append(instances, *instance)
+	}
+
+	return instances, nil
+}
+
+// instanceByNode returns the instance of the node by the configured resolver regardless of the provider id
+func (c *Cloud) instanceByNode(ctx context.Context, node *v1.Node) (*Instance, error) {
+	switch resolver := c.config.Node.InstanceResolver; resolver {
+	case "", NodeInstanceResolverInstanceID:
+		return c.instanceByInstanceID(ctx, node.Name)
+	case NodeInstanceResolverHostname:
+		return c.instanceByHostname(ctx, nodeHostname(node))
+	case NodeInstanceResolverPrivateIP:
+		ipAddresses := nodeInternalIPAddresses(node)
+		if len(ipAddresses) == 0 {
+			return nil, fmt.Errorf("node %q has no internal ip address to find the instance", node.Name)
+		}
+		return c.findInstance(ctx, node.Name, func(instance Instance) bool {
+			return lo.ContainsBy(instanceNetworkInterfaces(instance), func(networkInterface InstanceNetworkInterface) bool {
+				return networkInterface.NetworkID != commonGlobalNetworkID && lo.Contains(ipAddresses, networkInterface.IPAddress)
+			})
+		})
+	case NodeInstanceResolverLabel, NodeInstanceResolverAnnotation:
+		values := node.Labels
+		if resolver == NodeInstanceResolverAnnotation {
+			values = node.Annotations
+		}
+		instanceID := values[c.config.Node.InstanceIDKey]
+		if instanceID == "" {
+			return nil, fmt.Errorf("node %q has no %s %q to find the instance", node.Name, resolver, c.config.Node.InstanceIDKey)
+		}
+		return c.instanceByInstanceID(ctx, instanceID)
+	default:
+		return nil, fmt.Errorf("instance resolver %q is not supported", resolver)
+	}
+}
+
+func (c *Cloud) instanceByInstanceID(ctx context.Context, instanceID string) (*Instance, error) {
+	instances, err := c.client.DescribeInstancesByInstanceID(ctx, []string{instanceID})
+	if err != nil {
+		return nil, fmt.Errorf("cloud not fetch instance info for %q: %w", instanceID, err)
+	}
+
+	if err := isSingleInstance(instances, instanceID); err != nil {
+		return nil, err
+	}
+
+	return &instances[0], nil
+}
+
+func (c *Cloud) instanceByHostname(ctx context.Context, hostname string) (*Instance, error) {
+	// the hostname may be a FQDN while the instance id can not contain dots
+	shortHostname, _, _ := strings.Cut(hostname, ".")
+	return c.findInstance(ctx, hostname, func(instance Instance) bool {
+		for _, candidate := range []string{instance.InstanceID, instance.Description} {
+			if candidate != "" && (strings.EqualFold(candidate, hostname) || strings.EqualFold(candidate, shortHostname)) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// findInstance returns the only instance in the region matched by the predicate
+func (c *Cloud) findInstance(ctx context.Context, name string, predicate func(instance Instance) bool) (*Instance, error) {
+	all, err := c.client.DescribeInstances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cloud not fetch instance info for %q: %w", name, err)
+	}
+
+	instances := lo.Filter(all, func(instance Instance, _ int) bool { return predicate(instance) })
+	if err := isSingleInstance(instances, name); err != nil {
+		return nil, err
+	}
+
+	return &instances[0], nil
+}
+
+func nodeHostname(node *v1.Node) string {
+	for _, address := range node.Status.Addresses {
+		if address.Type == v1.NodeHostName && address.Address != "" {
+			return address.Address
+		}
+	}
+	return node.Name
+}
+
+// nodeInternalIPAddresses returns the internal ip addresses of the node.
+// Before the node is initialized, the address given by kubelet --node-ip is used.
+func nodeInternalIPAddresses(node *v1.Node) []string {
+	ipAddresses := []string{}
+	for _, address := range node.Status.Addresses {
+		if address.Type == v1.NodeInternalIP {
+			ipAddresses = append(ipAddresses, address.Address)
+		}
+	}
+	if len(ipAddresses) == 0 {
+		if providedIPs, ok := node.Annotations[cloudproviderapi.AnnotationAlphaProvidedIPAddr]; ok {
+			ipAddresses = append(ipAddresses, strings.Split(providedIPs, ",")...)
+		}
+	}
+	return ipAddresses
+}
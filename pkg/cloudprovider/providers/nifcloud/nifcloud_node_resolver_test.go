@@ -0,0 +1,436 @@
+package nifcloud_test
+
+import (
+	"context"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	cloudprovider "k8s.io/cloud-provider"
+)
+
+var _ = Describe("instanceByNodeName", func() {
+	var ctrl *gomock.Controller
+	var region string = "east1"
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	newCloud := func(client nifcloud.CloudAPIClient, nodeConfig nifcloud.NodeConfig, nodes ...*v1.Node) *nifcloud.Cloud {
+		objects := []runtime.Object{}
+		for _, node := range nodes {
+			objects = append(objects, node)
+		}
+		cloud := &nifcloud.Cloud{}
+		cloud.SetClient(client)
+		cloud.SetRegion(region)
+		cloud.SetConfig(nifcloud.CloudConfig{Node: nodeConfig})
+		cloud.SetKubeClient(fake.NewSimpleClientset(objects...))
+		return cloud
+	}
+
+	otherInstance := nifcloud.Instance{
+		InstanceID:       "otherinstance",
+		InstanceUniqueID: "i-efgh5678",
+		PrivateIPAddress: "192.168.0.101",
+		Zone:             "east-12",
+	}
+
+	Context("instance resolver is instanceID", func() {
+		It("return the instance whose instance id is the node name", func() {
+			ctx := context.Background()
+			testInstance := helper.NewTestInstance()
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstancesByInstanceID(gomock.Any(), []string{testInstance.InstanceID}).
+				Return([]nifcloud.Instance{*testInstance}, nil).
+				Times(1)
+
+			cloud := newCloud(c, nifcloud.NodeConfig{InstanceResolver: nifcloud.NodeInstanceResolverInstanceID})
+
+			gotInstance, err := nifcloud.ExportInstanceByNodeName(cloud, ctx, types.NodeName(testInstance.InstanceID))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotInstance).Should(Equal(testInstance))
+		})
+	})
+
+	Context("instance resolver is hostname", func() {
+		It("return the instance matched with the hostname ignoring the case and the domain", func() {
+			ctx := context.Background()
+			testInstance := helper.NewTestInstance()
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstances(gomock.Any()).
+				Return([]nifcloud.Instance{otherInstance, *testInstance}, nil).
+				Times(1)
+
+			cloud := newCloud(c, nifcloud.NodeConfig{InstanceResolver: nifcloud.NodeInstanceResolverHostname})
+
+			gotInstance, err := nifcloud.ExportInstanceByNodeName(cloud, ctx, types.NodeName("TestInstance.example.com"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotInstance).Should(Equal(testInstance))
+		})
+
+		It("return the instance whose description is the hostname", func() {
+			ctx := context.Background()
+			testInstance := helper.NewTestInstance()
+			testInstance.Description = "worker-1"
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstances(gomock.Any()).
+				Return([]nifcloud.Instance{otherInstance, *testInstance}, nil).
+				Times(1)
+
+			cloud := newCloud(c, nifcloud.NodeConfig{InstanceResolver: nifcloud.NodeInstanceResolverHostname})
+
+			gotInstance, err := nifcloud.ExportInstanceByNodeName(cloud, ctx, types.NodeName("worker-1"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotInstance).Should(Equal(testInstance))
+		})
+
+		It("return not found error if no instance matches", func() {
+			ctx := context.Background()
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstances(gomock.Any()).
+				Return([]nifcloud.Instance{otherInstance}, nil).
+				Times(1)
+
+			cloud := newCloud(c, nifcloud.NodeConfig{InstanceResolver: nifcloud.NodeInstanceResolverHostname})
+
+			gotInstance, err := nifcloud.ExportInstanceByNodeName(cloud, ctx, types.NodeName("worker-1"))
+			Expect(err).Should(MatchError(cloudprovider.InstanceNotFound))
+			Expect(gotInstance).Should(BeNil())
+		})
+	})
+
+	Context("instance resolver is privateIP", func() {
+		It("return the instance whose private ip address is the internal ip of the node", func() {
+			ctx := context.Background()
+			testInstance := helper.NewTestInstance()
+			testNode := &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+				Status: v1.NodeStatus{
+					Addresses: []v1.NodeAddress{
+						{Type: v1.NodeHostName, Address: "worker-1"},
+						{Type: v1.NodeInternalIP, Address: testInstance.PrivateIPAddress},
+					},
+				},
+			}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstances(gomock.Any()).
+				Return([]nifcloud.Instance{otherInstance, *testInstance}, nil).
+				Times(1)
+
+			cloud := newCloud(c, nifcloud.NodeConfig{InstanceResolver: nifcloud.NodeInstanceResolverPrivateIP}, testNode)
+
+			gotInstance, err := nifcloud.ExportInstanceByNodeName(cloud, ctx, types.NodeName(testNode.Name))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotInstance).Should(Equal(testInstance))
+		})
+
+		It("return the instance whose private LAN ip address is the internal ip of the node", func() {
+			ctx := context.Background()
+			testInstance := helper.NewTestInstanceWithNetworkInterfaces()
+			testInstance.NetworkInterfaces = append(testInstance.NetworkInterfaces, nifcloud.InstanceNetworkInterface{
+				NetworkID: "net-abcd1234",
+				IPAddress: "10.0.0.10",
+			})
+			testNode := &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+				Status: v1.NodeStatus{
+					Addresses: []v1.NodeAddress{
+						{Type: v1.NodeInternalIP, Address: "10.0.0.10"},
+					},
+				},
+			}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstances(gomock.Any()).
+				Return([]nifcloud.Instance{otherInstance, *testInstance}, nil).
+				Times(1)
+
+			cloud := newCloud(c, nifcloud.NodeConfig{InstanceResolver: nifcloud.NodeInstanceResolverPrivateIP}, testNode)
+
+			gotInstance, err := nifcloud.ExportInstanceByNodeName(cloud, ctx, types.NodeName(testNode.Name))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotInstance).Should(Equal(testInstance))
+		})
+
+		It("use the ip address provided by kubelet before the node is initialized", func() {
+			ctx := context.Background()
+			testInstance := helper.NewTestInstance()
+			testNode := &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "worker-1",
+					Annotations: map[string]string{
+						"alpha.kubernetes.io/provided-node-ip": testInstance.PrivateIPAddress,
+					},
+				},
+			}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstances(gomock.Any()).
+				Return([]nifcloud.Instance{otherInstance, *testInstance}, nil).
+				Times(1)
+
+			cloud := newCloud(c, nifcloud.NodeConfig{InstanceResolver: nifcloud.NodeInstanceResolverPrivateIP}, testNode)
+
+			gotInstance, err := nifcloud.ExportInstanceByNodeName(cloud, ctx, types.NodeName(testNode.Name))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotInstance).Should(Equal(testInstance))
+		})
+
+		It("return error without calling API if the node has no internal ip", func() {
+			ctx := context.Background()
+			testNode := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+
+			cloud := newCloud(c, nifcloud.NodeConfig{InstanceResolver: nifcloud.NodeInstanceResolverPrivateIP}, testNode)
+
+			gotInstance, err := nifcloud.ExportInstanceByNodeName(cloud, ctx, types.NodeName(testNode.Name))
+			Expect(err).Should(HaveOccurred())
+			Expect(err).ShouldNot(MatchError(cloudprovider.InstanceNotFound))
+			Expect(gotInstance).Should(BeNil())
+		})
+	})
+
+	Context("instance resolver is label", func() {
+		It("return the instance whose instance id is the label value", func() {
+			ctx := context.Background()
+			testInstance := helper.NewTestInstance()
+			testNode := &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "worker-1",
+					Labels: map[string]string{"nifcloud.com/instance-id": testInstance.InstanceID},
+				},
+			}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstancesByInstanceID(gomock.Any(), []string{testInstance.InstanceID}).
+				Return([]nifcloud.Instance{*testInstance}, nil).
+				Times(1)
+
+			cloud := newCloud(c, nifcloud.NodeConfig{
+				InstanceResolver: nifcloud.NodeInstanceResolverLabel,
+				InstanceIDKey:    "nifcloud.com/instance-id",
+			}, testNode)
+
+			gotInstance, err := nifcloud.ExportInstanceByNodeName(cloud, ctx, types.NodeName(testNode.Name))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotInstance).Should(Equal(testInstance))
+		})
+
+		It("return error if the node does not exist", func() {
+			ctx := context.Background()
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+
+			cloud := newCloud(c, nifcloud.NodeConfig{
+				InstanceResolver: nifcloud.NodeInstanceResolverLabel,
+				InstanceIDKey:    "nifcloud.com/instance-id",
+			})
+
+			gotInstance, err := nifcloud.ExportInstanceByNodeName(cloud, ctx, types.NodeName("worker-1"))
+			Expect(err).Should(HaveOccurred())
+			Expect(gotInstance).Should(BeNil())
+		})
+	})
+
+	Context("instance resolver is annotation", func() {
+		It("return error without calling API if the node has no annotation", func() {
+			ctx := context.Background()
+			testNode := &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "worker-1",
+					Labels: map[string]string{"nifcloud.com/instance-id": "testinstance"},
+				},
+			}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+
+			cloud := newCloud(c, nifcloud.NodeConfig{
+				InstanceResolver: nifcloud.NodeInstanceResolverAnnotation,
+				InstanceIDKey:    "nifcloud.com/instance-id",
+			}, testNode)
+
+			gotInstance, err := nifcloud.ExportInstanceByNodeName(cloud, ctx, types.NodeName(testNode.Name))
+			Expect(err).Should(HaveOccurred())
+			Expect(gotInstance).Should(BeNil())
+		})
+	})
+})
+
+var _ = Describe("InstanceMetadata with instance resolver", func() {
+	var ctrl *gomock.Controller
+	var region string = "east1"
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("node has no provider id and the resolver is privateIP", func() {
+		It("return the metadata of the instance found by the internal ip", func() {
+			ctx := context.Background()
+			testInstance := helper.NewTestInstance()
+			testNode := &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+				Status: v1.NodeStatus{
+					Addresses: []v1.NodeAddress{
+						{Type: v1.NodeInternalIP, Address: testInstance.PrivateIPAddress},
+					},
+				},
+			}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstances(gomock.Any()).
+				Return([]nifcloud.Instance{*testInstance}, nil).
+				Times(1)
+
+			cloud := &nifcloud.Cloud{}
+			cloud.SetClient(c)
+			cloud.SetRegion(region)
+			cloud.SetConfig(nifcloud.CloudConfig{
+				Node: nifcloud.NodeConfig{InstanceResolver: nifcloud.NodeInstanceResolverPrivateIP},
+			})
+
+			metadata, err := cloud.InstanceMetadata(ctx, testNode)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(metadata.ProviderID).Should(Equal("nifcloud:///east-11/i-abcd1234"))
+		})
+	})
+})
+
+var _ = Describe("instancesByNodes", func() {
+	var ctrl *gomock.Controller
+	var region string = "east1"
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	newCloud := func(client nifcloud.CloudAPIClient, nodeConfig nifcloud.NodeConfig) *nifcloud.Cloud {
+		cloud := &nifcloud.Cloud{}
+		cloud.SetClient(client)
+		cloud.SetRegion(region)
+		cloud.SetConfig(nifcloud.CloudConfig{Node: nodeConfig})
+		return cloud
+	}
+
+	otherInstance := nifcloud.Instance{
+		InstanceID:       "otherinstance",
+		InstanceUniqueID: "i-efgh5678",
+		PrivateIPAddress: "192.168.0.101",
+		Zone:             "east-12",
+	}
+
+	Context("instance resolver is instanceID", func() {
+		It("fetch the instances named as the nodes at once", func() {
+			ctx := context.Background()
+			testInstance := helper.NewTestInstance()
+			testNodes := []*v1.Node{
+				{ObjectMeta: metav1.ObjectMeta{Name: testInstance.InstanceID}},
+				{ObjectMeta: metav1.ObjectMeta{Name: otherInstance.InstanceID}},
+			}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstancesByInstanceID(gomock.Any(), []string{testInstance.InstanceID, otherInstance.InstanceID}).
+				Return([]nifcloud.Instance{*testInstance, otherInstance}, nil).
+				Times(1)
+
+			cloud := newCloud(c, nifcloud.NodeConfig{})
+
+			gotInstances, err := nifcloud.ExportInstancesByNodes(cloud, ctx, testNodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotInstances).Should(Equal([]nifcloud.Instance{*testInstance, otherInstance}))
+		})
+	})
+
+	Context("instance resolver is hostname", func() {
+		It("find the instances of the nodes not named as the instance ids", func() {
+			ctx := context.Background()
+			testInstance := helper.NewTestInstance()
+			testNodes := []*v1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+					Status: v1.NodeStatus{
+						Addresses: []v1.NodeAddress{{Type: v1.NodeHostName, Address: testInstance.InstanceID}},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "worker-2"},
+					Spec:       v1.NodeSpec{ProviderID: "nifcloud:///east-12/i-efgh5678"},
+				},
+			}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeInstancesByInstanceUniqueID(gomock.Any(), []string{otherInstance.InstanceUniqueID}).
+				Return([]nifcloud.Instance{otherInstance}, nil).
+				Times(1)
+			c.EXPECT().
+				DescribeInstances(gomock.Any()).
+				Return([]nifcloud.Instance{*testInstance, otherInstance}, nil).
+				Times(1)
+
+			cloud := newCloud(c, nifcloud.NodeConfig{InstanceResolver: nifcloud.NodeInstanceResolverHostname})
+
+			gotInstances, err := nifcloud.ExportInstancesByNodes(cloud, ctx, testNodes)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotInstances).Should(ConsistOf(*testInstance, otherInstance))
+		})
+	})
+
+	Context("instance resolver is label", func() {
+		It("return error if the instance of a node is not found", func() {
+			ctx := context.Background()
+			testNodes := []*v1.Node{
+				{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}},
+			}
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+
+			cloud := newCloud(c, nifcloud.NodeConfig{
+				InstanceResolver: nifcloud.NodeInstanceResolverLabel,
+				InstanceIDKey:    "nifcloud.com/instance-id",
+			})
+
+			_, err := nifcloud.ExportInstancesByNodes(cloud, ctx, testNodes)
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
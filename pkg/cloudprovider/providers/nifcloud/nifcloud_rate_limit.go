@@ -0,0 +1,86 @@
+package nifcloud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultReadRateLimitQPS       = 10
+	defaultReadRateLimitBurst     = 20
+	defaultMutatingRateLimitQPS   = 2
+	defaultMutatingRateLimitBurst = 5
+
+	rateLimitBudgetRead     = "read"
+	rateLimitBudgetMutating = "mutating"
+)
+
+// apiRateLimiter limits the rate of API requests on the client side.
+// It is shared by all controllers using the same client,
+// and read (Describe*) requests and mutating requests have separate budgets
+// so that polling resources does not delay creating or updating them.
+type apiRateLimiter struct {
+	read     *rate.Limiter
+	mutating *rate.Limiter
+}
+
+func newAPIRateLimiter(config RateLimitConfig) *apiRateLimiter {
+	return &apiRateLimiter{
+		read:     newTokenBucket(config.Read, defaultReadRateLimitQPS, defaultReadRateLimitBurst),
+		mutating: newTokenBucket(config.Mutating, defaultMutatingRateLimitQPS, defaultMutatingRateLimitBurst),
+	}
+}
+
+func newTokenBucket(config TokenBucketConfig, defaultQPS float64, defaultBurst int) *rate.Limiter {
+	qps := defaultQPS
+	if config.QPS > 0 {
+		qps = config.QPS
+	}
+	burst := defaultBurst
+	if config.Burst > 0 {
+		burst = config.Burst
+	}
+	return rate.NewLimiter(rate.Limit(qps), burst)
+}
+
+// budget returns the budget name and the limiter for the operation
+func (l *apiRateLimiter) budget(operationName string) (string, *rate.Limiter) {
+	if strings.HasPrefix(operationName, "Describe") || strings.HasPrefix(operationName, "NiftyDescribe") {
+		return rateLimitBudgetRead, l.read
+	}
+	return rateLimitBudgetMutating, l.mutating
+}
+
+// wait blocks until the request of the operation is allowed or ctx is done
+func (l *apiRateLimiter) wait(ctx context.Context, operationName string) error {
+	budget, limiter := l.budget(operationName)
+
+	start := time.Now()
+	err := limiter.Wait(ctx)
+	recordNIFCLOUDRateLimiterMetric(operationName, budget, time.Since(start).Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to wait for rate limiter of %s: %w", operationName, err)
+	}
+
+	return nil
+}
+
+// addMiddleware adds the middleware waiting for the limiter to the stack of computing.Client.
+// It is added after the retry middleware to limit every attempt including retries.
+func (l *apiRateLimiter) addMiddleware(stack *middleware.Stack) error {
+	return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc(
+		"NIFCLOUDRateLimit",
+		func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			if err := l.wait(ctx, awsmiddleware.GetOperationName(ctx)); err != nil {
+				return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+			}
+			return next.HandleFinalize(ctx, in)
+		},
+	), "Retry", middleware.After)
+}
@@ -0,0 +1,83 @@
+package nifcloud_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+var _ = Describe("rate limit", func() {
+	var (
+		ts                    *httptest.Server
+		testNifcloudAPIClient *nifcloud.ExportNifcloudAPIClient
+		loadBalancer          *nifcloud.LoadBalancer
+	)
+
+	BeforeEach(func() {
+		ts = helper.NewTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.ParseForm()).NotTo(HaveOccurred())
+			if strings.HasPrefix(r.Form.Get("Action"), "Describe") {
+				_, _ = w.Write(lo.Must(os.ReadFile("./testdata/describe_instances_instance_id.xml")))
+				return
+			}
+			_, _ = w.Write(lo.Must(os.ReadFile("./testdata/delete_load_balancer.xml")))
+		}))
+		testNifcloudAPIClient = nifcloud.NewNIFCLOUDAPIClientWithCloudConfig("testkey", "testsecretkey", &nifcloud.CloudConfig{
+			Global: nifcloud.GlobalConfig{Region: "jp-east-1", Endpoint: ts.URL},
+			RateLimit: nifcloud.RateLimitConfig{
+				Read:     nifcloud.TokenBucketConfig{QPS: 0.01, Burst: 1},
+				Mutating: nifcloud.TokenBucketConfig{QPS: 5, Burst: 1},
+			},
+		})
+		loadBalancer = &nifcloud.LoadBalancer{Name: "testlb", LoadBalancerPort: 80, InstancePort: 30000}
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	Context("the budget is exhausted", func() {
+		It("wait for the token and record the wait time", func() {
+			histogram := nifcloud.ExportNIFCLOUDAPIRateLimiterMetric.WithLabelValues("DeleteLoadBalancer", "mutating")
+			before := lo.Must(testutil.GetHistogramMetricCount(histogram))
+
+			start := time.Now()
+			Expect(testNifcloudAPIClient.DeleteLoadBalancer(context.Background(), loadBalancer)).ShouldNot(HaveOccurred())
+			Expect(testNifcloudAPIClient.DeleteLoadBalancer(context.Background(), loadBalancer)).ShouldNot(HaveOccurred())
+			Expect(time.Since(start)).Should(BeNumerically(">=", 150*time.Millisecond))
+
+			Expect(testutil.GetHistogramMetricCount(histogram)).Should(Equal(before + 2))
+		})
+
+		It("return error if the context deadline is exceeded", func() {
+			_, err := testNifcloudAPIClient.DescribeInstancesByInstanceID(context.Background(), []string{"testinstance"})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			_, err = testNifcloudAPIClient.DescribeInstancesByInstanceID(ctx, []string{"testinstance"})
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("the read budget is exhausted", func() {
+		It("does not block mutating requests", func() {
+			_, err := testNifcloudAPIClient.DescribeInstancesByInstanceID(context.Background(), []string{"testinstance"})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			Expect(testNifcloudAPIClient.DeleteLoadBalancer(ctx, loadBalancer)).ShouldNot(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,98 @@
+package nifcloud
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+)
+
+const (
+	defaultRetryMaxAttempts = 5
+	defaultRetryMaxBackoff  = 20 * time.Second
+)
+
+// retryClass is the classification of an API error for retrying
+type retryClass string
+
+const (
+	retryClassRetryable retryClass = "retryable"
+	retryClassThrottled retryClass = "throttled"
+	retryClassFatal     retryClass = "fatal"
+	// retryClassUnknown is the error which is not an API error (e.g. connection error).
+	// The SDK default rules decide whether it is retried.
+	retryClassUnknown retryClass = "unknown"
+)
+
+// classifyRetry classifies the error.
+// Throttling errors and server side errors are retried, and the other client errors are not.
+func classifyRetry(err error) retryClass {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return retryClassUnknown
+	}
+
+	if IsThrottled(err) {
+		return retryClassThrottled
+	}
+
+	if strings.HasPrefix(apiErr.ErrorCode(), "Server.") {
+		return retryClassRetryable
+	}
+
+	if strings.HasPrefix(apiErr.ErrorCode(), "Client.") {
+		return retryClassFatal
+	}
+
+	return retryClassUnknown
+}
+
+func isErrorRetryable(err error) aws.Ternary {
+	switch classifyRetry(err) {
+	case retryClassRetryable, retryClassThrottled:
+		return aws.TrueTernary
+	case retryClassFatal:
+		return aws.FalseTernary
+	default:
+		return aws.UnknownTernary
+	}
+}
+
+func isErrorThrottle(err error) aws.Ternary {
+	if classifyRetry(err) == retryClassThrottled {
+		return aws.TrueTernary
+	}
+	return aws.UnknownTernary
+}
+
+// newRetryer returns the retryer of the API client.
+// Failed requests are retried with exponential backoff and full jitter,
+// and the request rate is reduced while the API returns throttling errors.
+// The sleep between attempts is canceled when the context is done, so the retries never exceed the deadline.
+func newRetryer(config RetryConfig) aws.Retryer {
+	maxAttempts := defaultRetryMaxAttempts
+	if config.MaxAttempts > 0 {
+		maxAttempts = config.MaxAttempts
+	}
+	maxBackoff := defaultRetryMaxBackoff
+	if config.MaxBackoff.Duration > 0 {
+		maxBackoff = config.MaxBackoff.Duration
+	}
+
+	return retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+		o.Throttles = append([]retry.IsErrorThrottle{retry.IsErrorThrottleFunc(isErrorThrottle)}, o.Throttles...)
+		o.StandardOptions = append(o.StandardOptions, func(so *retry.StandardOptions) {
+			so.MaxAttempts = maxAttempts
+			so.MaxBackoff = maxBackoff
+			so.Backoff = retry.NewExponentialJitterBackoff(maxBackoff)
+			// NoRetryCanceledError must be checked first not to retry the canceled requests
+			so.Retryables = append([]retry.IsErrorRetryable{
+				retry.NoRetryCanceledError{},
+				retry.IsErrorRetryableFunc(isErrorRetryable),
+			}, so.Retryables...)
+		})
+	})
+}
@@ -0,0 +1,192 @@
+package nifcloud_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func newErrorResponse(code string) string {
+	return fmt.Sprintf("<Response><Errors><Error><Code>%s</Code><Message>error</Message></Error></Errors><RequestID>test</RequestID></Response>", code)
+}
+
+var _ = Describe("classifyRetry", func() {
+	DescribeTable("classify the error",
+		func(err error, expected string) {
+			Expect(nifcloud.ExportClassifyRetry(err)).Should(Equal(expected))
+		},
+		Entry("throttling", helper.NewMockAPIError("Client.RequestLimitExceeded"), "throttled"),
+		Entry("server error", helper.NewMockAPIError("Server.InternalError"), "retryable"),
+		Entry("client error", helper.NewMockAPIError("Client.InvalidParameterNotFound.Instance"), "fatal"),
+		Entry("not an api error", errors.New("connection reset"), "unknown"),
+	)
+})
+
+var _ = Describe("newRetryer", func() {
+	DescribeTable("decide whether the error is retried",
+		func(err error, expected bool) {
+			retryer := nifcloud.ExportNewRetryer(nifcloud.RetryConfig{})
+			Expect(retryer.IsErrorRetryable(err)).Should(Equal(expected))
+		},
+		Entry("throttling", helper.NewMockAPIError("Client.RequestLimitExceeded"), true),
+		Entry("server error", helper.NewMockAPIError("Server.InternalError"), true),
+		Entry("client error", helper.NewMockAPIError("Client.InvalidParameterNotFound.Instance"), false),
+		Entry("canceled", context.Canceled, false),
+	)
+
+	It("use the default max attempts", func() {
+		retryer := nifcloud.ExportNewRetryer(nifcloud.RetryConfig{})
+		Expect(retryer.MaxAttempts()).Should(Equal(5))
+	})
+
+	It("use the configured max attempts", func() {
+		retryer := nifcloud.ExportNewRetryer(nifcloud.RetryConfig{MaxAttempts: 2})
+		Expect(retryer.MaxAttempts()).Should(Equal(2))
+	})
+
+	It("never wait longer than the max backoff", func() {
+		retryer := nifcloud.ExportNewRetryer(nifcloud.RetryConfig{MaxBackoff: metav1.Duration{Duration: 100 * time.Millisecond}})
+		for attempt := 1; attempt <= 10; attempt++ {
+			delay, err := retryer.RetryDelay(attempt, helper.NewMockAPIError("Server.InternalError"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(delay).Should(BeNumerically("<=", 100*time.Millisecond))
+		}
+	})
+})
+
+var _ = Describe("retry", func() {
+	var (
+		ts                    *httptest.Server
+		handler               http.HandlerFunc
+		requests              atomic.Int32
+		testNifcloudAPIClient *nifcloud.ExportNifcloudAPIClient
+	)
+
+	// failThen returns the handler responding the error until the given number of requests,
+	// and the instance after that.
+	failThen := func(failures int32, status int, code string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) <= failures {
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(newErrorResponse(code)))
+				return
+			}
+			_, _ = w.Write(lo.Must(os.ReadFile("./testdata/describe_instances_instance_id.xml")))
+		}
+	}
+
+	BeforeEach(func() {
+		requests.Store(0)
+	})
+
+	JustBeforeEach(func() {
+		ts = helper.NewTestServer(handler)
+		testNifcloudAPIClient = nifcloud.NewNIFCLOUDAPIClientWithCloudConfig("testkey", "testsecretkey", &nifcloud.CloudConfig{
+			Global: nifcloud.GlobalConfig{Region: "jp-east-1", Endpoint: ts.URL},
+			Retry: nifcloud.RetryConfig{
+				MaxAttempts: 3,
+				// the backoff is almost zero not to make the result depend on the timing
+				MaxBackoff: metav1.Duration{Duration: time.Nanosecond},
+			},
+		})
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	Context("the server error is returned temporarily", func() {
+		BeforeEach(func() {
+			handler = failThen(2, http.StatusServiceUnavailable, "Server.InternalError")
+		})
+
+		It("retry and succeed", func() {
+			counter := nifcloud.ExportNIFCLOUDAPIRetryMetric.WithLabelValues("DescribeInstances", "retryable")
+			before := lo.Must(testutil.GetCounterMetricValue(counter))
+
+			_, err := testNifcloudAPIClient.DescribeInstancesByInstanceID(context.Background(), []string{"testinstance"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(requests.Load()).Should(BeEquivalentTo(3))
+
+			Expect(testutil.GetCounterMetricValue(counter)).Should(Equal(before + 2))
+		})
+	})
+
+	Context("the throttling error is returned temporarily", func() {
+		BeforeEach(func() {
+			handler = failThen(1, http.StatusBadRequest, "Client.RequestLimitExceeded")
+		})
+
+		It("retry and succeed", func() {
+			counter := nifcloud.ExportNIFCLOUDAPIRetryMetric.WithLabelValues("DescribeInstances", "throttled")
+			before := lo.Must(testutil.GetCounterMetricValue(counter))
+
+			_, err := testNifcloudAPIClient.DescribeInstancesByInstanceID(context.Background(), []string{"testinstance"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(requests.Load()).Should(BeEquivalentTo(2))
+
+			Expect(testutil.GetCounterMetricValue(counter)).Should(Equal(before + 1))
+		})
+	})
+
+	Context("the server error is returned continuously", func() {
+		BeforeEach(func() {
+			handler = failThen(10, http.StatusServiceUnavailable, "Server.InternalError")
+		})
+
+		It("give up after the max attempts", func() {
+			_, err := testNifcloudAPIClient.DescribeInstancesByInstanceID(context.Background(), []string{"testinstance"})
+			Expect(err).Should(HaveOccurred())
+			Expect(requests.Load()).Should(BeEquivalentTo(3))
+		})
+	})
+
+	Context("the client error is returned with status 500", func() {
+		BeforeEach(func() {
+			handler = failThen(10, http.StatusInternalServerError, "Client.InvalidParameterNotFound.Instance")
+		})
+
+		It("does not retry", func() {
+			_, err := testNifcloudAPIClient.DescribeInstancesByInstanceID(context.Background(), []string{"testinstance"})
+			Expect(err).Should(HaveOccurred())
+			Expect(requests.Load()).Should(BeEquivalentTo(1))
+		})
+	})
+
+	Context("the context deadline is exceeded while retrying", func() {
+		BeforeEach(func() {
+			handler = failThen(1000, http.StatusServiceUnavailable, "Server.InternalError")
+		})
+
+		It("stop retrying", func() {
+			testNifcloudAPIClient = nifcloud.NewNIFCLOUDAPIClientWithCloudConfig("testkey", "testsecretkey", &nifcloud.CloudConfig{
+				Global: nifcloud.GlobalConfig{Region: "jp-east-1", Endpoint: ts.URL},
+				Retry: nifcloud.RetryConfig{
+					MaxAttempts: 1000,
+					MaxBackoff:  metav1.Duration{Duration: time.Minute},
+				},
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			_, err := testNifcloudAPIClient.DescribeInstancesByInstanceID(ctx, []string{"testinstance"})
+			Expect(err).Should(HaveOccurred())
+			Expect(time.Since(start)).Should(BeNumerically("<", 5*time.Second))
+		})
+	})
+})
@@ -0,0 +1,233 @@
+package nifcloud
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	cloudprovider "k8s.io/cloud-provider"
+	"k8s.io/klog/v2"
+)
+
+var _ cloudprovider.Routes = &Cloud{}
+
+func (rc RouteConfig) validate() error {
+	if rc.RouterName == "" {
+		if len(rc.ClusterCIDRs) > 0 || rc.NetworkID != "" {
+			return fmt.Errorf("routerName is required to manage routes")
+		}
+		return nil
+	}
+	if len(rc.ClusterCIDRs) == 0 {
+		return fmt.Errorf("clusterCIDRs is required with routerName")
+	}
+	for _, cidr := range rc.ClusterCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("clusterCIDRs has invalid CIDR %q: %w", cidr, err)
+		}
+	}
+	if rc.NetworkID != "" && !strings.HasPrefix(rc.NetworkID, "net-") {
+		return fmt.Errorf("networkID %q is not a network id", rc.NetworkID)
+	}
+	return nil
+}
+
+// routeNetworkID returns the network of the next hop addresses
+func (c *Cloud) routeNetworkID() string {
+	if c.config.Route.NetworkID != "" {
+		return c.config.Route.NetworkID
+	}
+	return commonPrivateNetworkID
+}
+
+// isClusterRoute returns true if the destination is in the cluster CIDRs, that is, the route is owned by this cluster
+func (c *Cloud) isClusterRoute(destinationCIDR string) bool {
+	_, destination, err := net.ParseCIDR(destinationCIDR)
+	if err != nil {
+		return false
+	}
+	destinationOnes, _ := destination.Mask.Size()
+	for _, cidr := range c.config.Route.ClusterCIDRs {
+		_, cluster, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		clusterOnes, clusterBits := cluster.Mask.Size()
+		_, destinationBits := destination.Mask.Size()
+		if clusterBits == destinationBits && clusterOnes <= destinationOnes && cluster.Contains(destination.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// routeName returns the name of the route, prefixed with the cluster name
+func routeName(clusterName, destinationCIDR string) string {
+	return fmt.Sprintf("%s-%s", clusterName, destinationCIDR)
+}
+
+// routeNextHop returns the address of the instance in the route network
+func (c *Cloud) routeNextHop(instance *Instance) (string, error) {
+	networkID := c.routeNetworkID()
+	networkInterface, ok := lo.Find(instanceNetworkInterfaces(*instance), func(ni InstanceNetworkInterface) bool {
+		return ni.NetworkID == networkID && ni.IPAddress != ""
+	})
+	if !ok {
+		return "", fmt.Errorf("instance %s has no address in network %s", instance.InstanceID, networkID)
+	}
+	return networkInterface.IPAddress, nil
+}
+
+// ListRoutes lists all managed routes that belong to the specified clusterName
+func (c *Cloud) ListRoutes(ctx context.Context, clusterName string) ([]*cloudprovider.Route, error) {
+	routeTable, err := c.client.DescribeRouterRouteTable(ctx, c.config.Route.RouterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe route table of router %q: %w", c.config.Route.RouterName, err)
+	}
+
+	nodeNames, err := c.nodeNamesByNextHop(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := []*cloudprovider.Route{}
+	for _, route := range routeTable.Routes {
+		if !c.isClusterRoute(route.DestinationCIDR) {
+			continue
+		}
+		nodeName, ok := nodeNames[route.IPAddress]
+		routes = append(routes, &cloudprovider.Route{
+			Name:            routeName(clusterName, route.DestinationCIDR),
+			TargetNode:      nodeName,
+			DestinationCIDR: route.DestinationCIDR,
+			Blackhole:       !ok,
+		})
+	}
+
+	return routes, nil
+}
+
+// nodeNamesByNextHop returns the node names keyed by the addresses of their instances in the route network
+func (c *Cloud) nodeNamesByNextHop(ctx context.Context) (map[string]types.NodeName, error) {
+	if c.kubeClient == nil {
+		return nil, fmt.Errorf("could not list nodes: kubernetes client is not initialized")
+	}
+	nodes, err := c.kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	instances, err := c.client.DescribeInstances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instances: %w", err)
+	}
+	instancesByUniqueID := lo.KeyBy(instances, func(instance Instance) string { return instance.InstanceUniqueID })
+
+	nodeNames := map[string]types.NodeName{}
+	for _, node := range nodes.Items {
+		if node.Spec.ProviderID == "" || c.isForeignNode(&node) {
+			continue
+		}
+		instanceUniqueID, err := getInstanceUniqueIDFromProviderID(node.Spec.ProviderID)
+		if err != nil {
+			continue
+		}
+		instance, ok := instancesByUniqueID[instanceUniqueID]
+		if !ok {
+			continue
+		}
+		if nextHop, err := c.routeNextHop(&instance); err == nil {
+			nodeNames[nextHop] = types.NodeName(node.Name)
+		}
+	}
+
+	return nodeNames, nil
+}
+
+// CreateRoute creates the described managed route
+// route.Name will be ignored, although the cloud-provider may use nameHint
+// to create a more user-meaningful name.
+func (c *Cloud) CreateRoute(ctx context.Context, clusterName string, nameHint string, route *cloudprovider.Route) error {
+	if !c.isClusterRoute(route.DestinationCIDR) {
+		return fmt.Errorf("destination %s of route to node %q is not in route.clusterCIDRs %v", route.DestinationCIDR, route.TargetNode, c.config.Route.ClusterCIDRs)
+	}
+
+	instance, err := c.instanceByNodeName(ctx, route.TargetNode)
+	if err != nil {
+		return fmt.Errorf("failed to get instance of node %q: %w", route.TargetNode, err)
+	}
+	nextHop, err := c.routeNextHop(instance)
+	if err != nil {
+		return err
+	}
+
+	// the router does not accept changes while the previous change is being applied
+	c.routesLock.Lock()
+	defer c.routesLock.Unlock()
+
+	routeTable, err := c.client.DescribeRouterRouteTable(ctx, c.config.Route.RouterName)
+	if err != nil {
+		return fmt.Errorf("failed to describe route table of router %q: %w", c.config.Route.RouterName, err)
+	}
+	if existing, ok := lo.Find(routeTable.Routes, func(r Route) bool { return r.DestinationCIDR == route.DestinationCIDR }); ok {
+		if existing.IPAddress == nextHop {
+			return nil
+		}
+		klog.Infof("Changing next hop of route %s from %s to %s", route.DestinationCIDR, existing.IPAddress, nextHop)
+		if err := c.deleteRoute(ctx, routeTable.RouteTableID, route.DestinationCIDR); err != nil {
+			return err
+		}
+	}
+
+	klog.Infof("Creating route %s via %s (node %q) in router %q", route.DestinationCIDR, nextHop, route.TargetNode, c.config.Route.RouterName)
+	newRoute := &Route{
+		DestinationCIDR: route.DestinationCIDR,
+		IPAddress:       nextHop,
+		NetworkID:       c.routeNetworkID(),
+	}
+	if err := c.client.CreateRoute(ctx, routeTable.RouteTableID, newRoute); err != nil {
+		return err
+	}
+	if err := c.client.WaitRouterApplied(ctx, c.config.Route.RouterName); err != nil {
+		return fmt.Errorf("failed to wait for route %s to be applied: %w", route.DestinationCIDR, err)
+	}
+
+	return nil
+}
+
+// DeleteRoute deletes the specified managed route
+// Route should be as returned by ListRoutes
+func (c *Cloud) DeleteRoute(ctx context.Context, clusterName string, route *cloudprovider.Route) error {
+	if !c.isClusterRoute(route.DestinationCIDR) {
+		return fmt.Errorf("destination %s of route is not in route.clusterCIDRs %v, refusing to delete it", route.DestinationCIDR, c.config.Route.ClusterCIDRs)
+	}
+
+	c.routesLock.Lock()
+	defer c.routesLock.Unlock()
+
+	routeTable, err := c.client.DescribeRouterRouteTable(ctx, c.config.Route.RouterName)
+	if err != nil {
+		return fmt.Errorf("failed to describe route table of router %q: %w", c.config.Route.RouterName, err)
+	}
+	if !lo.ContainsBy(routeTable.Routes, func(r Route) bool { return r.DestinationCIDR == route.DestinationCIDR }) {
+		klog.Infof("Route %s is already deleted", route.DestinationCIDR)
+		return nil
+	}
+
+	klog.Infof("Deleting route %s (node %q) in router %q", route.DestinationCIDR, route.TargetNode, c.config.Route.RouterName)
+	return c.deleteRoute(ctx, routeTable.RouteTableID, route.DestinationCIDR)
+}
+
+func (c *Cloud) deleteRoute(ctx context.Context, routeTableID, destinationCIDR string) error {
+	if err := c.client.DeleteRoute(ctx, routeTableID, destinationCIDR); err != nil {
+		return err
+	}
+	if err := c.client.WaitRouterApplied(ctx, c.config.Route.RouterName); err != nil {
+		return fmt.Errorf("failed to wait for route %s to be deleted: %w", destinationCIDR, err)
+	}
+	return nil
+}
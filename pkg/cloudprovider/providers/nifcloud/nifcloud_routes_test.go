@@ -0,0 +1,232 @@
+package nifcloud_test
+
+import (
+	"context"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	cloudprovider "k8s.io/cloud-provider"
+)
+
+var _ = Describe("Routes", func() {
+	var ctrl *gomock.Controller
+	testRouterName := "router01"
+	testRouteTableID := "rtb-abcd1234"
+	testClusterName := "testcluster"
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	newCloud := func(client nifcloud.CloudAPIClient, nodes ...*v1.Node) *nifcloud.Cloud {
+		kubeClient := fake.NewSimpleClientset()
+		for _, node := range nodes {
+			_, err := kubeClient.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+		cloud := &nifcloud.Cloud{}
+		cloud.SetClient(client)
+		cloud.SetKubeClient(kubeClient)
+		cloud.SetConfig(nifcloud.CloudConfig{
+			Route: nifcloud.RouteConfig{
+				RouterName:   testRouterName,
+				ClusterCIDRs: []string{"10.244.0.0/16"},
+			},
+		})
+		return cloud
+	}
+
+	testNode := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "testinstance"},
+		Spec:       v1.NodeSpec{ProviderID: "nifcloud:///east-11/i-abcd1234"},
+	}
+
+	Context("router is not configured", func() {
+		It("does not support routes", func() {
+			cloud := &nifcloud.Cloud{}
+			_, supported := cloud.Routes()
+			Expect(supported).Should(BeFalse())
+		})
+	})
+
+	Describe("ListRoutes", func() {
+		It("return the routes in the cluster CIDRs", func() {
+			ctx := context.Background()
+			testInstance := *helper.NewTestInstanceWithNetworkInterfaces()
+
+			c := nifcloud.NewMockCloudAPIClient(ctrl)
+			c.EXPECT().
+				DescribeRouterRouteTable(gomock.Any(), testRouterName).
+				Return(&nifcloud.RouteTable{
+					RouteTableID: testRouteTableID,
+					Routes: []nifcloud.Route{
+						{DestinationCIDR: "10.244.1.0/24", IPAddress: testInstance.PrivateIPAddress},
+						{DestinationCIDR: "10.244.2.0/24", IPAddress: "10.100.0.99"},
+						{DestinationCIDR: "10.245.1.0/24", IPAddress: testInstance.PrivateIPAddress},
+					},
+				}, nil).
+				Times(1)
+			c.EXPECT().
+				DescribeInstances(gomock.Any()).
+				Return([]nifcloud.Instance{testInstance}, nil).
+				Times(1)
+
+			cloud := newCloud(c, testNode)
+			routes, supported := cloud.Routes()
+			Expect(supported).Should(BeTrue())
+
+			gotRoutes, err := routes.ListRoutes(ctx, testClusterName)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(gotRoutes).Should(Equal([]*cloudprovider.Route{
+				{
+					Name:            "testcluster-10.244.1.0/24",
+					TargetNode:      "testinstance",
+					DestinationCIDR: "10.244.1.0/24",
+				},
+				{
+					Name:            "testcluster-10.244.2.0/24",
+					DestinationCIDR: "10.244.2.0/24",
+					Blackhole:       true,
+				},
+			}))
+		})
+	})
+
+	Describe("CreateRoute", func() {
+		Context("the route does not exist", func() {
+			It("create the route to the private IP address of the node and wait", func() {
+				ctx := context.Background()
+				testInstance := helper.NewTestInstanceWithNetworkInterfaces()
+
+				c := nifcloud.NewMockCloudAPIClient(ctrl)
+				c.EXPECT().
+					DescribeInstancesByInstanceID(gomock.Any(), []string{"testinstance"}).
+					Return([]nifcloud.Instance{*testInstance}, nil).
+					Times(1)
+				c.EXPECT().
+					DescribeRouterRouteTable(gomock.Any(), testRouterName).
+					Return(&nifcloud.RouteTable{RouteTableID: testRouteTableID}, nil).
+					Times(1)
+				gomock.InOrder(
+					c.EXPECT().
+						CreateRoute(gomock.Any(), testRouteTableID, &nifcloud.Route{
+							DestinationCIDR: "10.244.1.0/24",
+							IPAddress:       testInstance.PrivateIPAddress,
+							NetworkID:       "net-COMMON_PRIVATE",
+						}).
+						Return(nil).
+						Times(1),
+					c.EXPECT().
+						WaitRouterApplied(gomock.Any(), testRouterName).
+						Return(nil).
+						Times(1),
+				)
+
+				cloud := newCloud(c)
+				err := cloud.CreateRoute(ctx, testClusterName, "", &cloudprovider.Route{
+					TargetNode:      "testinstance",
+					DestinationCIDR: "10.244.1.0/24",
+				})
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("the route already exists", func() {
+			It("does nothing", func() {
+				ctx := context.Background()
+				testInstance := helper.NewTestInstanceWithNetworkInterfaces()
+
+				c := nifcloud.NewMockCloudAPIClient(ctrl)
+				c.EXPECT().
+					DescribeInstancesByInstanceID(gomock.Any(), []string{"testinstance"}).
+					Return([]nifcloud.Instance{*testInstance}, nil).
+					Times(1)
+				c.EXPECT().
+					DescribeRouterRouteTable(gomock.Any(), testRouterName).
+					Return(&nifcloud.RouteTable{
+						RouteTableID: testRouteTableID,
+						Routes:       []nifcloud.Route{{DestinationCIDR: "10.244.1.0/24", IPAddress: testInstance.PrivateIPAddress}},
+					}, nil).
+					Times(1)
+
+				cloud := newCloud(c)
+				err := cloud.CreateRoute(ctx, testClusterName, "", &cloudprovider.Route{
+					TargetNode:      "testinstance",
+					DestinationCIDR: "10.244.1.0/24",
+				})
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("the destination is not in the cluster CIDRs", func() {
+			It("return error without calling API", func() {
+				ctx := context.Background()
+
+				cloud := newCloud(nifcloud.NewMockCloudAPIClient(ctrl))
+				err := cloud.CreateRoute(ctx, testClusterName, "", &cloudprovider.Route{
+					TargetNode:      "testinstance",
+					DestinationCIDR: "10.245.1.0/24",
+				})
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("DeleteRoute", func() {
+		Context("the route exists", func() {
+			It("delete the route and wait", func() {
+				ctx := context.Background()
+
+				c := nifcloud.NewMockCloudAPIClient(ctrl)
+				c.EXPECT().
+					DescribeRouterRouteTable(gomock.Any(), testRouterName).
+					Return(&nifcloud.RouteTable{
+						RouteTableID: testRouteTableID,
+						Routes:       []nifcloud.Route{{DestinationCIDR: "10.244.2.0/24", IPAddress: "10.100.0.99"}},
+					}, nil).
+					Times(1)
+				gomock.InOrder(
+					c.EXPECT().
+						DeleteRoute(gomock.Any(), testRouteTableID, "10.244.2.0/24").
+						Return(nil).
+						Times(1),
+					c.EXPECT().
+						WaitRouterApplied(gomock.Any(), testRouterName).
+						Return(nil).
+						Times(1),
+				)
+
+				cloud := newCloud(c)
+				err := cloud.DeleteRoute(ctx, testClusterName, &cloudprovider.Route{
+					DestinationCIDR: "10.244.2.0/24",
+					Blackhole:       true,
+				})
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Context("the destination is not in the cluster CIDRs", func() {
+			It("refuse to delete the route of another cluster", func() {
+				ctx := context.Background()
+
+				cloud := newCloud(nifcloud.NewMockCloudAPIClient(ctrl))
+				err := cloud.DeleteRoute(ctx, testClusterName, &cloudprovider.Route{
+					DestinationCIDR: "10.245.1.0/24",
+				})
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+	})
+})
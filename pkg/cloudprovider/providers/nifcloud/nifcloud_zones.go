@@ -11,24 +11,12 @@ import (
 
 // GetZone returns the Zone containing the current failure zone and locality region that the program is running in
 func (c *Cloud) GetZone(ctx context.Context) (cloudprovider.Zone, error) {
-	instanceID := os.Getenv("NODE_NAME")
-	if instanceID == "" {
-		return cloudprovider.Zone{}, fmt.Errorf("could not get instance id for this node. environment variable 'NODE_NAME' is empty")
+	nodeName := os.Getenv("NODE_NAME")
+	if nodeName == "" {
+		return cloudprovider.Zone{}, fmt.Errorf("could not get node name for this node. environment variable 'NODE_NAME' is empty")
 	}
 
-	instances, err := c.client.DescribeInstancesByInstanceID(ctx, []string{instanceID})
-	if err != nil {
-		return cloudprovider.Zone{}, fmt.Errorf("cloud not fetch instance info for %q: %w", instanceID, err)
-	}
-
-	if err := isSingleInstance(instances, instanceID); err != nil {
-		return cloudprovider.Zone{}, err
-	}
-
-	return cloudprovider.Zone{
-		FailureDomain: instances[0].Zone,
-		Region:        c.region,
-	}, nil
+	return c.GetZoneByNodeName(ctx, types.NodeName(nodeName))
 }
 
 // GetZoneByProviderID returns the Zone containing the current zone and locality region of the node specified by providerID
@@ -55,18 +43,13 @@ func (c *Cloud) GetZoneByProviderID(ctx context.Context, providerID string) (clo
 
 // GetZoneByNodeName returns the Zone containing the current zone and locality region of the node specified by node name
 func (c *Cloud) GetZoneByNodeName(ctx context.Context, name types.NodeName) (cloudprovider.Zone, error) {
-	instanceID := string(name)
-	instances, err := c.client.DescribeInstancesByInstanceID(ctx, []string{instanceID})
+	instance, err := c.instanceByNodeName(ctx, name)
 	if err != nil {
-		return cloudprovider.Zone{}, fmt.Errorf("cloud not fetch instance info for %q: %w", instanceID, err)
-	}
-
-	if err := isSingleInstance(instances, instanceID); err != nil {
 		return cloudprovider.Zone{}, err
 	}
 
 	return cloudprovider.Zone{
-		FailureDomain: instances[0].Zone,
+		FailureDomain: instance.Zone,
 		Region:        c.region,
 	}, nil
 }
@@ -89,7 +89,7 @@ var _ = Describe("GetZone", func() {
 				nodeName := "testinstance"
 				expectedZone := cloudprovider.Zone{}
 
-				notFoundErr := helper.NewMockAPIError(nifcloud.ExportErrorCodeInstanceNotFound)
+				notFoundErr := helper.NewMockAPIError(helper.ErrorCodeInstanceNotFound)
 				c := nifcloud.NewMockCloudAPIClient(ctrl)
 				c.EXPECT().
 					DescribeInstancesByInstanceID(gomock.Any(), []string{nodeName}).
@@ -178,7 +178,7 @@ var _ = Describe("GetZoneByProviderID", func() {
 			testInstanceUniqueID := "i-abcd1234"
 			expectedZone := cloudprovider.Zone{}
 
-			notFoundErr := helper.NewMockAPIError(nifcloud.ExportErrorCodeInstanceNotFound)
+			notFoundErr := helper.NewMockAPIError(helper.ErrorCodeInstanceNotFound)
 			c := nifcloud.NewMockCloudAPIClient(ctrl)
 			c.EXPECT().
 				DescribeInstancesByInstanceUniqueID(gomock.Any(), []string{testInstanceUniqueID}).
@@ -265,7 +265,7 @@ var _ = Describe("GetZoneByNodeName", func() {
 			nodeName := "testinstance"
 			expectedZone := cloudprovider.Zone{}
 
-			notFoundErr := helper.NewMockAPIError(nifcloud.ExportErrorCodeInstanceNotFound)
+			notFoundErr := helper.NewMockAPIError(helper.ErrorCodeInstanceNotFound)
 			c := nifcloud.NewMockCloudAPIClient(ctrl)
 			c.EXPECT().
 				DescribeInstancesByInstanceID(gomock.Any(), []string{nodeName}).
@@ -67,6 +67,20 @@ func (mr *MockCloudAPIClientMockRecorder) ConfigureElasticLoadBalancerHealthChec
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigureElasticLoadBalancerHealthCheck", reflect.TypeOf((*MockCloudAPIClient)(nil).ConfigureElasticLoadBalancerHealthCheck), ctx, elasticLoadBalancer)
 }
 
+// ConfigureHealthCheck mocks base method.
+func (m *MockCloudAPIClient) ConfigureHealthCheck(ctx context.Context, loadBalancer *LoadBalancer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfigureHealthCheck", ctx, loadBalancer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfigureHealthCheck indicates an expected call of ConfigureHealthCheck.
+func (mr *MockCloudAPIClientMockRecorder) ConfigureHealthCheck(ctx, loadBalancer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigureHealthCheck", reflect.TypeOf((*MockCloudAPIClient)(nil).ConfigureHealthCheck), ctx, loadBalancer)
+}
+
 // CreateElasticLoadBalancer mocks base method.
 func (m *MockCloudAPIClient) CreateElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer) (string, error) {
 	m.ctrl.T.Helper()
@@ -97,6 +111,20 @@ func (mr *MockCloudAPIClientMockRecorder) CreateLoadBalancer(ctx, loadBalancer a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoadBalancer", reflect.TypeOf((*MockCloudAPIClient)(nil).CreateLoadBalancer), ctx, loadBalancer)
 }
 
+// CreateRoute mocks base method.
+func (m *MockCloudAPIClient) CreateRoute(ctx context.Context, routeTableID string, route *Route) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRoute", ctx, routeTableID, route)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRoute indicates an expected call of CreateRoute.
+func (mr *MockCloudAPIClientMockRecorder) CreateRoute(ctx, routeTableID, route any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRoute", reflect.TypeOf((*MockCloudAPIClient)(nil).CreateRoute), ctx, routeTableID, route)
+}
+
 // DeleteElasticLoadBalancer mocks base method.
 func (m *MockCloudAPIClient) DeleteElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer) error {
 	m.ctrl.T.Helper()
@@ -125,6 +153,20 @@ func (mr *MockCloudAPIClientMockRecorder) DeleteLoadBalancer(ctx, loadBalancer a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoadBalancer", reflect.TypeOf((*MockCloudAPIClient)(nil).DeleteLoadBalancer), ctx, loadBalancer)
 }
 
+// DeleteRoute mocks base method.
+func (m *MockCloudAPIClient) DeleteRoute(ctx context.Context, routeTableID, destinationCIDR string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRoute", ctx, routeTableID, destinationCIDR)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRoute indicates an expected call of DeleteRoute.
+func (mr *MockCloudAPIClientMockRecorder) DeleteRoute(ctx, routeTableID, destinationCIDR any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRoute", reflect.TypeOf((*MockCloudAPIClient)(nil).DeleteRoute), ctx, routeTableID, destinationCIDR)
+}
+
 // DeregisterInstancesFromElasticLoadBalancer mocks base method.
 func (m *MockCloudAPIClient) DeregisterInstancesFromElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer, instances []Instance) error {
 	m.ctrl.T.Helper()
@@ -153,6 +195,36 @@ func (mr *MockCloudAPIClientMockRecorder) DeregisterInstancesFromLoadBalancer(ct
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeregisterInstancesFromLoadBalancer", reflect.TypeOf((*MockCloudAPIClient)(nil).DeregisterInstancesFromLoadBalancer), ctx, loadBalancer, instances)
 }
 
+// DescribeAllElasticLoadBalancers mocks base method.
+func (m *MockCloudAPIClient) DescribeAllElasticLoadBalancers(ctx context.Context) ([]ElasticLoadBalancer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeAllElasticLoadBalancers", ctx)
+	ret0, _ := ret[0].([]ElasticLoadBalancer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeAllElasticLoadBalancers indicates an expected call of DescribeAllElasticLoadBalancers.
+func (mr *MockCloudAPIClientMockRecorder) DescribeAllElasticLoadBalancers(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeAllElasticLoadBalancers", reflect.TypeOf((*MockCloudAPIClient)(nil).DescribeAllElasticLoadBalancers), ctx)
+}
+
+// DescribeAllLoadBalancers mocks base method.
+func (m *MockCloudAPIClient) DescribeAllLoadBalancers(ctx context.Context) ([]LoadBalancer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeAllLoadBalancers", ctx)
+	ret0, _ := ret[0].([]LoadBalancer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeAllLoadBalancers indicates an expected call of DescribeAllLoadBalancers.
+func (mr *MockCloudAPIClientMockRecorder) DescribeAllLoadBalancers(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeAllLoadBalancers", reflect.TypeOf((*MockCloudAPIClient)(nil).DescribeAllLoadBalancers), ctx)
+}
+
 // DescribeElasticLoadBalancers mocks base method.
 func (m *MockCloudAPIClient) DescribeElasticLoadBalancers(ctx context.Context, name string) ([]ElasticLoadBalancer, error) {
 	m.ctrl.T.Helper()
@@ -168,6 +240,21 @@ func (mr *MockCloudAPIClientMockRecorder) DescribeElasticLoadBalancers(ctx, name
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeElasticLoadBalancers", reflect.TypeOf((*MockCloudAPIClient)(nil).DescribeElasticLoadBalancers), ctx, name)
 }
 
+// DescribeInstances mocks base method.
+func (m *MockCloudAPIClient) DescribeInstances(ctx context.Context) ([]Instance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeInstances", ctx)
+	ret0, _ := ret[0].([]Instance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeInstances indicates an expected call of DescribeInstances.
+func (mr *MockCloudAPIClientMockRecorder) DescribeInstances(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeInstances", reflect.TypeOf((*MockCloudAPIClient)(nil).DescribeInstances), ctx)
+}
+
 // DescribeInstancesByInstanceID mocks base method.
 func (m *MockCloudAPIClient) DescribeInstancesByInstanceID(ctx context.Context, instanceIDs []string) ([]Instance, error) {
 	m.ctrl.T.Helper()
@@ -213,6 +300,21 @@ func (mr *MockCloudAPIClientMockRecorder) DescribeLoadBalancers(ctx, name any) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeLoadBalancers", reflect.TypeOf((*MockCloudAPIClient)(nil).DescribeLoadBalancers), ctx, name)
 }
 
+// DescribeRouterRouteTable mocks base method.
+func (m *MockCloudAPIClient) DescribeRouterRouteTable(ctx context.Context, routerName string) (*RouteTable, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeRouterRouteTable", ctx, routerName)
+	ret0, _ := ret[0].(*RouteTable)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeRouterRouteTable indicates an expected call of DescribeRouterRouteTable.
+func (mr *MockCloudAPIClientMockRecorder) DescribeRouterRouteTable(ctx, routerName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeRouterRouteTable", reflect.TypeOf((*MockCloudAPIClient)(nil).DescribeRouterRouteTable), ctx, routerName)
+}
+
 // DescribeSecurityGroupsByInstanceIDs mocks base method.
 func (m *MockCloudAPIClient) DescribeSecurityGroupsByInstanceIDs(ctx context.Context, instanceIDs []string) ([]SecurityGroup, error) {
 	m.ctrl.T.Helper()
@@ -312,6 +414,90 @@ func (mr *MockCloudAPIClientMockRecorder) SetFilterForLoadBalancer(ctx, loadBala
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFilterForLoadBalancer", reflect.TypeOf((*MockCloudAPIClient)(nil).SetFilterForLoadBalancer), ctx, loadBalancer, filters)
 }
 
+// UpdateElasticLoadBalancer mocks base method.
+func (m *MockCloudAPIClient) UpdateElasticLoadBalancer(ctx context.Context, loadBalancer *ElasticLoadBalancer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateElasticLoadBalancer", ctx, loadBalancer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateElasticLoadBalancer indicates an expected call of UpdateElasticLoadBalancer.
+func (mr *MockCloudAPIClientMockRecorder) UpdateElasticLoadBalancer(ctx, loadBalancer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateElasticLoadBalancer", reflect.TypeOf((*MockCloudAPIClient)(nil).UpdateElasticLoadBalancer), ctx, loadBalancer)
+}
+
+// UpdateElasticLoadBalancerName mocks base method.
+func (m *MockCloudAPIClient) UpdateElasticLoadBalancerName(ctx context.Context, name, newName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateElasticLoadBalancerName", ctx, name, newName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateElasticLoadBalancerName indicates an expected call of UpdateElasticLoadBalancerName.
+func (mr *MockCloudAPIClientMockRecorder) UpdateElasticLoadBalancerName(ctx, name, newName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateElasticLoadBalancerName", reflect.TypeOf((*MockCloudAPIClient)(nil).UpdateElasticLoadBalancerName), ctx, name, newName)
+}
+
+// UpdateLoadBalancer mocks base method.
+func (m *MockCloudAPIClient) UpdateLoadBalancer(ctx context.Context, loadBalancer *LoadBalancer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLoadBalancer", ctx, loadBalancer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLoadBalancer indicates an expected call of UpdateLoadBalancer.
+func (mr *MockCloudAPIClientMockRecorder) UpdateLoadBalancer(ctx, loadBalancer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLoadBalancer", reflect.TypeOf((*MockCloudAPIClient)(nil).UpdateLoadBalancer), ctx, loadBalancer)
+}
+
+// UpdateLoadBalancerName mocks base method.
+func (m *MockCloudAPIClient) UpdateLoadBalancerName(ctx context.Context, name, newName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLoadBalancerName", ctx, name, newName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLoadBalancerName indicates an expected call of UpdateLoadBalancerName.
+func (mr *MockCloudAPIClientMockRecorder) UpdateLoadBalancerName(ctx, name, newName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLoadBalancerName", reflect.TypeOf((*MockCloudAPIClient)(nil).UpdateLoadBalancerName), ctx, name, newName)
+}
+
+// WaitElasticLoadBalancerApplied mocks base method.
+func (m *MockCloudAPIClient) WaitElasticLoadBalancerApplied(ctx context.Context, elasticLoadBalancerName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitElasticLoadBalancerApplied", ctx, elasticLoadBalancerName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitElasticLoadBalancerApplied indicates an expected call of WaitElasticLoadBalancerApplied.
+func (mr *MockCloudAPIClientMockRecorder) WaitElasticLoadBalancerApplied(ctx, elasticLoadBalancerName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitElasticLoadBalancerApplied", reflect.TypeOf((*MockCloudAPIClient)(nil).WaitElasticLoadBalancerApplied), ctx, elasticLoadBalancerName)
+}
+
+// WaitRouterApplied mocks base method.
+func (m *MockCloudAPIClient) WaitRouterApplied(ctx context.Context, routerName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitRouterApplied", ctx, routerName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitRouterApplied indicates an expected call of WaitRouterApplied.
+func (mr *MockCloudAPIClientMockRecorder) WaitRouterApplied(ctx, routerName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitRouterApplied", reflect.TypeOf((*MockCloudAPIClient)(nil).WaitRouterApplied), ctx, routerName)
+}
+
 // WaitSecurityGroupApplied mocks base method.
 func (m *MockCloudAPIClient) WaitSecurityGroupApplied(ctx context.Context, securityGroupName string) error {
 	m.ctrl.T.Helper()
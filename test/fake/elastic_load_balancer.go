@@ -0,0 +1,365 @@
+package fake
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+	"github.com/samber/lo"
+)
+
+const (
+	commonGlobalNetworkID      = "net-COMMON_GLOBAL"
+	commonPrivateNetworkID     = "net-COMMON_PRIVATE"
+	elasticLoadBalancerStateOK = "available"
+)
+
+type elasticLoadBalancer struct {
+	id                string
+	name              string
+	vip               string
+	accountingType    string
+	networkVolume     int32
+	availabilityZone  string
+	networkInterfaces []nifcloud.NetworkInterface
+	listeners         []*listener
+}
+
+type describeElasticLoadBalancersResponse struct {
+	XMLName      xml.Name                         `xml:"NiftyDescribeElasticLoadBalancersResponse"`
+	Descriptions []elasticLoadBalancerDescription `xml:"NiftyDescribeElasticLoadBalancersResult>ElasticLoadBalancerDescriptions>member"`
+	RequestID    string                           `xml:"ResponseMetadata>RequestId"`
+}
+
+type elasticLoadBalancerDescription struct {
+	ElasticLoadBalancerID   string                           `xml:"ElasticLoadBalancerId"`
+	ElasticLoadBalancerName string                           `xml:"ElasticLoadBalancerName"`
+	DNSName                 string                           `xml:"DNSName"`
+	NetworkVolume           string                           `xml:"NetworkVolume"`
+	State                   string                           `xml:"State"`
+	AccountingType          string                           `xml:"AccountingType"`
+	Listeners               []elasticLoadBalancerListener    `xml:"ElasticLoadBalancerListenerDescriptions>member"`
+	AvailabilityZones       []string                         `xml:"AvailabilityZones>member"`
+	NetworkInterfaces       []elasticLoadBalancerNetworkItem `xml:"NetworkInterfaces>member"`
+}
+
+type elasticLoadBalancerListener struct {
+	Protocol                string           `xml:"Listener>Protocol"`
+	ElasticLoadBalancerPort int32            `xml:"Listener>ElasticLoadBalancerPort"`
+	InstancePort            int32            `xml:"Listener>InstancePort"`
+	BalancingType           int32            `xml:"Listener>BalancingType"`
+	Instances               []instanceMember `xml:"Listener>Instances>member"`
+	HealthCheck             healthCheck      `xml:"Listener>HealthCheck"`
+}
+
+type elasticLoadBalancerNetworkItem struct {
+	NetworkID         string                  `xml:"NetworkId"`
+	NetworkName       string                  `xml:"NetworkName"`
+	IPAddress         string                  `xml:"IpAddress"`
+	IsVipNetwork      bool                    `xml:"IsVipNetwork"`
+	SystemIPAddresses []systemIPAddressMember `xml:"SystemIpAddresses>member"`
+}
+
+type systemIPAddressMember struct {
+	SystemIPAddress string `xml:"SystemIpAddress"`
+}
+
+type createElasticLoadBalancerResponse struct {
+	XMLName xml.Name `xml:"NiftyCreateElasticLoadBalancerResponse"`
+	// the VIP is not returned until the elastic load balancer is created
+	DNSName   string `xml:"NiftyCreateElasticLoadBalancerResult>DNSName"`
+	RequestID string `xml:"ResponseMetadata>RequestId"`
+}
+
+// ElasticLoadBalancers returns the ports of all elastic load balancers.
+// The balancing targets are filled from the instance inventory.
+func (s *Server) ElasticLoadBalancers() []nifcloud.ElasticLoadBalancer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []nifcloud.ElasticLoadBalancer{}
+	for _, elb := range s.elasticLoadBalancers {
+		for _, l := range elb.listeners {
+			result = append(result, nifcloud.ElasticLoadBalancer{
+				AvailabilityZone:              elb.availabilityZone,
+				Name:                          elb.name,
+				VIP:                           elb.vip,
+				AccountingType:                elb.accountingType,
+				Protocol:                      l.protocol,
+				NetworkVolume:                 elb.networkVolume,
+				BalancingType:                 l.balancingType,
+				BalancingTargets:              s.instancesOf(l.instanceIDs),
+				LoadBalancerPort:              l.loadBalancerPort,
+				InstancePort:                  l.instancePort,
+				HealthCheckTarget:             l.healthCheckTarget,
+				HealthCheckInterval:           l.healthCheckInterval,
+				HealthCheckUnhealthyThreshold: l.healthCheckUnhealthyThreshold,
+				NetworkInterfaces:             append([]nifcloud.NetworkInterface{}, elb.networkInterfaces...),
+			})
+		}
+	}
+	return result
+}
+
+func (s *Server) findElasticLoadBalancer(name string) (*elasticLoadBalancer, error) {
+	elb, ok := lo.Find(s.elasticLoadBalancers, func(elb *elasticLoadBalancer) bool { return elb.name == name })
+	if !ok {
+		return nil, newAPIError(helper.ErrorCodeElasticLoadBalancerNotFound, "The ElasticLoadBalancerName '%s' does not exist.", name)
+	}
+	return elb, nil
+}
+
+func (elb *elasticLoadBalancer) findListener(protocol string, loadBalancerPort, instancePort int32) (*listener, error) {
+	l, ok := lo.Find(elb.listeners, func(l *listener) bool {
+		return l.protocol == protocol && l.loadBalancerPort == loadBalancerPort && l.instancePort == instancePort
+	})
+	if !ok {
+		return nil, newAPIError(helper.ErrorCodeElasticLoadBalancerPortNotFound,
+			"The Protocol '%s', ElasticLoadBalancerPort '%d' and InstancePort '%d' of '%s' does not exist.",
+			protocol, loadBalancerPort, instancePort, elb.name)
+	}
+	return l, nil
+}
+
+func (s *Server) findElasticLoadBalancerListener(form url.Values) (*elasticLoadBalancer, *listener, error) {
+	elb, err := s.findElasticLoadBalancer(form.Get("ElasticLoadBalancerName"))
+	if err != nil {
+		return nil, nil, err
+	}
+	l, err := elb.findListener(form.Get("Protocol"), formInt32(form, "ElasticLoadBalancerPort"), formInt32(form, "InstancePort"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return elb, l, nil
+}
+
+func (s *Server) describeElasticLoadBalancers(form url.Values) (interface{}, error) {
+	elasticLoadBalancers := s.elasticLoadBalancers
+	if names := indexedValues(form, "ElasticLoadBalancers.ElasticLoadBalancerName.%d"); len(names) > 0 {
+		elasticLoadBalancers = []*elasticLoadBalancer{}
+		for _, name := range names {
+			elb, err := s.findElasticLoadBalancer(name)
+			if err != nil {
+				return nil, err
+			}
+			elasticLoadBalancers = append(elasticLoadBalancers, elb)
+		}
+	}
+
+	res := &describeElasticLoadBalancersResponse{RequestID: s.requestID()}
+	for _, elb := range elasticLoadBalancers {
+		desc := elasticLoadBalancerDescription{
+			ElasticLoadBalancerID:   elb.id,
+			ElasticLoadBalancerName: elb.name,
+			DNSName:                 elb.vip,
+			NetworkVolume:           fmt.Sprint(elb.networkVolume),
+			// the state transitions are not emulated not to make the waiters sleep
+			State:             elasticLoadBalancerStateOK,
+			AccountingType:    elb.accountingType,
+			AvailabilityZones: []string{elb.availabilityZone},
+		}
+		for _, l := range elb.listeners {
+			desc.Listeners = append(desc.Listeners, elasticLoadBalancerListener{
+				Protocol:                l.protocol,
+				ElasticLoadBalancerPort: l.loadBalancerPort,
+				InstancePort:            l.instancePort,
+				BalancingType:           l.balancingType,
+				Instances:               s.instanceMembers(l.instanceIDs),
+				HealthCheck: healthCheck{
+					Target:             l.healthCheckTarget,
+					Interval:           l.healthCheckInterval,
+					UnhealthyThreshold: l.healthCheckUnhealthyThreshold,
+				},
+			})
+		}
+		for _, networkInterface := range elb.networkInterfaces {
+			item := elasticLoadBalancerNetworkItem{
+				NetworkID:    networkInterface.NetworkId,
+				NetworkName:  networkInterface.NetworkName,
+				IPAddress:    networkInterface.IPAddress,
+				IsVipNetwork: networkInterface.IsVipNetwork,
+			}
+			for _, systemIPAddress := range networkInterface.SystemIpAddresses {
+				item.SystemIPAddresses = append(item.SystemIPAddresses, systemIPAddressMember{SystemIPAddress: systemIPAddress})
+			}
+			desc.NetworkInterfaces = append(desc.NetworkInterfaces, item)
+		}
+		res.Descriptions = append(res.Descriptions, desc)
+	}
+	return res, nil
+}
+
+func newElasticLoadBalancerListener(form url.Values, prefix string) *listener {
+	l := &listener{
+		protocol:         form.Get(prefix + "Protocol"),
+		loadBalancerPort: formInt32(form, prefix+"ElasticLoadBalancerPort"),
+		instancePort:     formInt32(form, prefix+"InstancePort"),
+		balancingType:    formInt32(form, prefix+"BalancingType"),
+		// the default values of the API
+		healthCheckTarget:             "ICMP",
+		healthCheckInterval:           10,
+		healthCheckUnhealthyThreshold: 3,
+	}
+	if l.balancingType == 0 {
+		l.balancingType = 1
+	}
+	return l
+}
+
+func (s *Server) createElasticLoadBalancer(form url.Values) (interface{}, error) {
+	name := form.Get("ElasticLoadBalancerName")
+	if _, err := s.findElasticLoadBalancer(name); err == nil {
+		return nil, newAPIError(helper.ErrorCodeElasticLoadBalancerDuplicate, "The ElasticLoadBalancerName '%s' has already been registered.", name)
+	}
+
+	elb := &elasticLoadBalancer{
+		id:               fmt.Sprintf("elb-%08d", len(s.elasticLoadBalancers)+1),
+		name:             name,
+		accountingType:   form.Get("AccountingType"),
+		networkVolume:    formInt32(form, "NetworkVolume"),
+		availabilityZone: form.Get("AvailabilityZones.member.1"),
+		listeners:        []*listener{newElasticLoadBalancerListener(form, "Listeners.member.1.")},
+	}
+	if elb.accountingType == "" {
+		elb.accountingType = "2"
+	}
+	if elb.networkVolume == 0 {
+		elb.networkVolume = 10
+	}
+
+	for _, prefix := range indexedPrefixes(form, "NetworkInterface.%d.") {
+		networkInterface := nifcloud.NetworkInterface{
+			NetworkId:         form.Get(prefix + "NetworkId"),
+			IPAddress:         form.Get(prefix + "IpAddress"),
+			SystemIpAddresses: indexedValues(form, prefix+"SystemIpAddresses.%d.SystemIpAddress"),
+			IsVipNetwork:      form.Get(prefix+"IsVipNetwork") == "true",
+		}
+		elb.networkInterfaces = append(elb.networkInterfaces, networkInterface)
+	}
+	if len(elb.networkInterfaces) == 0 {
+		elb.networkInterfaces = []nifcloud.NetworkInterface{{NetworkId: commonGlobalNetworkID, IsVipNetwork: true}}
+	}
+	for i := range elb.networkInterfaces {
+		networkInterface := &elb.networkInterfaces[i]
+		// the addresses of the common networks are assigned by NIFCLOUD
+		if networkInterface.IPAddress == "" {
+			networkInterface.IPAddress = s.allocateIPAddress()
+		}
+		if len(networkInterface.SystemIpAddresses) == 0 {
+			networkInterface.SystemIpAddresses = []string{s.allocateIPAddress(), s.allocateIPAddress()}
+		}
+		if networkInterface.IsVipNetwork {
+			elb.vip = networkInterface.IPAddress
+		}
+	}
+
+	s.elasticLoadBalancers = append(s.elasticLoadBalancers, elb)
+
+	return &createElasticLoadBalancerResponse{RequestID: s.requestID()}, nil
+}
+
+func (s *Server) registerPortWithElasticLoadBalancer(form url.Values) (interface{}, error) {
+	elb, err := s.findElasticLoadBalancer(form.Get("ElasticLoadBalancerName"))
+	if err != nil {
+		return nil, err
+	}
+
+	l := newElasticLoadBalancerListener(form, "Listeners.member.1.")
+	if _, err := elb.findListener(l.protocol, l.loadBalancerPort, l.instancePort); err == nil {
+		return nil, newAPIError(helper.ErrorCodeElasticLoadBalancerPortDuplicate,
+			"The Protocol '%s' and ElasticLoadBalancerPort '%d' of '%s' already exist.", l.protocol, l.loadBalancerPort, elb.name)
+	}
+	elb.listeners = append(elb.listeners, l)
+
+	return &emptyResponse{}, nil
+}
+
+func (s *Server) configureElasticLoadBalancerHealthCheck(form url.Values) (interface{}, error) {
+	_, l, err := s.findElasticLoadBalancerListener(form)
+	if err != nil {
+		return nil, err
+	}
+
+	l.healthCheckTarget = form.Get("HealthCheck.Target")
+	l.healthCheckInterval = formInt32(form, "HealthCheck.Interval")
+	l.healthCheckUnhealthyThreshold = formInt32(form, "HealthCheck.UnhealthyThreshold")
+
+	return &emptyResponse{}, nil
+}
+
+func (s *Server) registerInstancesWithElasticLoadBalancer(form url.Values) (interface{}, error) {
+	elb, l, err := s.findElasticLoadBalancerListener(form)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceIDs := indexedValues(form, "Instances.member.%d.InstanceId")
+	for _, instanceID := range instanceIDs {
+		if _, ok := s.findInstance(instanceID); !ok {
+			return nil, newAPIError(helper.ErrorCodeInstanceNotFound, "The instance_id '%s' does not exist.", instanceID)
+		}
+		if lo.Contains(l.instanceIDs, instanceID) {
+			return nil, newAPIError(helper.ErrorCodeElasticLoadBalancerHavingRegisteredTarget,
+				"The instance_id '%s' has already been registered with '%s'.", instanceID, elb.name)
+		}
+	}
+	l.instanceIDs = append(l.instanceIDs, instanceIDs...)
+
+	return &emptyResponse{}, nil
+}
+
+func (s *Server) deregisterInstancesFromElasticLoadBalancer(form url.Values) (interface{}, error) {
+	elb, l, err := s.findElasticLoadBalancerListener(form)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceIDs := indexedValues(form, "Instances.member.%d.InstanceId")
+	for _, instanceID := range instanceIDs {
+		if !lo.Contains(l.instanceIDs, instanceID) {
+			return nil, newAPIError(helper.ErrorCodeInstanceNotFound,
+				"The instance_id '%s' is not registered with '%s'.", instanceID, elb.name)
+		}
+	}
+	l.instanceIDs = lo.Without(l.instanceIDs, instanceIDs...)
+
+	return &emptyResponse{}, nil
+}
+
+func (s *Server) deleteElasticLoadBalancer(form url.Values) (interface{}, error) {
+	elb, l, err := s.findElasticLoadBalancerListener(form)
+	if err != nil {
+		return nil, err
+	}
+
+	elb.listeners = lo.Without(elb.listeners, l)
+	if len(elb.listeners) == 0 {
+		s.elasticLoadBalancers = lo.Without(s.elasticLoadBalancers, elb)
+	}
+
+	return &emptyResponse{}, nil
+}
+
+func (s *Server) updateElasticLoadBalancer(form url.Values) (interface{}, error) {
+	elb, err := s.findElasticLoadBalancer(form.Get("ElasticLoadBalancerName"))
+	if err != nil {
+		return nil, err
+	}
+
+	if networkVolume := formInt32(form, "NetworkVolumeUpdate"); networkVolume != 0 {
+		elb.networkVolume = networkVolume
+	}
+	if accountingType := form.Get("AccountingTypeUpdate"); accountingType != "" {
+		elb.accountingType = accountingType
+	}
+	if name := form.Get("ElasticLoadBalancerNameUpdate"); name != "" {
+		if _, err := s.findElasticLoadBalancer(name); err == nil {
+			return nil, newAPIError(helper.ErrorCodeElasticLoadBalancerDuplicate, "The ElasticLoadBalancerName '%s' has already been registered.", name)
+		}
+		elb.name = name
+	}
+
+	return &emptyResponse{}, nil
+}
@@ -0,0 +1,140 @@
+package fake
+
+import (
+	"encoding/xml"
+	"net/url"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+	"github.com/samber/lo"
+)
+
+type describeInstancesResponse struct {
+	XMLName      xml.Name      `xml:"DescribeInstancesResponse"`
+	RequestID    string        `xml:"requestId"`
+	Reservations []reservation `xml:"reservationSet>item"`
+}
+
+type reservation struct {
+	Instances []instanceItem `xml:"instancesSet>item"`
+}
+
+type instanceItem struct {
+	InstanceID        string                 `xml:"instanceId"`
+	InstanceUniqueID  string                 `xml:"instanceUniqueId"`
+	State             string                 `xml:"instanceState>name"`
+	PrivateDNSName    string                 `xml:"privateDnsName"`
+	DNSName           string                 `xml:"dnsName"`
+	InstanceType      string                 `xml:"instanceType"`
+	Zone              string                 `xml:"placement>availabilityZone"`
+	PrivateIPAddress  string                 `xml:"privateIpAddress"`
+	IPAddress         string                 `xml:"ipAddress"`
+	Description       string                 `xml:"description"`
+	AccountingType    string                 `xml:"AccountingType"`
+	ImageID           string                 `xml:"imageId"`
+	Tenancy           string                 `xml:"tenancy"`
+	NetworkInterfaces []networkInterfaceItem `xml:"networkInterfaceSet>item"`
+}
+
+type networkInterfaceItem struct {
+	NetworkID        string `xml:"niftyNetworkId"`
+	PrivateIPAddress string `xml:"privateIpAddress,omitempty"`
+	PrivateDNSName   string `xml:"privateDnsName,omitempty"`
+	PublicIP         string `xml:"association>publicIp,omitempty"`
+	PublicDNSName    string `xml:"association>publicDnsName,omitempty"`
+}
+
+func newInstanceItem(instance nifcloud.Instance) instanceItem {
+	return instanceItem{
+		InstanceID:        instance.InstanceID,
+		InstanceUniqueID:  instance.InstanceUniqueID,
+		State:             instance.State,
+		PrivateDNSName:    instance.PrivateIPAddress,
+		DNSName:           instance.PublicIPAddress,
+		InstanceType:      instance.InstanceType,
+		Zone:              instance.Zone,
+		PrivateIPAddress:  instance.PrivateIPAddress,
+		IPAddress:         instance.PublicIPAddress,
+		Description:       instance.Description,
+		AccountingType:    instance.AccountingType,
+		ImageID:           instance.ImageID,
+		Tenancy:           instance.Tenancy,
+		NetworkInterfaces: newNetworkInterfaceItems(instance),
+	}
+}
+
+func newNetworkInterfaceItems(instance nifcloud.Instance) []networkInterfaceItem {
+	networkInterfaces := instance.NetworkInterfaces
+	if len(networkInterfaces) == 0 {
+		if instance.PublicIPAddress != "" {
+			networkInterfaces = append(networkInterfaces, nifcloud.InstanceNetworkInterface{NetworkID: commonGlobalNetworkID, IPAddress: instance.PublicIPAddress})
+		}
+		if instance.PrivateIPAddress != "" {
+			networkInterfaces = append(networkInterfaces, nifcloud.InstanceNetworkInterface{NetworkID: commonPrivateNetworkID, IPAddress: instance.PrivateIPAddress})
+		}
+	}
+
+	return lo.Map(networkInterfaces, func(networkInterface nifcloud.InstanceNetworkInterface, _ int) networkInterfaceItem {
+		if networkInterface.NetworkID == commonGlobalNetworkID {
+			return networkInterfaceItem{
+				NetworkID:     networkInterface.NetworkID,
+				PublicIP:      networkInterface.IPAddress,
+				PublicDNSName: networkInterface.DNSName,
+			}
+		}
+		return networkInterfaceItem{
+			NetworkID:        networkInterface.NetworkID,
+			PrivateIPAddress: networkInterface.IPAddress,
+			PrivateDNSName:   networkInterface.DNSName,
+		}
+	})
+}
+
+// AddInstance adds the instance to the inventory
+func (s *Server) AddInstance(instance nifcloud.Instance) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if instance.State == "" {
+		instance.State = "running"
+	}
+	s.instances = append(s.instances, instance)
+}
+
+// RemoveInstance removes the instance from the inventory as if it is deleted
+func (s *Server) RemoveInstance(instanceID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.instances = lo.Reject(s.instances, func(instance nifcloud.Instance, _ int) bool {
+		return instance.InstanceID == instanceID
+	})
+}
+
+func (s *Server) findInstance(instanceID string) (nifcloud.Instance, bool) {
+	return lo.Find(s.instances, func(instance nifcloud.Instance) bool {
+		return instance.InstanceID == instanceID
+	})
+}
+
+func (s *Server) describeInstances(form url.Values) (interface{}, error) {
+	instances := s.instances
+	if instanceIDs := indexedValues(form, "InstanceId.%d"); len(instanceIDs) > 0 {
+		instances = []nifcloud.Instance{}
+		for _, instanceID := range instanceIDs {
+			instance, ok := s.findInstance(instanceID)
+			if !ok {
+				return nil, newAPIError(helper.ErrorCodeInstanceNotFound, "The instance_id '%s' does not exist.", instanceID)
+			}
+			instances = append(instances, instance)
+		}
+	}
+
+	res := &describeInstancesResponse{RequestID: s.requestID()}
+	for _, instance := range instances {
+		res.Reservations = append(res.Reservations, reservation{
+			Instances: []instanceItem{newInstanceItem(instance)},
+		})
+	}
+	return res, nil
+}
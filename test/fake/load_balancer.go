@@ -0,0 +1,393 @@
+package fake
+
+import (
+	"encoding/xml"
+	"net/url"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+	"github.com/samber/lo"
+)
+
+const filterAnyIPAddresses = "*.*.*.*"
+
+type loadBalancer struct {
+	name           string
+	vip            string
+	accountingType string
+	networkVolume  int32
+	policyType     string
+	listeners      []*listener
+}
+
+// listener is a port of the load balancer. L4 load balancers and elastic load balancers share it.
+type listener struct {
+	protocol         string
+	loadBalancerPort int32
+	instancePort     int32
+	balancingType    int32
+
+	healthCheckTarget             string
+	healthCheckInterval           int32
+	healthCheckUnhealthyThreshold int32
+
+	instanceIDs []string
+
+	filterType string
+	filters    []string
+}
+
+type describeLoadBalancersResponse struct {
+	XMLName      xml.Name                  `xml:"DescribeLoadBalancersResponse"`
+	Descriptions []loadBalancerDescription `xml:"DescribeLoadBalancersResult>LoadBalancerDescriptions>member"`
+	RequestID    string                    `xml:"ResponseMetadata>RequestId"`
+}
+
+type loadBalancerDescription struct {
+	LoadBalancerName string                `xml:"LoadBalancerName"`
+	DNSName          string                `xml:"DNSName"`
+	NetworkVolume    int32                 `xml:"NetworkVolume"`
+	PolicyType       string                `xml:"PolicyType"`
+	Listeners        []listenerDescription `xml:"ListenerDescriptions>member"`
+	Instances        []instanceMember      `xml:"Instances>member"`
+	HealthCheck      healthCheck           `xml:"HealthCheck"`
+	Filter           filter                `xml:"Filter"`
+	AccountingType   string                `xml:"AccountingType"`
+}
+
+type listenerDescription struct {
+	LoadBalancerPort int32 `xml:"Listener>LoadBalancerPort"`
+	InstancePort     int32 `xml:"Listener>InstancePort"`
+	BalancingType    int32 `xml:"Listener>BalancingType"`
+}
+
+type instanceMember struct {
+	InstanceID       string `xml:"InstanceId"`
+	InstanceUniqueID string `xml:"InstanceUniqueId"`
+}
+
+type healthCheck struct {
+	Target             string `xml:"Target"`
+	Interval           int32  `xml:"Interval"`
+	UnhealthyThreshold int32  `xml:"UnhealthyThreshold"`
+}
+
+type filter struct {
+	FilterType  string            `xml:"FilterType"`
+	IPAddresses []ipAddressMember `xml:"IPAddresses>member"`
+}
+
+type ipAddressMember struct {
+	IPAddress string `xml:"IPAddress"`
+}
+
+type createLoadBalancerResponse struct {
+	XMLName   xml.Name `xml:"CreateLoadBalancerResponse"`
+	DNSName   string   `xml:"CreateLoadBalancerResult>DNSName"`
+	RequestID string   `xml:"ResponseMetadata>RequestId"`
+}
+
+// LoadBalancers returns the ports of all L4 load balancers.
+// The balancing targets are filled from the instance inventory.
+func (s *Server) LoadBalancers() []nifcloud.LoadBalancer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []nifcloud.LoadBalancer{}
+	for _, lb := range s.loadBalancers {
+		for _, l := range lb.listeners {
+			result = append(result, nifcloud.LoadBalancer{
+				Name:                          lb.name,
+				VIP:                           lb.vip,
+				AccountingType:                lb.accountingType,
+				NetworkVolume:                 lb.networkVolume,
+				PolicyType:                    lb.policyType,
+				BalancingType:                 l.balancingType,
+				BalancingTargets:              s.instancesOf(l.instanceIDs),
+				LoadBalancerPort:              l.loadBalancerPort,
+				InstancePort:                  l.instancePort,
+				HealthCheckTarget:             l.healthCheckTarget,
+				HealthCheckInterval:           l.healthCheckInterval,
+				HealthCheckUnhealthyThreshold: l.healthCheckUnhealthyThreshold,
+				FilterType:                    l.filterType,
+				Filters:                       append([]string{}, l.filters...),
+			})
+		}
+	}
+	return result
+}
+
+func (s *Server) instancesOf(instanceIDs []string) []nifcloud.Instance {
+	instances := []nifcloud.Instance{}
+	for _, instanceID := range instanceIDs {
+		if instance, ok := s.findInstance(instanceID); ok {
+			instances = append(instances, instance)
+		} else {
+			instances = append(instances, nifcloud.Instance{InstanceID: instanceID})
+		}
+	}
+	return instances
+}
+
+func (s *Server) instanceMembers(instanceIDs []string) []instanceMember {
+	return lo.Map(s.instancesOf(instanceIDs), func(instance nifcloud.Instance, _ int) instanceMember {
+		return instanceMember{InstanceID: instance.InstanceID, InstanceUniqueID: instance.InstanceUniqueID}
+	})
+}
+
+func (s *Server) findLoadBalancer(name string) (*loadBalancer, error) {
+	lb, ok := lo.Find(s.loadBalancers, func(lb *loadBalancer) bool { return lb.name == name })
+	if !ok {
+		return nil, newAPIError(helper.ErrorCodeLoadBalancerNotFound, "The LoadBalancerName '%s' does not exist.", name)
+	}
+	return lb, nil
+}
+
+func (lb *loadBalancer) findListener(loadBalancerPort, instancePort int32) (*listener, error) {
+	l, ok := lo.Find(lb.listeners, func(l *listener) bool {
+		return l.loadBalancerPort == loadBalancerPort && l.instancePort == instancePort
+	})
+	if !ok {
+		return nil, newAPIError(helper.ErrorCodeLoadBalancerPortNotFound,
+			"The LoadBalancerPort '%d' and InstancePort '%d' of '%s' does not exist.", loadBalancerPort, instancePort, lb.name)
+	}
+	return l, nil
+}
+
+func (s *Server) findLoadBalancerListener(form url.Values) (*loadBalancer, *listener, error) {
+	lb, err := s.findLoadBalancer(form.Get("LoadBalancerName"))
+	if err != nil {
+		return nil, nil, err
+	}
+	l, err := lb.findListener(formInt32(form, "LoadBalancerPort"), formInt32(form, "InstancePort"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return lb, l, nil
+}
+
+func (s *Server) describeLoadBalancers(form url.Values) (interface{}, error) {
+	loadBalancers := s.loadBalancers
+	if names := indexedValues(form, "LoadBalancerNames.member.%d"); len(names) > 0 {
+		loadBalancers = []*loadBalancer{}
+		for _, name := range names {
+			lb, err := s.findLoadBalancer(name)
+			if err != nil {
+				return nil, err
+			}
+			loadBalancers = append(loadBalancers, lb)
+		}
+	}
+
+	res := &describeLoadBalancersResponse{RequestID: s.requestID()}
+	for _, lb := range loadBalancers {
+		// each port is described as a load balancer
+		for _, l := range lb.listeners {
+			filterIPAddresses := []ipAddressMember{}
+			for _, ipAddress := range l.filters {
+				filterIPAddresses = append(filterIPAddresses, ipAddressMember{IPAddress: ipAddress})
+			}
+			if len(filterIPAddresses) == 0 {
+				filterIPAddresses = []ipAddressMember{{IPAddress: filterAnyIPAddresses}}
+			}
+			res.Descriptions = append(res.Descriptions, loadBalancerDescription{
+				LoadBalancerName: lb.name,
+				DNSName:          lb.vip,
+				NetworkVolume:    lb.networkVolume,
+				PolicyType:       lb.policyType,
+				Listeners: []listenerDescription{
+					{
+						LoadBalancerPort: l.loadBalancerPort,
+						InstancePort:     l.instancePort,
+						BalancingType:    l.balancingType,
+					},
+				},
+				Instances: s.instanceMembers(l.instanceIDs),
+				HealthCheck: healthCheck{
+					Target:             l.healthCheckTarget,
+					Interval:           l.healthCheckInterval,
+					UnhealthyThreshold: l.healthCheckUnhealthyThreshold,
+				},
+				Filter: filter{
+					FilterType:  l.filterType,
+					IPAddresses: filterIPAddresses,
+				},
+				AccountingType: lb.accountingType,
+			})
+		}
+	}
+	return res, nil
+}
+
+func newListener(form url.Values, prefix string) *listener {
+	l := &listener{
+		loadBalancerPort:  formInt32(form, prefix+"LoadBalancerPort"),
+		instancePort:      formInt32(form, prefix+"InstancePort"),
+		balancingType:     formInt32(form, prefix+"BalancingType"),
+		healthCheckTarget: "ICMP",
+		// the default values of the API
+		healthCheckInterval:           5,
+		healthCheckUnhealthyThreshold: 1,
+		filterType:                    "1",
+	}
+	if l.balancingType == 0 {
+		l.balancingType = 1
+	}
+	return l
+}
+
+func (s *Server) createLoadBalancer(form url.Values) (interface{}, error) {
+	name := form.Get("LoadBalancerName")
+	if _, err := s.findLoadBalancer(name); err == nil {
+		return nil, newAPIError(helper.ErrorCodeLoadBalancerDuplicate, "The LoadBalancerName '%s' has already been registered.", name)
+	}
+
+	lb := &loadBalancer{
+		name:           name,
+		vip:            s.allocateIPAddress(),
+		accountingType: form.Get("AccountingType"),
+		networkVolume:  formInt32(form, "NetworkVolume"),
+		policyType:     form.Get("PolicyType"),
+		listeners:      []*listener{newListener(form, "Listeners.member.1.")},
+	}
+	if lb.accountingType == "" {
+		lb.accountingType = "2"
+	}
+	if lb.networkVolume == 0 {
+		lb.networkVolume = 10
+	}
+	if lb.policyType == "" {
+		lb.policyType = "standard"
+	}
+	s.loadBalancers = append(s.loadBalancers, lb)
+
+	return &createLoadBalancerResponse{DNSName: lb.vip, RequestID: s.requestID()}, nil
+}
+
+func (s *Server) registerPortWithLoadBalancer(form url.Values) (interface{}, error) {
+	lb, err := s.findLoadBalancer(form.Get("LoadBalancerName"))
+	if err != nil {
+		return nil, err
+	}
+
+	l := newListener(form, "Listeners.member.1.")
+	if _, err := lb.findListener(l.loadBalancerPort, l.instancePort); err == nil {
+		return nil, newAPIError(helper.ErrorCodeLoadBalancerPortAlreadyExists,
+			"The LoadBalancerPort '%d' of '%s' already exists.", l.loadBalancerPort, lb.name)
+	}
+	lb.listeners = append(lb.listeners, l)
+
+	return &emptyResponse{}, nil
+}
+
+func (s *Server) configureHealthCheck(form url.Values) (interface{}, error) {
+	_, l, err := s.findLoadBalancerListener(form)
+	if err != nil {
+		return nil, err
+	}
+
+	l.healthCheckTarget = form.Get("HealthCheck.Target")
+	l.healthCheckInterval = formInt32(form, "HealthCheck.Interval")
+	l.healthCheckUnhealthyThreshold = formInt32(form, "HealthCheck.UnhealthyThreshold")
+
+	return &emptyResponse{}, nil
+}
+
+func (s *Server) setFilterForLoadBalancer(form url.Values) (interface{}, error) {
+	_, l, err := s.findLoadBalancerListener(form)
+	if err != nil {
+		return nil, err
+	}
+
+	if filterType := form.Get("FilterType"); filterType != "" {
+		l.filterType = filterType
+	}
+	for _, prefix := range indexedPrefixes(form, "IPAddresses.member.%d.") {
+		ipAddress := form.Get(prefix + "IPAddress")
+		if form.Get(prefix+"AddOnFilter") == "false" {
+			l.filters = lo.Without(l.filters, ipAddress)
+			continue
+		}
+		if lo.Contains(l.filters, ipAddress) {
+			return nil, newAPIError(helper.ErrorCodeIPAddressDuplicate, "The IPAddress '%s' has already been registered.", ipAddress)
+		}
+		l.filters = append(l.filters, ipAddress)
+	}
+
+	return &emptyResponse{}, nil
+}
+
+func (s *Server) registerInstancesWithLoadBalancer(form url.Values) (interface{}, error) {
+	lb, l, err := s.findLoadBalancerListener(form)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceIDs := indexedValues(form, "Instances.member.%d.InstanceId")
+	for _, instanceID := range instanceIDs {
+		if _, ok := s.findInstance(instanceID); !ok {
+			return nil, newAPIError(helper.ErrorCodeInstanceNotFound, "The instance_id '%s' does not exist.", instanceID)
+		}
+		if lo.Contains(l.instanceIDs, instanceID) {
+			return nil, newAPIError(helper.ErrorCodeLoadBalancerHavingRegisteredTarget,
+				"The instance_id '%s' has already been registered with '%s'.", instanceID, lb.name)
+		}
+	}
+	l.instanceIDs = append(l.instanceIDs, instanceIDs...)
+
+	return &emptyResponse{}, nil
+}
+
+func (s *Server) deregisterInstancesFromLoadBalancer(form url.Values) (interface{}, error) {
+	lb, l, err := s.findLoadBalancerListener(form)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceIDs := indexedValues(form, "Instances.member.%d.InstanceId")
+	for _, instanceID := range instanceIDs {
+		if !lo.Contains(l.instanceIDs, instanceID) {
+			return nil, newAPIError(helper.ErrorCodeInstanceNotFound,
+				"The instance_id '%s' is not registered with '%s'.", instanceID, lb.name)
+		}
+	}
+	l.instanceIDs = lo.Without(l.instanceIDs, instanceIDs...)
+
+	return &emptyResponse{}, nil
+}
+
+func (s *Server) deleteLoadBalancer(form url.Values) (interface{}, error) {
+	lb, l, err := s.findLoadBalancerListener(form)
+	if err != nil {
+		return nil, err
+	}
+
+	lb.listeners = lo.Without(lb.listeners, l)
+	if len(lb.listeners) == 0 {
+		s.loadBalancers = lo.Without(s.loadBalancers, lb)
+	}
+
+	return &emptyResponse{}, nil
+}
+
+func (s *Server) updateLoadBalancer(form url.Values) (interface{}, error) {
+	lb, err := s.findLoadBalancer(form.Get("LoadBalancerName"))
+	if err != nil {
+		return nil, err
+	}
+
+	if networkVolume := formInt32(form, "NetworkVolumeUpdate"); networkVolume != 0 {
+		lb.networkVolume = networkVolume
+	}
+	if accountingType := form.Get("AccountingTypeUpdate"); accountingType != "" {
+		lb.accountingType = accountingType
+	}
+	if name := form.Get("LoadBalancerNameUpdate"); name != "" {
+		if _, err := s.findLoadBalancer(name); err == nil {
+			return nil, newAPIError(helper.ErrorCodeLoadBalancerDuplicate, "The LoadBalancerName '%s' has already been registered.", name)
+		}
+		lb.name = name
+	}
+
+	return &emptyResponse{}, nil
+}
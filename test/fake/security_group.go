@@ -0,0 +1,198 @@
+package fake
+
+import (
+	"encoding/xml"
+	"net/url"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/test/helper"
+	"github.com/samber/lo"
+)
+
+const securityGroupStatusApplied = "applied"
+
+type securityGroup struct {
+	name        string
+	instanceIDs []string
+	// a rule has only one of IpRanges or Groups to be compared easily
+	rules []nifcloud.SecurityGroupRule
+}
+
+type describeSecurityGroupsResponse struct {
+	XMLName        xml.Name            `xml:"DescribeSecurityGroupsResponse"`
+	RequestID      string              `xml:"requestId"`
+	SecurityGroups []securityGroupItem `xml:"securityGroupInfo>item"`
+}
+
+type securityGroupItem struct {
+	GroupName     string             `xml:"groupName"`
+	GroupStatus   string             `xml:"groupStatus"`
+	IPPermissions []ipPermissionItem `xml:"ipPermissions>item"`
+	Instances     []instanceIDItem   `xml:"instancesSet>item"`
+}
+
+type instanceIDItem struct {
+	InstanceID string `xml:"instanceId"`
+}
+
+type ipPermissionItem struct {
+	IPProtocol string        `xml:"ipProtocol"`
+	FromPort   int32         `xml:"fromPort,omitempty"`
+	ToPort     int32         `xml:"toPort,omitempty"`
+	InOut      string        `xml:"inOut"`
+	IPRanges   []ipRangeItem `xml:"ipRanges>item"`
+	Groups     []groupItem   `xml:"groups>item"`
+}
+
+type ipRangeItem struct {
+	CidrIP string `xml:"cidrIp"`
+}
+
+type groupItem struct {
+	GroupName string `xml:"groupName"`
+}
+
+// AddSecurityGroup adds the security group which the instances belong to
+func (s *Server) AddSecurityGroup(name string, instanceIDs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.securityGroups = append(s.securityGroups, &securityGroup{
+		name:        name,
+		instanceIDs: instanceIDs,
+	})
+}
+
+// SecurityGroupRules returns the rules of the security group. Each rule has one IP range or group.
+func (s *Server) SecurityGroupRules(name string) []nifcloud.SecurityGroupRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sg, err := s.findSecurityGroup(name)
+	if err != nil {
+		return nil
+	}
+	return append([]nifcloud.SecurityGroupRule{}, sg.rules...)
+}
+
+func (s *Server) findSecurityGroup(name string) (*securityGroup, error) {
+	sg, ok := lo.Find(s.securityGroups, func(sg *securityGroup) bool { return sg.name == name })
+	if !ok {
+		return nil, newAPIError(helper.ErrorCodeSecurityGroupNotFound, "The groupName '%s' does not exist.", name)
+	}
+	return sg, nil
+}
+
+func (s *Server) describeSecurityGroups(form url.Values) (interface{}, error) {
+	securityGroups := s.securityGroups
+	if names := indexedValues(form, "GroupName.%d"); len(names) > 0 {
+		securityGroups = []*securityGroup{}
+		for _, name := range names {
+			sg, err := s.findSecurityGroup(name)
+			if err != nil {
+				return nil, err
+			}
+			securityGroups = append(securityGroups, sg)
+		}
+	}
+
+	res := &describeSecurityGroupsResponse{RequestID: s.requestID()}
+	for _, sg := range securityGroups {
+		item := securityGroupItem{
+			GroupName: sg.name,
+			// the rules are applied immediately not to make the waiters sleep
+			GroupStatus: securityGroupStatusApplied,
+		}
+		for _, instanceID := range sg.instanceIDs {
+			item.Instances = append(item.Instances, instanceIDItem{InstanceID: instanceID})
+		}
+		for _, rule := range sg.rules {
+			permission := ipPermissionItem{
+				IPProtocol: rule.IpProtocol,
+				FromPort:   rule.FromPort,
+				ToPort:     rule.ToPort,
+				InOut:      rule.InOut,
+			}
+			for _, cidr := range rule.IpRanges {
+				permission.IPRanges = append(permission.IPRanges, ipRangeItem{CidrIP: cidr})
+			}
+			for _, group := range rule.Groups {
+				permission.Groups = append(permission.Groups, groupItem{GroupName: group})
+			}
+			item.IPPermissions = append(item.IPPermissions, permission)
+		}
+		res.SecurityGroups = append(res.SecurityGroups, item)
+	}
+	return res, nil
+}
+
+// securityGroupRulesOf returns the rules in the IpPermissions parameter split by IP range and group
+func securityGroupRulesOf(form url.Values) []nifcloud.SecurityGroupRule {
+	rules := []nifcloud.SecurityGroupRule{}
+	for _, prefix := range indexedPrefixes(form, "IpPermissions.%d.") {
+		base := nifcloud.SecurityGroupRule{
+			IpProtocol: form.Get(prefix + "IpProtocol"),
+			FromPort:   formInt32(form, prefix+"FromPort"),
+			ToPort:     formInt32(form, prefix+"ToPort"),
+			InOut:      form.Get(prefix + "InOut"),
+		}
+		if base.InOut == "" {
+			base.InOut = "IN"
+		}
+		// a single port is specified only with FromPort
+		if base.ToPort == 0 {
+			base.ToPort = base.FromPort
+		}
+		for _, cidr := range indexedValues(form, prefix+"IpRanges.%d.CidrIp") {
+			rule := base
+			rule.IpRanges = []string{cidr}
+			rules = append(rules, rule)
+		}
+		for _, group := range indexedValues(form, prefix+"Groups.%d.GroupName") {
+			rule := base
+			rule.Groups = []string{group}
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func equalSecurityGroupRules(a, b nifcloud.SecurityGroupRule) bool {
+	return a.String() == b.String()
+}
+
+func (s *Server) authorizeSecurityGroupIngress(form url.Values) (interface{}, error) {
+	sg, err := s.findSecurityGroup(form.Get("GroupName"))
+	if err != nil {
+		return nil, err
+	}
+
+	rules := securityGroupRulesOf(form)
+	for _, rule := range rules {
+		if lo.ContainsBy(sg.rules, func(r nifcloud.SecurityGroupRule) bool { return equalSecurityGroupRules(r, rule) }) {
+			return nil, newAPIError(helper.ErrorCodeSecurityGroupDuplicate, "The rule %s of '%s' has already been registered.", rule.String(), sg.name)
+		}
+	}
+	sg.rules = append(sg.rules, rules...)
+
+	return &returnResponse{}, nil
+}
+
+func (s *Server) revokeSecurityGroupIngress(form url.Values) (interface{}, error) {
+	sg, err := s.findSecurityGroup(form.Get("GroupName"))
+	if err != nil {
+		return nil, err
+	}
+
+	rules := securityGroupRulesOf(form)
+	for _, rule := range rules {
+		if !lo.ContainsBy(sg.rules, func(r nifcloud.SecurityGroupRule) bool { return equalSecurityGroupRules(r, rule) }) {
+			return nil, newAPIError(helper.ErrorCodeSecurityGroupIngressNotFound, "The rule %s of '%s' does not exist.", rule.String(), sg.name)
+		}
+	}
+	sg.rules = lo.Reject(sg.rules, func(r nifcloud.SecurityGroupRule, _ int) bool {
+		return lo.ContainsBy(rules, func(rule nifcloud.SecurityGroupRule) bool { return equalSecurityGroupRules(r, rule) })
+	})
+
+	return &returnResponse{}, nil
+}
@@ -0,0 +1,268 @@
+// Package fake provides an in-memory fake of the NIFCLOUD computing API.
+// It keeps the state of instances, load balancers, elastic load balancers and security groups,
+// so that the real API client can be tested without accessing NIFCLOUD.
+package fake
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nifcloud/nifcloud-cloud-controller-manager/pkg/cloudprovider/providers/nifcloud"
+)
+
+// error code returned by the fake server for the action which it does not implement
+const errorCodeUnsupportedAction = "Client.InvalidParameterNotSupported.Action"
+
+// APIError is the error response of the fake server
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func newAPIError(code, format string, args ...interface{}) *APIError {
+	return &APIError{
+		StatusCode: http.StatusBadRequest,
+		Code:       code,
+		Message:    fmt.Sprintf(format, args...),
+	}
+}
+
+type errorResponse struct {
+	XMLName   xml.Name `xml:"Response"`
+	Code      string   `xml:"Errors>Error>Code"`
+	Message   string   `xml:"Errors>Error>Message"`
+	RequestID string   `xml:"RequestID"`
+}
+
+// emptyResponse is the response of the actions which return nothing but the request id
+type emptyResponse struct {
+	XMLName   xml.Name
+	RequestID string `xml:"ResponseMetadata>RequestId"`
+}
+
+type returnResponse struct {
+	XMLName   xml.Name
+	RequestID string `xml:"requestId"`
+	Return    bool   `xml:"return"`
+}
+
+type handlerFunc func(form url.Values) (interface{}, error)
+
+// Server is the fake NIFCLOUD computing API server.
+// Point the API client at Server.URL, seed the instances and security groups,
+// and inspect the resulting state after calling the cloud provider.
+type Server struct {
+	*httptest.Server
+
+	mu                   sync.Mutex
+	instances            []nifcloud.Instance
+	loadBalancers        []*loadBalancer
+	elasticLoadBalancers []*elasticLoadBalancer
+	securityGroups       []*securityGroup
+	requests             []string
+	injectedErrors       map[string][]*APIError
+	requestSeq           int
+	ipAddressSeq         int
+}
+
+// NewServer starts a new fake server. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		injectedErrors: map[string][]*APIError{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *Server) handlers() map[string]handlerFunc {
+	return map[string]handlerFunc{
+		// instances
+		"DescribeInstances": s.describeInstances,
+
+		// load balancers
+		"DescribeLoadBalancers":               s.describeLoadBalancers,
+		"CreateLoadBalancer":                  s.createLoadBalancer,
+		"RegisterPortWithLoadBalancer":        s.registerPortWithLoadBalancer,
+		"ConfigureHealthCheck":                s.configureHealthCheck,
+		"SetFilterForLoadBalancer":            s.setFilterForLoadBalancer,
+		"RegisterInstancesWithLoadBalancer":   s.registerInstancesWithLoadBalancer,
+		"DeregisterInstancesFromLoadBalancer": s.deregisterInstancesFromLoadBalancer,
+		"DeleteLoadBalancer":                  s.deleteLoadBalancer,
+		"UpdateLoadBalancer":                  s.updateLoadBalancer,
+
+		// elastic load balancers
+		"NiftyDescribeElasticLoadBalancers":               s.describeElasticLoadBalancers,
+		"NiftyCreateElasticLoadBalancer":                  s.createElasticLoadBalancer,
+		"NiftyRegisterPortWithElasticLoadBalancer":        s.registerPortWithElasticLoadBalancer,
+		"NiftyConfigureElasticLoadBalancerHealthCheck":    s.configureElasticLoadBalancerHealthCheck,
+		"NiftyRegisterInstancesWithElasticLoadBalancer":   s.registerInstancesWithElasticLoadBalancer,
+		"NiftyDeregisterInstancesFromElasticLoadBalancer": s.deregisterInstancesFromElasticLoadBalancer,
+		"NiftyDeleteElasticLoadBalancer":                  s.deleteElasticLoadBalancer,
+		"NiftyUpdateElasticLoadBalancer":                  s.updateElasticLoadBalancer,
+
+		// security groups
+		"DescribeSecurityGroups":        s.describeSecurityGroups,
+		"AuthorizeSecurityGroupIngress": s.authorizeSecurityGroupIngress,
+		"RevokeSecurityGroupIngress":    s.revokeSecurityGroupIngress,
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.writeError(w, newAPIError("Client.InvalidParameter", "failed to parse form: %v", err))
+		return
+	}
+	action := r.Form.Get("Action")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requestSeq++
+	s.requests = append(s.requests, action)
+
+	if errs := s.injectedErrors[action]; len(errs) > 0 {
+		s.injectedErrors[action] = errs[1:]
+		s.writeError(w, errs[0])
+		return
+	}
+
+	handler, ok := s.handlers()[action]
+	if !ok {
+		s.writeError(w, newAPIError(errorCodeUnsupportedAction, "action %q is not supported by the fake server", action))
+		return
+	}
+
+	res, err := handler(r.Form)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	switch res := res.(type) {
+	case *emptyResponse:
+		res.XMLName.Local = action + "Response"
+		res.RequestID = s.requestID()
+	case *returnResponse:
+		res.XMLName.Local = action + "Response"
+		res.RequestID = s.requestID()
+		res.Return = true
+	}
+	s.writeXML(w, http.StatusOK, res)
+}
+
+func (s *Server) requestID() string {
+	return fmt.Sprintf("fake-request-%d", s.requestSeq)
+}
+
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		apiErr = &APIError{StatusCode: http.StatusInternalServerError, Code: "Server.InternalError", Message: err.Error()}
+	}
+	s.writeXML(w, apiErr.StatusCode, &errorResponse{
+		Code:      apiErr.Code,
+		Message:   apiErr.Message,
+		RequestID: s.requestID(),
+	})
+}
+
+func (s *Server) writeXML(w http.ResponseWriter, statusCode int, v interface{}) {
+	body, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml;charset=UTF-8")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write([]byte(xml.Header))
+	_, _ = w.Write(body)
+}
+
+// allocateIPAddress returns an unused global IP address for VIPs
+func (s *Server) allocateIPAddress() string {
+	s.ipAddressSeq++
+	return fmt.Sprintf("198.51.100.%d", s.ipAddressSeq)
+}
+
+// InjectError makes the next call of the action fail with the error.
+// Multiple errors for the same action are returned in order.
+func (s *Server) InjectError(action string, err *APIError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err.StatusCode == 0 {
+		err.StatusCode = http.StatusBadRequest
+	}
+	s.injectedErrors[action] = append(s.injectedErrors[action], err)
+}
+
+// Requests returns the actions called so far in order
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.requests...)
+}
+
+// RequestCount returns how many times the action is called
+func (s *Server) RequestCount(action string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, r := range s.requests {
+		if r == action {
+			count++
+		}
+	}
+	return count
+}
+
+// indexedValues returns the values of the keys made by the format and the index from 1,
+// e.g. "Instances.member.%d.InstanceId"
+func indexedValues(form url.Values, format string) []string {
+	values := []string{}
+	for i := 1; ; i++ {
+		key := fmt.Sprintf(format, i)
+		if _, ok := form[key]; !ok {
+			return values
+		}
+		values = append(values, form.Get(key))
+	}
+}
+
+// indexedPrefixes returns the prefixes made by the format and the index from 1
+// while any key starts with the prefix, e.g. "IpPermissions.%d."
+func indexedPrefixes(form url.Values, format string) []string {
+	prefixes := []string{}
+	for i := 1; ; i++ {
+		prefix := fmt.Sprintf(format, i)
+		found := false
+		for key := range form {
+			if strings.HasPrefix(key, prefix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return prefixes
+		}
+		prefixes = append(prefixes, prefix)
+	}
+}
+
+func formInt32(form url.Values, key string) int32 {
+	v, _ := strconv.ParseInt(form.Get(key), 10, 32)
+	return int32(v)
+}
@@ -0,0 +1,23 @@
+package helper
+
+// Error codes of the NIFCLOUD API returned by the mocks and the fake server
+const (
+	ErrorCodeInstanceNotFound = "Client.InvalidParameterNotFound.Instance"
+
+	ErrorCodeLoadBalancerNotFound               = "Client.InvalidParameterNotFound.LoadBalancer"
+	ErrorCodeLoadBalancerPortNotFound           = "Client.InvalidParameterNotFound.LoadBalancerPort"
+	ErrorCodeLoadBalancerDuplicate              = "Client.InvalidParameterDuplicate.LoadBalancer"
+	ErrorCodeLoadBalancerPortAlreadyExists      = "Client.Inoperable.LoadBalancerPort.AlreadyExists"
+	ErrorCodeLoadBalancerHavingRegisteredTarget = "Client.Inoperable.LoadBalancer.HavingRegisteredInstance"
+	ErrorCodeIPAddressDuplicate                 = "Client.InvalidParameterDuplicate.IpAddress"
+
+	ErrorCodeElasticLoadBalancerNotFound               = "Client.InvalidParameterNotFound.ElasticLoadBalancer"
+	ErrorCodeElasticLoadBalancerPortNotFound           = "Client.InvalidParameterNotFound.Protocol.or.ElasticLoadBalancerPort"
+	ErrorCodeElasticLoadBalancerDuplicate              = "Client.InvalidParameterDuplicate.ElasticLoadBalancer"
+	ErrorCodeElasticLoadBalancerPortDuplicate          = "Client.InvalidParameterDuplicate.Protocol.and.ElasticLoadBalancerPort"
+	ErrorCodeElasticLoadBalancerHavingRegisteredTarget = "Client.Inoperable.ElasticLoadBalancer.HavingRegisteredInstance"
+
+	ErrorCodeSecurityGroupNotFound        = "Client.InvalidParameterNotFound.SecurityGroup"
+	ErrorCodeSecurityGroupIngressNotFound = "Client.InvalidParameterNotFound.SecurityGroupIngress"
+	ErrorCodeSecurityGroupDuplicate       = "Client.InvalidParameterDuplicate.SecurityGroup"
+)
@@ -15,3 +15,22 @@ func NewTestInstance() *nifcloud.Instance {
 		State:            "running",
 	}
 }
+
+// NewTestInstanceWithNetworkInterfaces returns the instance in testdata/describe_instances*.xml
+func NewTestInstanceWithNetworkInterfaces() *nifcloud.Instance {
+	instance := NewTestInstance()
+	instance.AccountingType = "1"
+	instance.ImageID = "283"
+	instance.Tenancy = "default"
+	instance.NetworkInterfaces = []nifcloud.InstanceNetworkInterface{
+		{
+			NetworkID: "net-COMMON_GLOBAL",
+			IPAddress: "203.0.113.1",
+		},
+		{
+			NetworkID: "net-COMMON_PRIVATE",
+			IPAddress: "192.168.0.100",
+		},
+	}
+	return instance
+}
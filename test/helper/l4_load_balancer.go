@@ -21,6 +21,7 @@ func NewTestL4LoadBalancer(name string) []nifcloud.LoadBalancer {
 			HealthCheckTarget:             "TCP:30000",
 			HealthCheckInterval:           10,
 			HealthCheckUnhealthyThreshold: 1,
+			FilterType:                    "1",
 			Filters:                       []string{},
 		},
 	}
@@ -43,6 +44,7 @@ func NewTestL4LoadBalancerWithTwoPort(name string) []nifcloud.LoadBalancer {
 			HealthCheckTarget:             "TCP:30000",
 			HealthCheckInterval:           10,
 			HealthCheckUnhealthyThreshold: 1,
+			FilterType:                    "1",
 			Filters:                       []string{},
 		},
 		{
@@ -57,6 +59,7 @@ func NewTestL4LoadBalancerWithTwoPort(name string) []nifcloud.LoadBalancer {
 			HealthCheckTarget:             "TCP:30001",
 			HealthCheckInterval:           10,
 			HealthCheckUnhealthyThreshold: 1,
+			FilterType:                    "1",
 			Filters:                       []string{},
 		},
 	}